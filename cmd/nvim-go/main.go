@@ -89,9 +89,9 @@ func Main(ctx context.Context, p *plugin.Plugin) error {
 	log := logger.FromContext(ctx).Named("main")
 	ctx = logger.NewContext(ctx, log)
 
-	buildctxt := buildctx.NewContext()
-	c := command.Register(ctx, p, buildctxt)
-	autocmd.Register(ctx, p, buildctxt, c)
+	buildContexts := buildctx.NewRegistry()
+	c := command.Register(ctx, p, buildContexts)
+	autocmd.Register(ctx, p, buildContexts, c)
 
 	if debug {
 		// starts the gops agent
@@ -1,11 +1,12 @@
 highlight delveBreakpointSign  guifg=#cc1100  guibg=None
 highlight delveTracepointSign  guifg=#5398d0  guibg=None
+highlight delveConditionalBreakpointSign  guifg=#e08a00  guibg=None
 
 highlight delvePCSign          guifg=#bbbb00  guibg=None
 highlight delvePCLine          guifg=None     guibg=#343941
 
 highlight delveCallStackSign   guifg=#9cce9c  guibg=None
-highlight delveCallStackSign   guibg=#394d39  guibg=None
+highlight delveCallStackLine   guifg=None     guibg=#394d39
 
 highlight GoCoverMiss          guifg=#5f0000  guibg=None
 highlight GoCoverPartial       guifg=#f0c674  guibg=None
@@ -31,6 +31,9 @@ nnoremap <silent><Plug>(nvim-go-metalinter)  :<C-u>Gometalinter<CR>
 
 " GoTest
 nnoremap <silent><Plug>(nvim-go-test)         :<C-u>Gotest<CR>
+nnoremap <silent><Plug>(nvim-go-test-func)    :<C-u>GoTestFunc<CR>
+nnoremap <silent><Plug>(nvim-go-test-failed)  :<C-u>GoTestFailed<CR>
+nnoremap <silent><Plug>(nvim-go-test-explorer)  :<C-u>GoTestExplorer<CR>
 nnoremap <silent><Plug>(nvim-go-switch-test)  :<C-u>GoSwitchTest<CR>
 
 " GoRename
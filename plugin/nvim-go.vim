@@ -38,41 +38,72 @@ endfunction
 
 call remote#host#Register(s:plugin_name, '', function('s:JobStart'))
 call remote#host#RegisterPlugin('nvim-go', '0', [
-\ {'type': 'autocmd', 'name': 'BufEnter', 'sync': 0, 'opts': {'eval': '{''BufNr'': bufnr(''%''), ''WinID'': win_getid(), ''Dir'': expand(''%:p:h''), ''Cfg'': {''Global'': {''ServerName'': v:servername, ''ErrorListType'': get(g:, ''go#global#errorlisttype'', ''locationlist'')}, ''Build'': {''Appengine'': get(g:, ''go#build#appengine'', 0), ''Autosave'': get(g:, ''go#build#autosave'', 0), ''Force'': get(g:, ''go#build#force'', 0), ''Flags'': get(g:, ''go#build#flags'', []), ''IsNotGb'': get(g:, ''go#build#is_not_gb'', 0)}, ''Cover'': {''Flags'': get(g:, ''go#cover#flags'', []), ''Mode'': get(g:, ''go#cover#mode'', '''')}, ''Fmt'': {''Autosave'': get(g:, ''go#fmt#autosave'', 0), ''Mode'': get(g:, ''go#fmt#mode'', ''goimports'')}, ''Generate'': {''TestAllFuncs'': get(g:, ''go#generate#test#allfuncs'', 1), ''TestExclFuncs'': get(g:, ''go#generate#test#exclude'', ''''), ''TestExportedFuncs'': get(g:, ''go#generate#test#exportedfuncs'', 0), ''TestSubTest'': get(g:, ''go#generate#test#subtest'', 1)}, ''Guru'': {''Reflection'': get(g:, ''go#guru#reflection'', 0), ''KeepCursor'': get(g:, ''go#guru#keep_cursor'', {''callees'':0,''callers'':0,''callstack'':0,''definition'':0,''describe'':0,''freevars'':0,''implements'':0,''peers'':0,''pointsto'':0,''referrers'':0,''whicherrs'':0}), ''JumpFirst'': get(g:, ''go#guru#jump_first'', 0)}, ''Iferr'': {''Autosave'': get(g:, ''go#iferr#autosave'', 0)}, ''Lint'': {''GolintAutosave'': get(g:, ''go#lint#golint#autosave'', 0), ''GolintIgnore'': get(g:, ''go#lint#golint#ignore'', []), ''GolintMinConfidence'': get(g:, ''go#lint#golint#min_confidence'', 0.8), ''GolintMode'': get(g:, ''go#lint#golint#mode'', ''current''), ''GoVetAutosave'': get(g:, ''go#lint#govet#autosave'', 0), ''GoVetFlags'': get(g:, ''go#lint#govet#flags'', []), ''GoVetIgnore'': get(g:, ''go#lint#govet#ignore'', []), ''MetalinterAutosave'': get(g:, ''go#lint#metalinter#autosave'', 0), ''MetalinterAutosaveTools'': get(g:, ''go#lint#metalinter#autosave#tools'', [''vet'', ''golint'']), ''MetalinterTools'': get(g:, ''go#lint#metalinter#tools'', [''vet'', ''golint'']), ''MetalinterDeadline'': get(g:, ''go#lint#metalinter#deadline'', ''5s''), ''MetalinterSkipDir'': get(g:, ''go#lint#metalinter#skip_dir'', [])}, ''Rename'': {''Prefill'': get(g:, ''go#rename#prefill'', 0)}, ''Terminal'': {''Mode'': get(g:, ''go#terminal#mode'', ''vsplit''), ''Position'': get(g:, ''go#terminal#position'', ''belowright''), ''Height'': get(g:, ''go#terminal#height'', 0), ''Width'': get(g:, ''go#terminal#width'', 0), ''StopInsert'': get(g:, ''go#terminal#stop_insert'', 1)}, ''Test'': {''AllPackage'': get(g:, ''go#test#all_package'', 0), ''Autosave'': get(g:, ''go#test#autosave'', 0), ''Flags'': get(g:, ''go#test#flags'', [])}, ''Debug'': {''Enable'': get(g:, ''go#debug'', 0), ''Pprof'': get(g:, ''go#debug#pprof'', 0)}}}', 'group': 'nvim-go', 'pattern': '*.go'}},
+\ {'type': 'autocmd', 'name': 'BufEnter', 'sync': 0, 'opts': {'eval': '{''BufNr'': bufnr(''%''), ''File'': expand(''%:p''), ''Dir'': expand(''%:p:h''), ''Cfg'': {''Global'': {''ServerName'': v:servername, ''ErrorListType'': get(g:, ''go#global#errorlisttype'', ''locationlist'')}, ''Build'': {''Appengine'': get(g:, ''go#build#appengine'', 0), ''Autosave'': get(g:, ''go#build#autosave'', 0), ''Force'': get(g:, ''go#build#force'', 0), ''Flags'': get(g:, ''go#build#flags'', []), ''IsNotGb'': get(g:, ''go#build#is_not_gb'', 0)}, ''Check'': {''Enable'': get(g:, ''go#check#enable'', 0), ''Delay'': get(g:, ''go#check#delay'', 500)}, ''Cover'': {''Flags'': get(g:, ''go#cover#flags'', []), ''Mode'': get(g:, ''go#cover#mode'', ''''), ''Style'': get(g:, ''go#cover#style'', ''highlight''), ''Counts'': get(g:, ''go#cover#counts'', 0)}, ''Diagnostic'': {''Echo'': get(g:, ''go#diagnostic#echo'', 1), ''Signs'': get(g:, ''go#diagnostic#signs'', 1), ''VirtualText'': get(g:, ''go#diagnostic#virtualtext'', 1)}, ''Fmt'': {''Autosave'': get(g:, ''go#fmt#autosave'', 0), ''Mode'': get(g:, ''go#fmt#mode'', ''goimports'')}, ''Generate'': {''TestAllFuncs'': get(g:, ''go#generate#test#allfuncs'', 1), ''TestExclFuncs'': get(g:, ''go#generate#test#exclude'', ''''), ''TestExportedFuncs'': get(g:, ''go#generate#test#exportedfuncs'', 0), ''TestSubTest'': get(g:, ''go#generate#test#subtest'', 1)}, ''Guru'': {''Reflection'': get(g:, ''go#guru#reflection'', 0), ''KeepCursor'': get(g:, ''go#guru#keep_cursor'', {''callees'':0,''callers'':0,''callstack'':0,''definition'':0,''describe'':0,''freevars'':0,''implements'':0,''peers'':0,''pointsto'':0,''referrers'':0,''whicherrs'':0}), ''JumpFirst'': get(g:, ''go#guru#jump_first'', 0)}, ''Iferr'': {''Autosave'': get(g:, ''go#iferr#autosave'', 0)}, ''Lint'': {''GolintAutosave'': get(g:, ''go#lint#golint#autosave'', 0), ''GolintIgnore'': get(g:, ''go#lint#golint#ignore'', []), ''GolintMinConfidence'': get(g:, ''go#lint#golint#min_confidence'', 0.8), ''GolintMode'': get(g:, ''go#lint#golint#mode'', ''current''), ''GoVetAutosave'': get(g:, ''go#lint#govet#autosave'', 0), ''GoVetFlags'': get(g:, ''go#lint#govet#flags'', []), ''GoVetIgnore'': get(g:, ''go#lint#govet#ignore'', []), ''MetalinterAutosave'': get(g:, ''go#lint#metalinter#autosave'', 0), ''MetalinterAutosaveTools'': get(g:, ''go#lint#metalinter#autosave#tools'', [''vet'', ''golint'']), ''MetalinterTools'': get(g:, ''go#lint#metalinter#tools'', [''vet'', ''golint'']), ''MetalinterDeadline'': get(g:, ''go#lint#metalinter#deadline'', ''5s''), ''MetalinterSkipDir'': get(g:, ''go#lint#metalinter#skip_dir'', [])}, ''Rename'': {''Prefill'': get(g:, ''go#rename#prefill'', 0)}, ''Terminal'': {''Mode'': get(g:, ''go#terminal#mode'', ''vsplit''), ''Position'': get(g:, ''go#terminal#position'', ''belowright''), ''Height'': get(g:, ''go#terminal#height'', 0), ''Width'': get(g:, ''go#terminal#width'', 0), ''StopInsert'': get(g:, ''go#terminal#stop_insert'', 1)}, ''Test'': {''AllPackage'': get(g:, ''go#test#all_package'', 0), ''Autosave'': get(g:, ''go#test#autosave'', 0), ''Flags'': get(g:, ''go#test#flags'', []), ''JSON'': get(g:, ''go#test#json'', 0)}, ''Delve'': {''EvalFloat'': get(g:, ''go#delve#eval#float'', 1), ''MaxRecurse'': get(g:, ''go#delve#max_variable_recurse'', 1), ''MaxStringLen'': get(g:, ''go#delve#max_string_len'', 64), ''MaxArrayValues'': get(g:, ''go#delve#max_array_values'', 64), ''Backend'': get(g:, ''go#delve#backend'', ''rpc2'')}, ''Debug'': {''Enable'': get(g:, ''go#debug'', 0), ''Pprof'': get(g:, ''go#debug#pprof'', 0)}}}', 'group': 'nvim-go', 'pattern': '*.go'}},
 \ {'type': 'autocmd', 'name': 'BufNewFile,BufReadPre', 'sync': 0, 'opts': {'eval': '{}', 'group': 'nvim-go-autocmd', 'pattern': '*.go'}},
-\ {'type': 'autocmd', 'name': 'BufWritePost', 'sync': 0, 'opts': {'eval': '{''Cwd'': getcwd(), ''File'': expand(''%:p'')}', 'group': 'nvim-go', 'pattern': '*.go'}},
-\ {'type': 'autocmd', 'name': 'BufWritePre', 'sync': 0, 'opts': {'eval': '{''Cwd'': getcwd(), ''File'': expand(''%:p'')}', 'group': 'nvim-go', 'pattern': '*.go'}},
-\ {'type': 'autocmd', 'name': 'VimLeavePre', 'sync': 0, 'opts': {'group': 'nvim-go', 'pattern': '*.go,terminal,context,thread'}},
+\ {'type': 'autocmd', 'name': 'BufWinEnter', 'sync': 0, 'opts': {'eval': '[expand(''%:p'')]', 'group': 'nvim-go', 'pattern': '*.go'}},
+\ {'type': 'autocmd', 'name': 'BufWipeout', 'sync': 0, 'opts': {'eval': '{''BufNr'': str2nr(expand(''<abuf>''))}', 'group': 'nvim-go', 'pattern': '*.go'}},
+\ {'type': 'autocmd', 'name': 'BufWritePost', 'sync': 0, 'opts': {'eval': '{''Cwd'': getcwd(), ''File'': expand(''%:p''), ''BufNr'': bufnr(''%''), ''WinID'': win_getid()}', 'group': 'nvim-go', 'pattern': '*.go'}},
+\ {'type': 'autocmd', 'name': 'BufWritePre', 'sync': 0, 'opts': {'eval': '{''Cwd'': getcwd(), ''File'': expand(''%:p''), ''BufNr'': bufnr(''%'')}', 'group': 'nvim-go', 'pattern': '*.go'}},
+\ {'type': 'autocmd', 'name': 'CursorHold', 'sync': 0, 'opts': {'eval': '{''Cwd'': getcwd(), ''File'': expand(''%:p''), ''BufNr'': bufnr(''%''), ''Line'': line(''.'')}', 'group': 'nvim-go', 'pattern': '*.go'}},
+\ {'type': 'autocmd', 'name': 'TextChanged,TextChangedI', 'sync': 0, 'opts': {'eval': '{''Cwd'': getcwd(), ''File'': expand(''%:p''), ''BufNr'': bufnr(''%'')}', 'group': 'nvim-go', 'pattern': '*.go'}},
+\ {'type': 'autocmd', 'name': 'VimLeavePre', 'sync': 0, 'opts': {'group': 'nvim-go', 'pattern': '*.go,terminal,context,thread,output'}},
+\ {'type': 'command', 'name': 'DlvAttach', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p:h''), bufnr(''%'')]', 'nargs': '+'}},
 \ {'type': 'command', 'name': 'DlvBreakpoint', 'sync': 0, 'opts': {'complete': 'customlist,FunctionsCompletion', 'eval': '[expand(''%:p'')]', 'nargs': '*'}},
-\ {'type': 'command', 'name': 'DlvConnect', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p:h'')]', 'nargs': '*'}},
+\ {'type': 'command', 'name': 'DlvBreakpointToggle', 'sync': 0, 'opts': {'eval': '[expand(''%:p''), line(''.'')]'}},
+\ {'type': 'command', 'name': 'DlvBreakpoints', 'sync': 0, 'opts': {'eval': '[getcwd()]'}},
+\ {'type': 'command', 'name': 'DlvClearAll', 'sync': 0, 'opts': {}},
+\ {'type': 'command', 'name': 'DlvConnect', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p:h''), bufnr(''%'')]', 'nargs': '*'}},
 \ {'type': 'command', 'name': 'DlvContinue', 'sync': 0, 'opts': {'eval': '[expand(''%:p:h'')]', 'nargs': '*'}},
-\ {'type': 'command', 'name': 'DlvDebug', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p:h'')]', 'nargs': '*'}},
+\ {'type': 'command', 'name': 'DlvDebug', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p:h''), bufnr(''%'')]', 'nargs': '*'}},
 \ {'type': 'command', 'name': 'DlvDetach', 'sync': 0, 'opts': {}},
+\ {'type': 'command', 'name': 'DlvEval', 'sync': 0, 'opts': {'nargs': '*'}},
+\ {'type': 'command', 'name': 'DlvExec', 'sync': 0, 'opts': {'complete': 'file', 'eval': '[getcwd(), expand(''%:p:h''), bufnr(''%'')]', 'nargs': '+'}},
+\ {'type': 'command', 'name': 'DlvInput', 'sync': 0, 'opts': {'nargs': '*'}},
 \ {'type': 'command', 'name': 'DlvNext', 'sync': 0, 'opts': {'eval': '[expand(''%:p:h'')]'}},
+\ {'type': 'command', 'name': 'DlvPause', 'sync': 0, 'opts': {}},
 \ {'type': 'command', 'name': 'DlvRestart', 'sync': 0, 'opts': {}},
+\ {'type': 'command', 'name': 'DlvRunToCursor', 'sync': 0, 'opts': {'eval': '[expand(''%:p:h''), expand(''%:p''), line(''.'')]'}},
+\ {'type': 'command', 'name': 'DlvSet', 'sync': 0, 'opts': {'nargs': '+'}},
 \ {'type': 'command', 'name': 'DlvState', 'sync': 0, 'opts': {}},
 \ {'type': 'command', 'name': 'DlvStdin', 'sync': 0, 'opts': {}},
+\ {'type': 'command', 'name': 'DlvStep', 'sync': 0, 'opts': {'eval': '[expand(''%:p:h'')]'}},
+\ {'type': 'command', 'name': 'DlvStepInstruction', 'sync': 0, 'opts': {'eval': '[expand(''%:p:h'')]'}},
+\ {'type': 'command', 'name': 'DlvStepOut', 'sync': 0, 'opts': {'eval': '[expand(''%:p:h'')]'}},
+\ {'type': 'command', 'name': 'DlvTest', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p:h''), expand(''%:p''), line2byte(line(''.'')) + (col(''.'')-2), bufnr(''%'')]', 'nargs': '*'}},
+\ {'type': 'command', 'name': 'DlvUnwatch', 'sync': 0, 'opts': {'complete': 'customlist,WatchesCompletion', 'nargs': '*'}},
+\ {'type': 'command', 'name': 'DlvWatch', 'sync': 0, 'opts': {'nargs': '+'}},
 \ {'type': 'command', 'name': 'GoBuffers', 'sync': 1, 'opts': {}},
 \ {'type': 'command', 'name': 'GoByteOffset', 'sync': 1, 'opts': {'eval': 'expand(''%:p'')'}},
-\ {'type': 'command', 'name': 'GoCover', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p'')]'}},
-\ {'type': 'command', 'name': 'GoGenerateTest', 'sync': 0, 'opts': {'addr': 'line', 'bang': '', 'complete': 'file', 'eval': 'expand(''%:p:h'')', 'nargs': '*', 'range': '%'}},
-\ {'type': 'command', 'name': 'GoIferr', 'sync': 0, 'opts': {'eval': 'expand(''%:p'')'}},
-\ {'type': 'command', 'name': 'GoSwitchTest', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p''), line2byte(line(''.'')) + (col(''.'')-2)]'}},
+\ {'type': 'command', 'name': 'GoCheck', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p''), bufnr(''%'')]'}},
+\ {'type': 'command', 'name': 'GoCover', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p''), bufnr(''%'')]'}},
+\ {'type': 'command', 'name': 'GoCoverClear', 'sync': 0, 'opts': {}},
+\ {'type': 'command', 'name': 'GoCoverDiff', 'sync': 0, 'opts': {}},
+\ {'type': 'command', 'name': 'GoCoverExport', 'sync': 0, 'opts': {'complete': 'file', 'eval': '[getcwd(), bufnr(''%'')]', 'nargs': '?'}},
+\ {'type': 'command', 'name': 'GoCoverLoad', 'sync': 0, 'opts': {'complete': 'file', 'eval': '[getcwd(), bufnr(''%'')]', 'nargs': '1'}},
+\ {'type': 'command', 'name': 'GoCoverReport', 'sync': 0, 'opts': {'eval': '[expand(''%:p:h''), bufnr(''%'')]'}},
+\ {'type': 'command', 'name': 'GoCoverToggle', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p''), bufnr(''%'')]'}},
+\ {'type': 'command', 'name': 'GoGenerateTest', 'sync': 0, 'opts': {'addr': 'line', 'bang': '', 'complete': 'file', 'eval': '[expand(''%:p:h''), bufnr(''%'')]', 'nargs': '*', 'range': '%'}},
+\ {'type': 'command', 'name': 'GoIferr', 'sync': 0, 'opts': {'eval': '[expand(''%:p''), bufnr(''%'')]'}},
+\ {'type': 'command', 'name': 'GoSwitchTest', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p''), line2byte(line(''.'')) + (col(''.'')-2), bufnr(''%''), win_getid()]'}},
 \ {'type': 'command', 'name': 'GoTabpages', 'sync': 1, 'opts': {}},
+\ {'type': 'command', 'name': 'GoTestExplorer', 'sync': 0, 'opts': {'eval': '[expand(''%:p:h''), bufnr(''%'')]'}},
+\ {'type': 'command', 'name': 'GoTestFailed', 'sync': 0, 'opts': {'eval': '[expand(''%:p:h''), bufnr(''%'')]'}},
+\ {'type': 'command', 'name': 'GoTestFunc', 'sync': 0, 'opts': {'eval': '[expand(''%:p:h''), expand(''%:p''), line2byte(line(''.'')) + (col(''.'')-2), bufnr(''%'')]', 'nargs': '*'}},
 \ {'type': 'command', 'name': 'GoWindows', 'sync': 1, 'opts': {}},
-\ {'type': 'command', 'name': 'Gobuild', 'sync': 0, 'opts': {'bang': '', 'eval': '[getcwd(), expand(''%:p'')]', 'nargs': '*'}},
-\ {'type': 'command', 'name': 'Gofmt', 'sync': 0, 'opts': {'eval': 'expand(''%:p:h'')'}},
-\ {'type': 'command', 'name': 'Golint', 'sync': 0, 'opts': {'complete': 'customlist,GoLintCompletion', 'eval': 'expand(''%:p'')', 'nargs': '?'}},
-\ {'type': 'command', 'name': 'Gometalinter', 'sync': 0, 'opts': {'eval': 'getcwd()'}},
-\ {'type': 'command', 'name': 'Gorename', 'sync': 0, 'opts': {'bang': '', 'eval': '[getcwd(), expand(''%:p''), expand(''<cword>'')]', 'nargs': '?'}},
+\ {'type': 'command', 'name': 'Gobuild', 'sync': 0, 'opts': {'bang': '', 'eval': '[getcwd(), expand(''%:p''), bufnr(''%'')]', 'nargs': '*'}},
+\ {'type': 'command', 'name': 'Gofmt', 'sync': 0, 'opts': {'eval': '[expand(''%:p:h''), bufnr(''%'')]'}},
+\ {'type': 'command', 'name': 'Golint', 'sync': 0, 'opts': {'complete': 'customlist,GoLintCompletion', 'eval': '[expand(''%:p''), bufnr(''%'')]', 'nargs': '?'}},
+\ {'type': 'command', 'name': 'Gometalinter', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p''), bufnr(''%''), win_getid()]'}},
+\ {'type': 'command', 'name': 'Gorename', 'sync': 0, 'opts': {'bang': '', 'eval': '[getcwd(), expand(''%:p''), expand(''<cword>''), bufnr(''%''), win_getid()]', 'nargs': '?'}},
 \ {'type': 'command', 'name': 'Gorun', 'sync': 0, 'opts': {'eval': 'expand(''%:p'')', 'nargs': '*'}},
 \ {'type': 'command', 'name': 'GorunLast', 'sync': 0, 'opts': {'eval': 'expand(''%:p'')'}},
-\ {'type': 'command', 'name': 'Gotest', 'sync': 0, 'opts': {'eval': 'expand(''%:p:h'')', 'nargs': '*'}},
-\ {'type': 'command', 'name': 'Govet', 'sync': 0, 'opts': {'complete': 'customlist,GoVetCompletion', 'eval': '[getcwd(), expand(''%:p'')]', 'nargs': '*'}},
+\ {'type': 'command', 'name': 'Gotest', 'sync': 0, 'opts': {'eval': '[expand(''%:p:h''), bufnr(''%'')]', 'nargs': '*'}},
+\ {'type': 'command', 'name': 'Govet', 'sync': 0, 'opts': {'complete': 'customlist,GoVetCompletion', 'eval': '[getcwd(), expand(''%:p''), bufnr(''%'')]', 'nargs': '*'}},
 \ {'type': 'function', 'name': 'FunctionsCompletion', 'sync': 1, 'opts': {}},
-\ {'type': 'function', 'name': 'GoGuru', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p''), &modified, line2byte(line(''.'')) + (col(''.'')-2)]'}},
+\ {'type': 'function', 'name': 'GoGuru', 'sync': 0, 'opts': {'eval': '[getcwd(), expand(''%:p''), &modified, line2byte(line(''.'')) + (col(''.'')-2), bufnr(''%''), win_getid()]'}},
 \ {'type': 'function', 'name': 'GoLintCompletion', 'sync': 1, 'opts': {'eval': 'getcwd()'}},
 \ {'type': 'function', 'name': 'GoVetCompletion', 'sync': 1, 'opts': {'eval': 'getcwd()'}},
+\ {'type': 'function', 'name': 'WatchesCompletion', 'sync': 1, 'opts': {}},
 \ ])
 
 let &cpo = s:save_cpo
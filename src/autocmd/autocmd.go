@@ -7,6 +7,7 @@ package autocmd
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/neovim/go-client/nvim"
 	"github.com/neovim/go-client/nvim/plugin"
@@ -21,20 +22,27 @@ type Autocmd struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 
-	Nvim         *nvim.Nvim
-	buildContext *buildctx.Context
-	cmd          *command.Command
+	Nvim          *nvim.Nvim
+	buildContexts *buildctx.Registry
+	cmd           *command.Command
 
 	bufWritePostChan chan error
 	bufWritePreChan  chan interface{}
 	mu               sync.Mutex
 	wg               sync.WaitGroup
 
+	checkMu    sync.Mutex
+	checkTimer *time.Timer
+	checkGen   uint64
+
+	echoMu           sync.Mutex
+	diagnosticEchoed bool
+
 	errs *syncmap.Map
 }
 
 // Register registers autocmd to Neovim.
-func Register(pctx context.Context, p *plugin.Plugin, buildContext *buildctx.Context, cmd *command.Command) {
+func Register(pctx context.Context, p *plugin.Plugin, buildContexts *buildctx.Registry, cmd *command.Command) {
 	ctx, cancel := context.WithCancel(pctx)
 	ctx = logger.NewContext(ctx, logger.FromContext(ctx).Named("autocmd"))
 
@@ -42,7 +50,7 @@ func Register(pctx context.Context, p *plugin.Plugin, buildContext *buildctx.Con
 		ctx:              ctx,
 		cancel:           cancel,
 		Nvim:             p.Nvim,
-		buildContext:     buildContext,
+		buildContexts:    buildContexts,
 		cmd:              cmd,
 		bufWritePreChan:  make(chan interface{}),
 		bufWritePostChan: make(chan error),
@@ -65,6 +73,15 @@ func Register(pctx context.Context, p *plugin.Plugin, buildContext *buildctx.Con
 	// If create the new file, does not run the 'BufReadPre', Instead of 'BufNewFile'.
 	p.HandleAutocmd(&plugin.AutocmdOptions{Event: "BufNewFile,BufReadPre", Group: "nvim-go-autocmd", Pattern: "*.go", Eval: "*"}, autocmd.BufReadPre)
 
+	// Handle the change of the buffer text. Type-checks the unsaved buffer with debounce.
+	p.HandleAutocmd(&plugin.AutocmdOptions{Event: "TextChanged,TextChangedI", Pattern: "*.go", Group: "nvim-go", Eval: "*"}, autocmd.textChanged)
+
+	// Handle the cursor holding. Echoes the error message of the cursor line.
+	p.HandleAutocmd(&plugin.AutocmdOptions{Event: "CursorHold", Pattern: "*.go", Group: "nvim-go", Eval: "*"}, autocmd.cursorHold)
+
+	// Handle the wipe out the buffer. Removes the build context of the buffer.
+	p.HandleAutocmd(&plugin.AutocmdOptions{Event: "BufWipeout", Pattern: "*.go", Group: "nvim-go", Eval: "*"}, autocmd.BufWipeout)
+
 	// p.HandleAutocmd(&plugin.AutocmdOptions{Event: "WinEnter", Group: "nvim-go-autocmd", Pattern: "*.go", Eval: "*"}, autocmd.WinEnter)
 
 	// Handle the before the write to file.
@@ -14,10 +14,10 @@ import (
 	"go.uber.org/zap"
 )
 
-// bufEnterEval represents the current buffer number, windows ID and buffer files directory.
+// bufEnterEval represents the current buffer number, buffer file and its directory.
 type bufEnterEval struct {
 	BufNr int    `eval:"bufnr('%')"`
-	WinID int    `eval:"win_getid()"`
+	File  string `eval:"expand('%:p')"`
 	Dir   string `eval:"expand('%:p:h')"`
 
 	Cfg *config.Config
@@ -25,7 +25,7 @@ type bufEnterEval struct {
 
 var configOnce sync.Once
 
-// BufEnter gets the current buffer number, windows ID and set context from the directory structure on BufEnter autocmd.
+// BufEnter registers the build context of the current buffer from the directory structure on BufEnter autocmd.
 func (a *Autocmd) BufEnter(eval *bufEnterEval) {
 	defer nvimutil.Profile(a.ctx, time.Now(), "BufEnter")
 
@@ -35,8 +35,10 @@ func (a *Autocmd) BufEnter(eval *bufEnterEval) {
 		logger.FromContext(a.ctx).Debug("VimEnter", zap.Any("eval.Config", eval.Cfg))
 	})
 
-	a.getStatus(eval.BufNr, eval.WinID, eval.Dir)
-	if eval.Dir != "" && a.buildContext.PrevDir != eval.Dir {
-		a.buildContext.SetContext(eval.Dir)
+	a.buildContexts.Context(eval.BufNr, eval.Dir)
+
+	// re-applies the coverage highlights from the last profile
+	if err := a.cmd.CoverBufEnter(eval.BufNr, eval.File); err != nil {
+		logger.FromContext(a.ctx).Error("BufEnter", zap.Error(err))
 	}
 }
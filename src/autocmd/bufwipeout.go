@@ -0,0 +1,24 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package autocmd
+
+import (
+	"time"
+
+	"github.com/zchee/nvim-go/src/nvimutil"
+)
+
+// bufWipeoutEval represents the wiped out buffer number.
+type bufWipeoutEval struct {
+	BufNr int `eval:"str2nr(expand('<abuf>'))"`
+}
+
+// BufWipeout removes the build context and the coverage state of the wiped out buffer on BufWipeout autocmd.
+func (a *Autocmd) BufWipeout(eval *bufWipeoutEval) {
+	defer nvimutil.Profile(a.ctx, time.Now(), "BufWipeout")
+
+	a.buildContexts.Delete(eval.BufNr)
+	a.cmd.DeleteCoverBuffer(eval.BufNr)
+}
@@ -15,8 +15,10 @@ import (
 )
 
 type bufWritePostEval struct {
-	Cwd  string `eval:"getcwd()"`
-	File string `eval:"expand('%:p')"`
+	Cwd   string `eval:"getcwd()"`
+	File  string `eval:"expand('%:p')"`
+	BufNr int    `eval:"bufnr('%')"`
+	WinID int    `eval:"win_getid()"`
 }
 
 func (a *Autocmd) bufWritePost(eval *bufWritePostEval) {
@@ -28,31 +30,33 @@ func (a *Autocmd) BufWritePost(eval *bufWritePostEval) error {
 	defer nvimutil.Profile(a.ctx, time.Now(), "BufWritePost")
 
 	dir := filepath.Dir(eval.File)
+	bctx := a.buildContexts.Context(eval.BufNr, dir)
 
+	// the autosave errors are stored to a.errs so that the following autosave
+	// commands still run, and CursorHold can echo them
 	if config.FmtAutosave {
+		a.errs.Delete("Fmt")
 		err := <-a.bufWritePreChan
 		switch e := err.(type) {
 		case error:
 			return nvimutil.ErrorWrap(a.Nvim, e)
 		case []*nvim.QuickfixError:
-			errlist := make(map[string][]*nvim.QuickfixError)
-			errlist["Fmt"] = e
-			return nvimutil.ErrorList(a.Nvim, errlist, true)
+			a.errs.Store("Fmt", e)
 		}
 	}
 
 	if config.BuildAutosave {
-		err := a.cmd.Build(nil, config.BuildForce, &command.CmdBuildEval{
-			Cwd:  eval.Cwd,
-			File: eval.File,
+		a.errs.Delete("Build")
+		err := a.cmd.Build(bctx, nil, config.BuildForce, &command.CmdBuildEval{
+			Cwd:   eval.Cwd,
+			File:  eval.File,
+			BufNr: eval.BufNr,
 		})
 		switch e := err.(type) {
 		case error:
 			return nvimutil.ErrorWrap(a.Nvim, e)
 		case []*nvim.QuickfixError:
-			errlist := make(map[string][]*nvim.QuickfixError)
-			errlist["Build"] = e
-			return nvimutil.ErrorList(a.Nvim, errlist, true)
+			a.errs.Store("Build", e)
 		}
 	}
 
@@ -62,7 +66,7 @@ func (a *Autocmd) BufWritePost(eval *bufWritePostEval) error {
 			defer a.wg.Done()
 
 			a.errs.Delete("Lint")
-			errlist, err := a.cmd.Lint(nil, eval.File)
+			errlist, err := a.cmd.Lint(bctx, nil, eval.File)
 			if err != nil {
 				nvimutil.ErrorWrap(a.Nvim, err)
 				return
@@ -81,9 +85,10 @@ func (a *Autocmd) BufWritePost(eval *bufWritePostEval) error {
 			}()
 
 			a.errs.Delete("Vet")
-			err := a.cmd.Vet(nil, &command.CmdVetEval{
-				Cwd:  eval.Cwd,
-				File: eval.File,
+			err := a.cmd.Vet(bctx, nil, &command.CmdVetEval{
+				Cwd:   eval.Cwd,
+				File:  eval.File,
+				BufNr: eval.BufNr,
 			})
 			switch e := err.(type) {
 			case error:
@@ -98,7 +103,12 @@ func (a *Autocmd) BufWritePost(eval *bufWritePostEval) error {
 		a.wg.Add(1)
 		go func() {
 			defer a.wg.Done()
-			a.cmd.Metalinter(eval.Cwd)
+			a.cmd.Metalinter(bctx, &command.CmdMetalinterEval{
+				Cwd:   eval.Cwd,
+				File:  eval.File,
+				BufNr: eval.BufNr,
+				WinID: eval.WinID,
+			})
 		}()
 	}
 
@@ -106,21 +116,30 @@ func (a *Autocmd) BufWritePost(eval *bufWritePostEval) error {
 		a.wg.Add(1)
 		go func() {
 			defer a.wg.Done()
-			a.cmd.Test(nil, dir)
+			a.cmd.Test(bctx, nil, dir)
 		}()
 	}
 
 	a.wg.Wait()
 	errlist := make(map[string][]*nvim.QuickfixError)
+	// the sources that re-ran without errors clear the own diagnostics
+	if config.FmtAutosave {
+		errlist["Fmt"] = nil
+	}
+	if config.BuildAutosave {
+		errlist["Build"] = nil
+	}
+	if config.GolintAutosave {
+		errlist["Lint"] = nil
+	}
+	if config.GoVetAutosave {
+		errlist["Vet"] = nil
+	}
 	a.errs.Range(func(ki, vi interface{}) bool {
 		k, v := ki.(string), vi.([]*nvim.QuickfixError)
 		errlist[k] = append(errlist[k], v...)
 		return true
 	})
 
-	if len(errlist) > 0 {
-		return nvimutil.ErrorList(a.Nvim, errlist, true)
-	}
-
-	return nvimutil.ClearErrorlist(a.Nvim, true)
+	return nvimutil.ErrorList(a.Nvim, errlist, true)
 }
@@ -13,8 +13,9 @@ import (
 )
 
 type bufWritePreEval struct {
-	Cwd  string `eval:"getcwd()"`
-	File string `eval:"expand('%:p')"`
+	Cwd   string `eval:"getcwd()"`
+	File  string `eval:"expand('%:p')"`
+	BufNr int    `eval:"bufnr('%')"`
 }
 
 func (a *Autocmd) bufWritePre(eval *bufWritePreEval) {
@@ -26,11 +27,12 @@ func (a *Autocmd) BufWritePre(eval *bufWritePreEval) {
 	defer nvimutil.Profile(a.ctx, time.Now(), "BufWritePre")
 
 	dir := filepath.Dir(eval.File)
+	bctx := a.buildContexts.Context(eval.BufNr, dir)
 
 	// Iferr need execute before Fmt function because that function calls "noautocmd write"
 	// Also do not use goroutine.
 	if config.IferrAutosave {
-		err := a.cmd.Iferr(eval.File)
+		err := a.cmd.Iferr(bctx, eval.File)
 		if err != nil {
 			return
 		}
@@ -38,7 +40,7 @@ func (a *Autocmd) BufWritePre(eval *bufWritePreEval) {
 
 	if config.FmtAutosave {
 		go func() {
-			a.bufWritePreChan <- a.cmd.Fmt(dir)
+			a.bufWritePreChan <- a.cmd.Fmt(bctx, dir)
 		}()
 	}
 }
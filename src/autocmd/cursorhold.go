@@ -0,0 +1,73 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package autocmd
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/zchee/nvim-go/src/config"
+	"github.com/zchee/nvim-go/src/nvimutil"
+)
+
+// cursorHoldEval represents the current working directory, buffer file name, buffer number and cursor line.
+type cursorHoldEval struct {
+	Cwd   string `eval:"getcwd()"`
+	File  string `eval:"expand('%:p')"`
+	BufNr int    `eval:"bufnr('%')"`
+	Line  int    `eval:"line('.')"`
+}
+
+func (a *Autocmd) cursorHold(eval *cursorHoldEval) {
+	go a.CursorHold(eval)
+}
+
+// CursorHold echoes the error message of the cursor line on CursorHold autocmd.
+// The errors are looked up from the stored errors of the commands and autosave,
+// and the error list of the buffer such as Golint and Govet.
+func (a *Autocmd) CursorHold(eval *cursorHoldEval) error {
+	if !config.DiagnosticEcho {
+		return nil
+	}
+	defer nvimutil.Profile(a.ctx, time.Now(), "CursorHold")
+
+	autosave := make(map[string][]*nvim.QuickfixError)
+	a.errs.Range(func(ki, vi interface{}) bool {
+		k, v := ki.(string), vi.([]*nvim.QuickfixError)
+		autosave[k] = v
+		return true
+	})
+	bctx := a.buildContexts.Context(eval.BufNr, filepath.Dir(eval.File))
+	errmap := mergeErrors(a.cmd.Errors(), autosave, bctx.Errlist)
+
+	a.echoMu.Lock()
+	defer a.echoMu.Unlock()
+
+	source, e := nvimutil.LineDiagnostic(errmap, eval.Cwd, eval.File, eval.BufNr, eval.Line)
+	if e == nil {
+		// clears only the message which echoed by CursorHold
+		if a.diagnosticEchoed {
+			a.diagnosticEchoed = false
+			return nvimutil.ClearMsg(a.Nvim)
+		}
+		return nil
+	}
+
+	a.diagnosticEchoed = true
+	return nvimutil.EchoDiagnostic(a.Nvim, source, e)
+}
+
+// mergeErrors merges the errmaps which keyed by the error list source.
+func mergeErrors(errmaps ...map[string][]*nvim.QuickfixError) map[string][]*nvim.QuickfixError {
+	merged := make(map[string][]*nvim.QuickfixError)
+	for _, errmap := range errmaps {
+		for source, errlist := range errmap {
+			merged[source] = append(merged[source], errlist...)
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,49 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package autocmd
+
+import (
+	"testing"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/zchee/nvim-go/src/nvimutil"
+)
+
+func TestMergeErrors(t *testing.T) {
+	var (
+		buildErr = &nvim.QuickfixError{FileName: "a.go", LNum: 10, Text: "undefined: foo"}
+		checkErr = &nvim.QuickfixError{Bufnr: 1, LNum: 20, Text: "expected declaration"}
+		lintErr  = &nvim.QuickfixError{FileName: "/src/pkg/a.go", LNum: 30, Text: "exported func should have comment"}
+		vetErr   = &nvim.QuickfixError{FileName: "a.go", LNum: 40, Text: "unreachable code"}
+		vetErr2  = &nvim.QuickfixError{FileName: "a.go", LNum: 50, Text: "self-assignment"}
+	)
+	cmdErrs := map[string][]*nvim.QuickfixError{"Build": {buildErr}}
+	autosaveErrs := map[string][]*nvim.QuickfixError{"Check": {checkErr}, "Vet": {vetErr}}
+	bufErrs := map[string][]*nvim.QuickfixError{"Lint": {lintErr}, "Vet": {vetErr2}}
+
+	errmap := mergeErrors(cmdErrs, autosaveErrs, bufErrs)
+
+	tests := []struct {
+		name       string
+		line       int
+		wantSource string
+		want       *nvim.QuickfixError
+	}{
+		{name: "command", line: 10, wantSource: "Build", want: buildErr},
+		{name: "autosave", line: 20, wantSource: "Check", want: checkErr},
+		{name: "buffer error list", line: 30, wantSource: "Lint", want: lintErr},
+		{name: "same source of autosave and buffer", line: 40, wantSource: "Vet", want: vetErr},
+		{name: "same source of buffer", line: 50, wantSource: "Vet", want: vetErr2},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			source, got := nvimutil.LineDiagnostic(errmap, "/src/pkg", "/src/pkg/a.go", 1, tt.line)
+			if source != tt.wantSource || got != tt.want {
+				t.Errorf("LineDiagnostic(line %d) = (%v, %v), want (%v, %v)", tt.line, source, got, tt.wantSource, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package autocmd
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/zchee/nvim-go/src/command"
+	"github.com/zchee/nvim-go/src/config"
+	"github.com/zchee/nvim-go/src/logger"
+	"github.com/zchee/nvim-go/src/nvimutil"
+	"go.uber.org/zap"
+)
+
+// textChangedEval represents the current working directory, buffer file name and buffer number.
+type textChangedEval struct {
+	Cwd   string `eval:"getcwd()"`
+	File  string `eval:"expand('%:p')"`
+	BufNr int    `eval:"bufnr('%')"`
+}
+
+// textChanged debounces the TextChanged and TextChangedI autocmd, and runs
+// TextChanged after config.CheckDelay milliseconds from the last change.
+func (a *Autocmd) textChanged(eval *textChangedEval) {
+	if !config.CheckEnable {
+		return
+	}
+
+	a.checkMu.Lock()
+	defer a.checkMu.Unlock()
+
+	if a.checkTimer != nil {
+		a.checkTimer.Stop()
+	}
+	a.checkGen++
+	gen := a.checkGen
+	a.checkTimer = time.AfterFunc(time.Duration(config.CheckDelay)*time.Millisecond, func() {
+		a.TextChanged(eval, gen)
+	})
+}
+
+// TextChanged type-checks the current buffer's package using the unsaved
+// buffer contents, and sets the results to the error list and diagnostics
+// without open the error list.
+// The results are discarded if the buffer is changed again during the check.
+func (a *Autocmd) TextChanged(eval *textChangedEval, gen uint64) error {
+	defer nvimutil.Profile(a.ctx, time.Now(), "TextChanged")
+
+	bctx := a.buildContexts.Context(eval.BufNr, filepath.Dir(eval.File))
+	errlist, err := a.cmd.Check(bctx, &command.CmdCheckEval{
+		Cwd:   eval.Cwd,
+		File:  eval.File,
+		BufNr: eval.BufNr,
+	})
+	if err != nil {
+		// do not echo the error during typing
+		logger.FromContext(a.ctx).Debug("TextChanged", zap.Error(err))
+		return err
+	}
+
+	a.checkMu.Lock()
+	stale := gen != a.checkGen
+	a.checkMu.Unlock()
+	if stale {
+		return nil
+	}
+
+	a.errs.Delete("Check")
+	if len(errlist) > 0 {
+		a.errs.Store("Check", errlist)
+	}
+
+	// merges the stored errors of the commands such as GoBuild so that the error list
+	// keeps them, and replaces the Check errors with this result
+	errmap := a.cmd.Errors()
+	errmap["Check"] = nil // clears the diagnostics if no errors
+	a.errs.Range(func(ki, vi interface{}) bool {
+		k, v := ki.(string), vi.([]*nvim.QuickfixError)
+		errmap[k] = append(errmap[k], v...)
+		return true
+	})
+
+	sources := make([]string, 0, len(errmap))
+	for source := range errmap {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	var list []*nvim.QuickfixError
+	for _, source := range sources {
+		list = append(list, errmap[source]...)
+	}
+
+	if err := nvimutil.SetDiagnostics(a.Nvim, errmap); err != nil {
+		return err
+	}
+	return nvimutil.SetErrorlist(a.Nvim, list)
+}
@@ -10,20 +10,16 @@ import (
 	"github.com/zchee/nvim-go/src/nvimutil"
 )
 
-// winEnterEval represents the current buffer number, windows ID and buffer files directory.
+// winEnterEval represents the current buffer number and buffer files directory.
 type winEnterEval struct {
 	BufNr int    `eval:"bufnr('%')"`
-	WinID int    `eval:"win_getid()"`
 	Dir   string `eval:"expand('%:p:h')"`
 }
 
 func (a *Autocmd) WinEnter(eval *winEnterEval) error {
 	defer nvimutil.Profile(a.ctx, time.Now(), "WinEnter")
 
-	a.getStatus(eval.BufNr, eval.WinID, eval.Dir)
-	if eval.Dir != "" && a.buildContext.PrevDir != eval.Dir {
-		a.buildContext.SetContext(eval.Dir)
-	}
+	a.buildContexts.Context(eval.BufNr, eval.Dir)
 
 	return nil
 }
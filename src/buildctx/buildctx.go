@@ -17,7 +17,7 @@ import (
 	"github.com/zchee/nvim-go/src/pathutil"
 )
 
-// Context represents a current nvim instances context.
+// Context represents a build context of the each buffers.
 type Context struct {
 	// Errlist map the nvim quickfix errors.
 	Errlist map[string][]*nvim.QuickfixError
@@ -27,16 +27,18 @@ type Context struct {
 
 	Buffer
 	Build
+
+	// BuildContext go/build context of the buffer's project.
+	// Use this instead of the global go/build.Default.
+	BuildContext build.Context
 }
 
 // Buffer represents a buffer context.
 type Buffer struct {
-	// BufNr number of current buffer.
+	// BufNr number of the buffer.
 	BufNr int
-	// WinID id of current window.
-	WinID int
 
-	// Dir current directory.
+	// Dir directory of the buffer file.
 	Dir string
 }
 
@@ -45,43 +47,62 @@ type Build struct {
 	// Tool name of build tool
 	Tool string
 	// ProjectRoot package directory full path in the case of go project,
-	// GB_PROJECT_DIR in the case of gb project.
+	// GB_PROJECT_DIR in the case of gb project,
+	// go.mod directory full path in the case of mod project.
 	ProjectRoot string
+	// ModulePath module path declared by go.mod in the case of mod project.
+	ModulePath string
+	// ModuleRoot go.mod directory full path in the case of mod project.
+	ModuleRoot string
 }
 
-// NewContext return the Context type with initialize Context.Errlist.
+// NewContext return the Context type with initialize Context.Errlist and Context.BuildContext.
 func NewContext() *Context {
 	return &Context{
-		Errlist: make(map[string][]*nvim.QuickfixError),
+		Errlist:      make(map[string][]*nvim.QuickfixError),
+		BuildContext: build.Default,
 	}
 }
 
 // buildContext return the new build context estimated from the path p directory structure.
-func (ctx *Context) buildContext(dir string, defaultContext build.Context) (string, string, build.Context) {
+func (ctx *Context) buildContext(dir string, defaultContext build.Context) (Build, build.Context) {
 	// copy context
 	buildContext := defaultContext
 
 	// Default is go context
-	tool := "go"
+	b := Build{Tool: "go"}
 	// Assign package directory full path from dir
-	projectRoot, _ := pathutil.PackagePath(dir)
+	b.ProjectRoot, _ = pathutil.PackagePath(dir)
+
+	// Check whether the dir is inside the Go modules.
+	// The GOPATH is not used in module mode, so keep the buildContext as is.
+	if os.Getenv("GO111MODULE") != "off" {
+		if modRoot, modPath, ok := pathutil.IsModule(filepath.Clean(dir)); ok {
+			b.Tool = "mod"
+			b.ProjectRoot = modRoot
+			b.ModuleRoot = modRoot
+			b.ModulePath = modPath
+			return b, buildContext
+		}
+	}
 
 	if config.BuildIsNotGb {
-		return tool, pathutil.FindVCSRoot(projectRoot), buildContext
+		b.ProjectRoot = pathutil.FindVCSRoot(b.ProjectRoot)
+		return b, buildContext
 	}
 
 	// Check whether the dir is Gb directory structure.
 	// If ok, append gb root and vendor path to the goPath lists.
 	if gbpath, ok := pathutil.IsGb(filepath.Clean(dir)); ok {
-		tool = "gb"
-		projectRoot = gbpath
+		b.Tool = "gb"
+		b.ProjectRoot = gbpath
 		buildContext.GOPATH = gbpath + string(filepath.ListSeparator) + filepath.Join(gbpath, "vendor")
 		if config.BuildAppengine {
 			buildContext.GOROOT = goappEnv("GOROOT")
 		}
 	}
 
-	return tool, projectRoot, buildContext
+	return b, buildContext
 }
 
 func goappEnv(env string) string {
@@ -94,17 +115,42 @@ func goappEnv(env string) string {
 	return strings.TrimSpace(string(out))
 }
 
-// SetContext sets the Tool, ProjectRoot, go/build.Default and $GOPATH to buildContext.
-// This function initializes for functions that use go/build.Default.
+// SetContext sets the Tool, ProjectRoot, ModulePath, ModuleRoot and BuildContext estimated from the dir.
+// SetContext does not change the global go/build.Default and $GOPATH.
 func (ctx *Context) SetContext(dir string) {
 	ctx.m.Lock()
 	defer ctx.m.Unlock()
 
-	ctx.Build.Tool, ctx.Build.ProjectRoot, build.Default = ctx.buildContext(dir, build.Default)
+	ctx.Build, ctx.BuildContext = ctx.buildContext(dir, build.Default)
 	if ctx.Build.Tool == "gb" {
-		build.Default.JoinPath = ctx.Build.GbJoinPath
+		ctx.BuildContext.JoinPath = ctx.Build.GbJoinPath
 	}
+	ctx.Buffer.Dir = dir
 	ctx.PrevDir = dir
+}
+
+// Environ returns the copy of process environment with $GOPATH of ctx.BuildContext.
+// Use this for the environment of the go tool commands.
+func (ctx *Context) Environ() []string {
+	env := os.Environ()
+	if ctx.BuildContext.GOPATH == "" || ctx.BuildContext.GOPATH == build.Default.GOPATH {
+		return env
+	}
 
-	os.Setenv("GOPATH", build.Default.GOPATH)
+	for i, e := range env {
+		if strings.HasPrefix(e, "GOPATH=") {
+			env = append(env[:i], env[i+1:]...)
+			break
+		}
+	}
+	return append(env, "GOPATH="+ctx.BuildContext.GOPATH)
+}
+
+// Cmd returns the command name of the build tool.
+// The "mod" tool uses the go command.
+func (b *Build) Cmd() string {
+	if b.Tool == "mod" {
+		return "go"
+	}
+	return b.Tool
 }
@@ -0,0 +1,51 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package buildctx
+
+import "sync"
+
+// Registry represents a registry of the build contexts keyed by the buffer number.
+// Registry is safe for concurrent use by multiple goroutines.
+type Registry struct {
+	mu       sync.Mutex
+	contexts map[int]*Context // map[bufnr]*Context
+}
+
+// NewRegistry returns the new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		contexts: make(map[int]*Context),
+	}
+}
+
+// Context returns the build context of the bufnr buffer.
+// If the bufnr buffer is not registered yet or the buffer directory is changed
+// from dir, Context registers the new build context estimated from dir.
+func (r *Registry) Context(bufnr int, dir string) *Context {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if ctx, ok := r.contexts[bufnr]; ok && (dir == "" || ctx.PrevDir == dir) {
+		return ctx
+	}
+
+	// Replace the context instead of re-set the registered context, because
+	// the other commands may still be reading it.
+	ctx := NewContext()
+	ctx.BufNr = bufnr
+	if dir != "" {
+		ctx.SetContext(dir)
+	}
+	r.contexts[bufnr] = ctx
+
+	return ctx
+}
+
+// Delete removes the build context of the bufnr buffer from the registry.
+func (r *Registry) Delete(bufnr int) {
+	r.mu.Lock()
+	delete(r.contexts, bufnr)
+	r.mu.Unlock()
+}
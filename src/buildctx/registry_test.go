@@ -0,0 +1,63 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package buildctx
+
+import (
+	"go/build"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var (
+	testCwd, _ = os.Getwd()
+	gsftpRoot  = filepath.Join(testCwd, "../testdata", "gb", "gsftp")
+	gsftp      = filepath.Join(gsftpRoot, "src", "cmd", "gsftp")
+	astdump    = filepath.Join(testCwd, "../testdata", "go", "src", "astdump")
+)
+
+func TestRegistry_Context(t *testing.T) {
+	defaultGopath := build.Default.GOPATH
+	r := NewRegistry()
+
+	gb := r.Context(1, gsftp)
+	if gb.BufNr != 1 {
+		t.Errorf("BufNr = %v, want %v", gb.BufNr, 1)
+	}
+	if gb.Tool != "gb" {
+		t.Errorf("Tool = %v, want %v", gb.Tool, "gb")
+	}
+	if want := gsftpRoot + string(filepath.ListSeparator) + filepath.Join(gsftpRoot, "vendor"); gb.BuildContext.GOPATH != want {
+		t.Errorf("BuildContext.GOPATH = %v, want %v", gb.BuildContext.GOPATH, want)
+	}
+
+	gobuf := r.Context(2, astdump)
+	if gobuf.Tool != "go" {
+		t.Errorf("Tool = %v, want %v", gobuf.Tool, "go")
+	}
+	if gobuf.BuildContext.GOPATH != defaultGopath {
+		t.Errorf("BuildContext.GOPATH = %v, want %v", gobuf.BuildContext.GOPATH, defaultGopath)
+	}
+
+	// the each buffers context must not change the global go/build.Default
+	if build.Default.GOPATH != defaultGopath {
+		t.Errorf("build.Default.GOPATH = %v, want %v", build.Default.GOPATH, defaultGopath)
+	}
+
+	if got := r.Context(1, ""); got != gb {
+		t.Errorf("Context(1, \"\") = %p, want registered context %p", got, gb)
+	}
+	if got := r.Context(1, gsftp); got != gb {
+		t.Errorf("Context(1, %q) = %p, want registered context %p", gsftp, got, gb)
+	}
+	if got := r.Context(1, astdump); got == gb || got.Tool != "go" {
+		t.Errorf("Context(1, %q) = %+v, want the new go context", astdump, got.Build)
+	}
+
+	r.Delete(2)
+	if got := r.Context(2, ""); got == gobuf || got.Tool != "" {
+		t.Errorf("Context(2, \"\") = %+v, want the new empty context", got.Build)
+	}
+}
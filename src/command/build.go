@@ -13,6 +13,7 @@ import (
 
 	"github.com/neovim/go-client/nvim"
 	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/buildctx"
 	"github.com/zchee/nvim-go/src/config"
 	"github.com/zchee/nvim-go/src/logger"
 	"github.com/zchee/nvim-go/src/nvimutil"
@@ -22,16 +23,20 @@ import (
 
 // CmdBuildEval struct type for Eval of GoBuild command.
 type CmdBuildEval struct {
-	Cwd  string `msgpack:",array"`
-	File string
+	Cwd   string `msgpack:",array"`
+	File  string
+	BufNr int
 }
 
 func (c *Command) cmdBuild(args []string, bang bool, eval *CmdBuildEval) {
 	go func() {
 		c.errs.Delete("Build")
 
-		err := c.Build(args, bang, eval)
+		bctx := c.buildContexts.Context(eval.BufNr, filepath.Dir(eval.File))
+		err := c.Build(bctx, args, bang, eval)
 		switch e := err.(type) {
+		case nil:
+			nvimutil.ClearDiagnostics(c.Nvim, "Build")
 		case error:
 			nvimutil.ErrorWrap(c.Nvim, e)
 		case []*nvim.QuickfixError:
@@ -48,14 +53,14 @@ func (c *Command) cmdBuild(args []string, bang bool, eval *CmdBuildEval) {
 }
 
 // Build builds the current buffers package use compile tool that determined
-// from the package directory structure.
-func (c *Command) Build(args []string, bang bool, eval *CmdBuildEval) interface{} {
+// from the package directory structure of bctx.
+func (c *Command) Build(bctx *buildctx.Context, args []string, bang bool, eval *CmdBuildEval) interface{} {
 	log := logger.FromContext(c.ctx).With(zap.Strings("args", args), zap.Bool("bang", bang), zap.Any("CmdBuildEval", eval))
 	if !bang {
 		bang = config.BuildForce
 	}
 
-	cmd, err := c.compileCmd(args, bang, eval.Cwd)
+	cmd, err := c.compileCmd(bctx, args, bang, eval.Cwd)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -66,7 +71,7 @@ func (c *Command) Build(args []string, bang bool, eval *CmdBuildEval) interface{
 
 	if buildErr := cmd.Run(); buildErr != nil {
 		if err, ok := buildErr.(*exec.ExitError); ok && err != nil {
-			errlist, err := nvimutil.ParseError(stderr.Bytes(), eval.Cwd, &c.buildContext.Build, nil)
+			errlist, err := nvimutil.ParseError(stderr.Bytes(), eval.Cwd, &bctx.Build, nil)
 			if err != nil {
 				return errors.WithStack(err)
 			}
@@ -75,21 +80,22 @@ func (c *Command) Build(args []string, bang bool, eval *CmdBuildEval) interface{
 		return errors.WithStack(buildErr)
 	}
 
-	return nvimutil.EchoSuccess(c.Nvim, "GoBuild", fmt.Sprintf("compiler: %s", c.buildContext.Build.Tool))
+	return nvimutil.EchoSuccess(c.Nvim, "GoBuild", fmt.Sprintf("compiler: %s", bctx.Build.Tool))
 }
 
 // compileCmd returns the *exec.Cmd corresponding to the compile tool.
-func (c *Command) compileCmd(args []string, bang bool, dir string) (*exec.Cmd, error) {
-	bin, err := exec.LookPath(c.buildContext.Build.Tool)
+func (c *Command) compileCmd(bctx *buildctx.Context, args []string, bang bool, dir string) (*exec.Cmd, error) {
+	bin, err := exec.LookPath(bctx.Build.Cmd())
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 	cmd := exec.Command(bin, "build")
+	cmd.Env = bctx.Environ()
 
 	if len(config.BuildFlags) > 0 {
 		args = append(args, config.BuildFlags...)
 	}
-	switch c.buildContext.Build.Tool {
+	switch bctx.Build.Tool {
 	case "go":
 		cmd.Dir = dir
 
@@ -101,8 +107,19 @@ func (c *Command) compileCmd(args []string, bang bool, dir string) (*exec.Cmd, e
 		if config.BuildAppengine {
 			cmd.Args[0] += "app"
 		}
+	case "mod":
+		// go command must be run inside the module in module mode
+		cmd.Dir = dir
+		if !pathutil.IsSubdir(bctx.Build.ModuleRoot, dir) {
+			cmd.Dir = bctx.Build.ModuleRoot
+		}
+
+		// Outputs the binary to DevNull if without bang
+		if !bang || !matchSlice("-o", args) {
+			args = append(args, "-o", os.DevNull)
+		}
 	case "gb":
-		cmd.Dir = c.buildContext.Build.ProjectRoot
+		cmd.Dir = bctx.Build.ProjectRoot
 
 		if config.BuildAppengine {
 			cmd.Args = append([]string{cmd.Args[0], "gae"}, cmd.Args[1:]...)
@@ -122,8 +122,8 @@ func TestCommand_Build(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			c := NewCommand(tt.fields.ctx, tt.fields.Nvim, tt.fields.buildctxt)
-			err := c.Build(tt.args.args, tt.args.bang, tt.args.eval)
+			c := NewCommand(tt.fields.ctx, tt.fields.Nvim, buildctx.NewRegistry())
+			err := c.Build(tt.fields.buildctxt, tt.args.args, tt.args.bang, tt.args.eval)
 			switch e := err.(type) {
 			case error:
 				if (err != nil) != tt.wantErr {
@@ -141,15 +141,15 @@ func TestCommand_Build(t *testing.T) {
 func BenchmarkBuildGo(b *testing.B) {
 	ctx := testutil.TestContext(context.Background())
 	buildctxt := buildctx.NewContext()
-	c := NewCommand(ctx, benchVim(b, astdumpMain), buildctxt)
+	c := NewCommand(ctx, benchVim(b, astdumpMain), buildctx.NewRegistry())
 
 	for i := 0; i < b.N; i++ {
-		c.Build(nil, false, &CmdBuildEval{
+		c.Build(buildctxt, nil, false, &CmdBuildEval{
 			Cwd:  astdump,
 			File: astdump,
 		})
-		if len(c.buildContext.Errlist) != 0 {
-			b.Errorf("BenchmarkBuildGo: %v", c.buildContext.Errlist)
+		if len(buildctxt.Errlist) != 0 {
+			b.Errorf("BenchmarkBuildGo: %v", buildctxt.Errlist)
 		}
 	}
 }
@@ -157,15 +157,15 @@ func BenchmarkBuildGo(b *testing.B) {
 func BenchmarkBuildGb(b *testing.B) {
 	ctx := testutil.TestContext(context.Background())
 	buildctxt := buildctx.NewContext()
-	c := NewCommand(ctx, benchVim(b, gsftpMain), buildctxt)
+	c := NewCommand(ctx, benchVim(b, gsftpMain), buildctx.NewRegistry())
 
 	for i := 0; i < b.N; i++ {
-		c.Build(nil, false, &CmdBuildEval{
+		c.Build(buildctxt, nil, false, &CmdBuildEval{
 			Cwd:  gsftpRoot,
 			File: gsftpRoot,
 		})
-		if len(c.buildContext.Errlist) != 0 {
-			b.Errorf("BenchmarkBuildGb: %v", c.buildContext.Errlist)
+		if len(buildctxt.Errlist) != 0 {
+			b.Errorf("BenchmarkBuildGb: %v", buildctxt.Errlist)
 		}
 	}
 }
@@ -0,0 +1,248 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"go/build"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/buildctx"
+	"github.com/zchee/nvim-go/src/nvimutil"
+	"github.com/zchee/nvim-go/src/pathutil"
+	"golang.org/x/tools/go/buildutil"
+	"golang.org/x/tools/go/loader"
+)
+
+// CmdCheckEval struct type for Eval of GoCheck command.
+type CmdCheckEval struct {
+	Cwd   string `msgpack:",array"`
+	File  string
+	BufNr int
+}
+
+func (c *Command) cmdCheck(eval *CmdCheckEval) {
+	go func() {
+		c.errs.Delete("Check")
+
+		bctx := c.buildContexts.Context(eval.BufNr, filepath.Dir(eval.File))
+		errlist, err := c.Check(bctx, eval)
+		if err != nil {
+			nvimutil.ErrorWrap(c.Nvim, err)
+			return
+		}
+		if len(errlist) == 0 {
+			nvimutil.EchoSuccess(c.Nvim, "GoCheck", "no errors")
+		} else {
+			c.errs.Store("Check", errlist)
+		}
+
+		errmap := c.Errors()
+		errmap["Check"] = errlist // clears the diagnostics if no errors
+		nvimutil.ErrorList(c.Nvim, errmap, true)
+	}()
+}
+
+// Check type-checks the package of the bctx buffer in-process using go/types.
+// The unsaved buffer contents are used as the overlay of build context, so
+// Check does not need to write the file.
+func (c *Command) Check(bctx *buildctx.Context, eval *CmdCheckEval) ([]*nvim.QuickfixError, error) {
+	defer nvimutil.Profile(c.ctx, time.Now(), "GoCheck")
+
+	b := nvim.Buffer(bctx.BufNr)
+	buf, err := c.Nvim.BufferLines(b, 0, -1, true)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// same as the overlay of Guru
+	overlay := map[string][]byte{
+		eval.File: bytes.Join(buf, []byte{'\n'}),
+	}
+	buildContext := bctx.BuildContext
+	checkContext := buildutil.OverlayContext(&buildContext, overlay)
+
+	// go/build could not find the module dependencies
+	var pkgDirs map[string]string
+	if bctx.Build.Tool == "mod" {
+		pkgDirs, err = modulePackageDirs(bctx.Environ(), filepath.Dir(eval.File))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	errlist, err := checkPackage(checkContext, pkgDirs, eval.File, eval.Cwd)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return errlist, nil
+}
+
+// modulePackageDirs returns the map of the import path and directory of the
+// package in dir and its dependencies including the test dependencies, which
+// resolved by the module aware "go list".
+func modulePackageDirs(env []string, dir string) (map[string]string, error) {
+	cmd := exec.Command("go", "list", "-e", "-deps", "-test", "-f", "{{.ImportPath}}\t{{.Dir}}", ".")
+	cmd.Dir = dir
+	cmd.Env = env
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(err, strings.TrimSpace(stderr.String()))
+	}
+
+	pkgDirs := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Split(line, "\t")
+		// skips the test variants such as "foo [foo.test]" and the test main package
+		if len(fields) != 2 || fields[1] == "" || strings.Contains(fields[0], " ") {
+			continue
+		}
+		pkgDirs[fields[0]] = fields[1]
+	}
+
+	return pkgDirs, nil
+}
+
+// checkPackage type-checks the package that contains filename and returns the
+// errors as the quickfix list.
+// pkgDirs is the map of the import path and directory of the dependencies
+// which go/build could not find such as the module dependencies, or nil.
+// The filenames of the errors are relative path from cwd.
+func checkPackage(ctxt *build.Context, pkgDirs map[string]string, filename, cwd string) ([]*nvim.QuickfixError, error) {
+	dir, base := filepath.Split(filename)
+	dir = filepath.Clean(dir)
+
+	pkgPath := "command-line-arguments"
+	var files []string
+	bp, err := ctxt.ImportDir(dir, build.ImportMode(0))
+	switch err.(type) {
+	case nil:
+		pkgPath = bp.ImportPath
+		for importPath, pkgDir := range pkgDirs {
+			if pkgDir == dir {
+				pkgPath = importPath
+			}
+		}
+		if matchSlice(base, bp.XTestGoFiles) {
+			pkgPath += "_test"
+			files = bp.XTestGoFiles
+			break
+		}
+		files = append(files, bp.GoFiles...)
+		files = append(files, bp.CgoFiles...)
+		files = append(files, bp.TestGoFiles...)
+	case *build.NoGoError, *build.MultiplePackageError, scanner.ErrorList:
+		// fallback to check the filename only
+	default:
+		return nil, errors.WithStack(err)
+	}
+	// filename is ignored by the build constraints, or go/build could not
+	// parse the package clause
+	if !matchSlice(base, files) {
+		files = []string{base}
+	}
+	for i, f := range files {
+		files[i] = filepath.Join(dir, f)
+	}
+
+	conf := loader.Config{
+		Build:       ctxt,
+		Cwd:         dir,
+		ParserMode:  parser.AllErrors,
+		AllowErrors: true,
+		TypeChecker: types.Config{
+			FakeImportC: true,
+			Error:       func(error) {}, // errors are collected to PackageInfo.Errors
+		},
+		// the function bodies of dependencies are not necessary for the diagnostics
+		TypeCheckFuncBodies: func(path string) bool { return path == pkgPath },
+	}
+	if pkgDirs != nil {
+		conf.FindPackage = func(ctxt *build.Context, importPath, fromDir string, mode build.ImportMode) (*build.Package, error) {
+			pkgDir, ok := pkgDirs[importPath]
+			if !ok {
+				return ctxt.Import(importPath, fromDir, mode)
+			}
+			bp, err := ctxt.ImportDir(pkgDir, mode)
+			if bp != nil {
+				bp.ImportPath = importPath
+			}
+			return bp, err
+		}
+	}
+	conf.CreateFromFilenames(pkgPath, files...)
+
+	prog, err := conf.Load()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var errlist []*nvim.QuickfixError
+	seen := make(map[string]bool)
+	appendError := func(pos token.Position, msg, typ string) {
+		// ignore the errors of dependency packages
+		if filepath.Dir(pos.Filename) != dir {
+			return
+		}
+		key := fmt.Sprintf("%s:%d:%d:%s", pos.Filename, pos.Line, pos.Column, msg)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+
+		errlist = append(errlist, &nvim.QuickfixError{
+			FileName: pathutil.Rel(cwd, pos.Filename),
+			LNum:     pos.Line,
+			Col:      pos.Column,
+			Text:     msg,
+			Type:     typ,
+		})
+	}
+
+	for _, info := range prog.Created {
+		for _, err := range info.Errors {
+			switch e := err.(type) {
+			case types.Error:
+				typ := "E"
+				if e.Soft {
+					typ = "W"
+				}
+				appendError(e.Fset.Position(e.Pos), e.Msg, typ)
+			case scanner.ErrorList:
+				for _, e := range e {
+					appendError(e.Pos, e.Msg, "E")
+				}
+			case *scanner.Error:
+				appendError(e.Pos, e.Msg, "E")
+			}
+		}
+	}
+
+	sort.SliceStable(errlist, func(i, j int) bool {
+		if errlist[i].FileName != errlist[j].FileName {
+			return errlist[i].FileName < errlist[j].FileName
+		}
+		if errlist[i].LNum != errlist[j].LNum {
+			return errlist[i].LNum < errlist[j].LNum
+		}
+		return errlist[i].Col < errlist[j].Col
+	})
+
+	return errlist, nil
+}
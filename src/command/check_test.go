@@ -0,0 +1,152 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"go/build"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/buildutil"
+)
+
+func TestCheckPackage(t *testing.T) {
+	astdumpSrc, err := ioutil.ReadFile(astdumpMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type wantError struct {
+		fileName string
+		lnum     int
+		typ      string
+		text     string // substring of the error message, the detail depends on the Go version
+	}
+	tests := []struct {
+		name    string
+		file    string
+		overlay []byte
+		want    []wantError
+	}{
+		{
+			name: "correct (astdump)",
+			file: astdumpMain,
+			want: nil,
+		},
+		{
+			name:    "unsaved type error (astdump)",
+			file:    astdumpMain,
+			overlay: []byte(strings.Replace(string(astdumpSrc), "ast.Print(fset, f)", "ast.Print(fset, f, 1)\n\tvar unused int", 1)),
+			want: []wantError{
+				{fileName: "astdump.go", lnum: 26, typ: "E", text: "too many arguments"},
+				{fileName: "astdump.go", lnum: 27, typ: "W", text: "unused"},
+			},
+		},
+		{
+			name: "syntax error (broken)",
+			file: brokenMain,
+			want: []wantError{
+				{fileName: "../broken/broken.go", lnum: 19, typ: "E", text: "expected declaration"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctxt := build.Default
+			ctxt.GOPATH = testGoPath
+			checkContext := &ctxt
+			if tt.overlay != nil {
+				checkContext = buildutil.OverlayContext(checkContext, map[string][]byte{tt.file: tt.overlay})
+			}
+
+			got, err := checkPackage(checkContext, nil, tt.file, astdump)
+			if err != nil {
+				t.Fatalf("checkPackage(%v) error = %v", tt.file, err)
+			}
+			if len(tt.want) == 0 && len(got) != 0 {
+				t.Fatalf("checkPackage(%v) = %v, want no errors", tt.file, got)
+			}
+			if len(got) < len(tt.want) {
+				t.Fatalf("checkPackage(%v) = %d errors, want at least %d", tt.file, len(got), len(tt.want))
+			}
+		Loop:
+			for _, want := range tt.want {
+				for _, e := range got {
+					if e.FileName == want.fileName && e.LNum == want.lnum && e.Type == want.typ && strings.Contains(e.Text, want.text) {
+						continue Loop
+					}
+				}
+				t.Errorf("checkPackage(%v) = %v, want contains %+v", tt.file, got, want)
+			}
+		})
+	}
+}
+
+func TestCheckPackage_relativeFileName(t *testing.T) {
+	ctxt := build.Default
+	ctxt.GOPATH = testGoPath
+
+	got, err := checkPackage(&ctxt, nil, brokenMain, filepath.Dir(broken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) == 0 || got[0].FileName != filepath.Join("broken", "broken.go") {
+		t.Errorf("checkPackage(%v) = %v, want the filename relative from cwd", brokenMain, got)
+	}
+}
+
+func TestCheckPackage_module(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not found")
+	}
+	root, err := ioutil.TempDir("", "nvim-go-check")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	files := map[string]string{
+		"go.mod":     "module example.com/m\n",
+		"sub/sub.go": "package sub\n\nfunc Name() string { return \"sub\" }\n",
+		"main.go":    "package main\n\nimport \"example.com/m/sub\"\n\nfunc main() {\n\tvar n int = sub.Name()\n\t_ = n\n}\n",
+	}
+	for name, src := range files {
+		fname := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fname), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fname, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	env := append(os.Environ(), "GO111MODULE=on", "GOFLAGS=-mod=mod", "GOPROXY=off")
+	pkgDirs, err := modulePackageDirs(env, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := pkgDirs["example.com/m/sub"]; got != filepath.Join(root, "sub") {
+		t.Fatalf("modulePackageDirs() = %v, want the example.com/m/sub directory", pkgDirs)
+	}
+
+	ctxt := build.Default
+	ctxt.GOPATH = testGoPath
+	got, err := checkPackage(&ctxt, pkgDirs, filepath.Join(root, "main.go"), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the module import is resolved, so the type error of its usage is reported
+	if len(got) != 1 || got[0].LNum != 6 || !strings.Contains(got[0].Text, "cannot use") {
+		for _, e := range got {
+			t.Logf("%+v", e)
+		}
+		t.Errorf("checkPackage() = %v, want the type error at line 6", got)
+	}
+}
@@ -6,12 +6,15 @@ package command
 
 import (
 	"context"
+	"sync"
 
 	"github.com/neovim/go-client/nvim"
 	"github.com/neovim/go-client/nvim/plugin"
 	"github.com/zchee/nvim-go/src/buildctx"
 	"github.com/zchee/nvim-go/src/command/delve"
+	"github.com/zchee/nvim-go/src/internal/cover"
 	"github.com/zchee/nvim-go/src/logger"
+	"github.com/zchee/nvim-go/src/nvimutil"
 	"golang.org/x/sync/syncmap"
 )
 
@@ -20,45 +23,89 @@ type Command struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 
-	Nvim         *nvim.Nvim
-	buildContext *buildctx.Context
-	errs         *syncmap.Map
+	Nvim          *nvim.Nvim
+	buildContexts *buildctx.Registry
+	errs          *syncmap.Map
+
+	testMu       sync.Mutex
+	testReport   *testReport       // the merged "go test -json" results
+	testSigns    []placedSign      // the placed test result signs
+	testExplorer *testExplorer     // the GoTestExplorer buffer
+	testOutput   *nvimutil.Buffer  // the captured test output buffer
+	testTermDirs map[string]string // the test packages of the last "__GO_TEST__" terminal run
+
+	coverMu           sync.Mutex
+	coverNS           int                       // highlight namespace of the coverage
+	coverProfiles     map[string]*cover.Profile // the last coverage profile of each files
+	coverPrevious     map[string]*cover.Profile // the previous coverage profile of each files
+	coverBuffers      map[nvim.Buffer][]int     // the coverage highlighted buffers and its sign ids
+	coverHidden       bool                      // whether the coverage highlights are toggled off
+	coverReport       *coverReport              // the GoCoverReport buffer
+	coverSignsDefined bool
+	coverVirtualText  *bool // whether the Neovim supports the virtual text, nil if not checked yet
 }
 
 // NewCommand return the new Command type with initialize some variables.
-func NewCommand(pctx context.Context, v *nvim.Nvim, buildctxt *buildctx.Context) *Command {
+func NewCommand(pctx context.Context, v *nvim.Nvim, buildContexts *buildctx.Registry) *Command {
 	ctx, cancel := context.WithCancel(pctx)
 	ctx = logger.NewContext(ctx, logger.FromContext(ctx).Named("command"))
 
 	return &Command{
-		ctx:          ctx,
-		cancel:       cancel,
-		Nvim:         v,
-		buildContext: buildctxt,
-		errs:         new(syncmap.Map),
+		ctx:           ctx,
+		cancel:        cancel,
+		Nvim:          v,
+		buildContexts: buildContexts,
+		errs:          new(syncmap.Map),
+		coverProfiles: make(map[string]*cover.Profile),
+		coverPrevious: make(map[string]*cover.Profile),
+		coverBuffers:  make(map[nvim.Buffer][]int),
 	}
 }
 
+// Errors returns the copy of the stored errors of each command source.
+func (c *Command) Errors() map[string][]*nvim.QuickfixError {
+	errmap := make(map[string][]*nvim.QuickfixError)
+	c.errs.Range(func(ki, vi interface{}) bool {
+		k, v := ki.(string), vi.([]*nvim.QuickfixError)
+		errmap[k] = append(errmap[k], v...)
+		return true
+	})
+
+	return errmap
+}
+
 // Register register nvim-go command or function to Neovim over the msgpack-rpc plugin interface.
-func Register(ctx context.Context, p *plugin.Plugin, buildctxt *buildctx.Context) *Command {
-	c := NewCommand(ctx, p.Nvim, buildctxt)
+func Register(ctx context.Context, p *plugin.Plugin, buildContexts *buildctx.Registry) *Command {
+	c := NewCommand(ctx, p.Nvim, buildContexts)
 
 	// Register command and function
 	// CommandOptions order: Name, NArgs, Range, Count, Addr, Bang, Register, Eval, Bar, Complete
-	p.HandleCommand(&plugin.CommandOptions{Name: "Gobuild", NArgs: "*", Bang: true, Eval: "[getcwd(), expand('%:p')]"}, c.cmdBuild)
-	p.HandleCommand(&plugin.CommandOptions{Name: "GoCover", Eval: "[getcwd(), expand('%:p')]"}, c.cmdCover)
-	p.HandleCommand(&plugin.CommandOptions{Name: "Gofmt", Eval: "expand('%:p:h')"}, c.cmdFmt)
-	p.HandleCommand(&plugin.CommandOptions{Name: "GoGenerateTest", NArgs: "*", Range: "%", Addr: "line", Bang: true, Eval: "expand('%:p:h')", Complete: "file"}, c.cmdGenerateTest)
-	p.HandleFunction(&plugin.FunctionOptions{Name: "GoGuru", Eval: "[getcwd(), expand('%:p'), &modified, line2byte(line('.')) + (col('.')-2)]"}, c.funcGuru)
-	p.HandleCommand(&plugin.CommandOptions{Name: "GoIferr", Eval: "expand('%:p')"}, c.cmdIferr)
-	p.HandleCommand(&plugin.CommandOptions{Name: "Golint", NArgs: "?", Eval: "expand('%:p')", Complete: "customlist,GoLintCompletion"}, c.cmdLint)
-	p.HandleCommand(&plugin.CommandOptions{Name: "Gometalinter", Eval: "getcwd()"}, c.cmdMetalinter)
-	p.HandleCommand(&plugin.CommandOptions{Name: "Gorename", NArgs: "?", Bang: true, Eval: "[getcwd(), expand('%:p'), expand('<cword>')]"}, c.cmdRename)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Gobuild", NArgs: "*", Bang: true, Eval: "[getcwd(), expand('%:p'), bufnr('%')]"}, c.cmdBuild)
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoCheck", Eval: "[getcwd(), expand('%:p'), bufnr('%')]"}, c.cmdCheck)
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoCover", Eval: "[getcwd(), expand('%:p'), bufnr('%')]"}, c.cmdCover)
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoCoverClear"}, c.cmdCoverClear)
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoCoverLoad", NArgs: "1", Eval: "[getcwd(), bufnr('%')]", Complete: "file"}, c.cmdCoverLoad)
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoCoverDiff"}, c.cmdCoverDiff)
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoCoverExport", NArgs: "?", Eval: "[getcwd(), bufnr('%')]", Complete: "file"}, c.cmdCoverExport)
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoCoverReport", Eval: "[expand('%:p:h'), bufnr('%')]"}, c.cmdCoverReport)
+	p.Handle("GoCoverReportAction", c.handleCoverReportAction)
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoCoverToggle", Eval: "[getcwd(), expand('%:p'), bufnr('%')]"}, c.cmdCoverToggle)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Gofmt", Eval: "[expand('%:p:h'), bufnr('%')]"}, c.cmdFmt)
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoGenerateTest", NArgs: "*", Range: "%", Addr: "line", Bang: true, Eval: "[expand('%:p:h'), bufnr('%')]", Complete: "file"}, c.cmdGenerateTest)
+	p.HandleFunction(&plugin.FunctionOptions{Name: "GoGuru", Eval: "[getcwd(), expand('%:p'), &modified, line2byte(line('.')) + (col('.')-2), bufnr('%'), win_getid()]"}, c.funcGuru)
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoIferr", Eval: "[expand('%:p'), bufnr('%')]"}, c.cmdIferr)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Golint", NArgs: "?", Eval: "[expand('%:p'), bufnr('%')]", Complete: "customlist,GoLintCompletion"}, c.cmdLint)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Gometalinter", Eval: "[getcwd(), expand('%:p'), bufnr('%'), win_getid()]"}, c.cmdMetalinter)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Gorename", NArgs: "?", Bang: true, Eval: "[getcwd(), expand('%:p'), expand('<cword>'), bufnr('%'), win_getid()]"}, c.cmdRename)
 	p.HandleCommand(&plugin.CommandOptions{Name: "Gorun", NArgs: "*", Eval: "expand('%:p')"}, c.cmdRun)
 	p.HandleCommand(&plugin.CommandOptions{Name: "GorunLast", Eval: "expand('%:p')"}, c.cmdRunLast)
-	p.HandleCommand(&plugin.CommandOptions{Name: "Gotest", NArgs: "*", Eval: "expand('%:p:h')"}, c.cmdTest)
-	p.HandleCommand(&plugin.CommandOptions{Name: "GoSwitchTest", Eval: "[getcwd(), expand('%:p'), line2byte(line('.')) + (col('.')-2)]"}, c.cmdSwitchTest)
-	p.HandleCommand(&plugin.CommandOptions{Name: "Govet", NArgs: "*", Eval: "[getcwd(), expand('%:p')]", Complete: "customlist,GoVetCompletion"}, c.cmdVet)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Gotest", NArgs: "*", Eval: "[expand('%:p:h'), bufnr('%')]"}, c.cmdTest)
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoTestFunc", NArgs: "*", Eval: "[expand('%:p:h'), expand('%:p'), line2byte(line('.')) + (col('.')-2), bufnr('%')]"}, c.cmdTestFunc)
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoTestFailed", Eval: "[expand('%:p:h'), bufnr('%')]"}, c.cmdTestFailed)
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoTestExplorer", Eval: "[expand('%:p:h'), bufnr('%')]"}, c.cmdTestExplorer)
+	p.Handle("GoTestExplorerAction", c.handleTestExplorerAction)
+	p.HandleCommand(&plugin.CommandOptions{Name: "GoSwitchTest", Eval: "[getcwd(), expand('%:p'), line2byte(line('.')) + (col('.')-2), bufnr('%'), win_getid()]"}, c.cmdSwitchTest)
+	p.HandleCommand(&plugin.CommandOptions{Name: "Govet", NArgs: "*", Eval: "[getcwd(), expand('%:p'), bufnr('%')]", Complete: "customlist,GoVetCompletion"}, c.cmdVet)
 
 	// Commnad completion
 	p.HandleFunction(&plugin.FunctionOptions{Name: "GoLintCompletion", Eval: "getcwd()"}, c.cmdLintComplete) // list the file, directory and go packages
@@ -70,7 +117,7 @@ func Register(ctx context.Context, p *plugin.Plugin, buildctxt *buildctx.Context
 	p.HandleCommand(&plugin.CommandOptions{Name: "GoWindows"}, c.cmdWindows)
 	p.HandleCommand(&plugin.CommandOptions{Name: "GoTabpages"}, c.cmdTabpagas)
 
-	delve.Register(ctx, p, buildctxt)
+	delve.Register(ctx, p, buildContexts)
 
 	return c
 }
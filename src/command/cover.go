@@ -7,10 +7,12 @@ package command
 import (
 	"bytes"
 	"fmt"
+	"go/build"
 	"io/ioutil"
 	"log"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -18,20 +20,26 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	"github.com/neovim/go-client/nvim"
 	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/buildctx"
 	"github.com/zchee/nvim-go/src/config"
 	"github.com/zchee/nvim-go/src/internal/cover"
 	"github.com/zchee/nvim-go/src/nvimutil"
 )
 
-// cmdCoverEval struct type for Eval of GoBuild command.
+// ----------------------------------------------------------------------------
+// GoCover
+
+// cmdCoverEval struct type for Eval of GoCover command.
 type cmdCoverEval struct {
-	Cwd  string `msgpack:",array"`
-	File string `msgpack:",array"`
+	Cwd   string `msgpack:",array"`
+	File  string
+	BufNr int
 }
 
 func (c *Command) cmdCover(eval *cmdCoverEval) {
 	go func() {
-		err := c.cover(eval)
+		bctx := c.buildContexts.Context(eval.BufNr, filepath.Dir(eval.File))
+		err := c.cover(bctx, eval)
 
 		switch e := err.(type) {
 		case error:
@@ -51,7 +59,7 @@ func (c *Command) cmdCover(eval *cmdCoverEval) {
 
 // cover run the go tool cover command and highlight current buffer based cover
 // profile result.
-func (c *Command) cover(eval *cmdCoverEval) interface{} {
+func (c *Command) cover(bctx *buildctx.Context, eval *cmdCoverEval) interface{} {
 	defer nvimutil.Profile(c.ctx, time.Now(), "GoCover")
 
 	coverFile, err := ioutil.TempFile(os.TempDir(), "nvim-go-cover")
@@ -65,67 +73,341 @@ func (c *Command) cover(eval *cmdCoverEval) interface{} {
 		cmd.Args = append(cmd.Args, config.CoverFlags...)
 	}
 	cmd.Dir = filepath.Dir(eval.File)
+	cmd.Env = bctx.Environ()
 
 	var stdout bytes.Buffer
 	cmd.Stdout = &stdout
 
 	if coverErr := cmd.Run(); coverErr != nil && coverErr.(*exec.ExitError) != nil {
-		errlist, err := nvimutil.ParseError(stdout.Bytes(), filepath.Dir(eval.File), &c.buildContext.Build, nil)
+		errlist, err := nvimutil.ParseError(stdout.Bytes(), filepath.Dir(eval.File), &bctx.Build, nil)
 		if err != nil {
 			return errors.WithStack(err)
 		}
 		return errlist
 	}
-	delete(c.buildContext.Errlist, "Cover")
+	delete(bctx.Errlist, "Cover")
 
 	profile, err := cover.ParseProfiles(coverFile.Name())
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	b, err := c.Nvim.CurrentBuffer()
+	pkgDirs, err := coverPackageDirs(&bctx.BuildContext, filepath.Dir(eval.File), profile)
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	buf, err := c.Nvim.BufferLines(b, 0, -1, true)
+	c.storeCover(coverFiles(profile, pkgDirs))
+
+	return c.highlightCover(nvim.Buffer(bctx.BufNr), eval.File)
+}
+
+// coverPackageDirs resolves the directory of each profiled packages from its
+// import path, because the profile file names are "importPath/file.go".
+// srcDir is the directory of the tested package used to resolve the vendored
+// or module packages.
+func coverPackageDirs(ctxt *build.Context, srcDir string, profile []*cover.Profile) (map[string]string, error) {
+	pkgDirs := make(map[string]string)
+	for _, prof := range profile {
+		importPath := path.Dir(prof.FileName)
+		if _, ok := pkgDirs[importPath]; ok {
+			continue
+		}
+		pkg, err := ctxt.Import(importPath, srcDir, build.FindOnly)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not find the %s package of the cover profile", importPath)
+		}
+		pkgDirs[importPath] = pkg.Dir
+	}
+
+	return pkgDirs, nil
+}
+
+// coverFiles returns the map of the absolute file name and its profile.
+// pkgDirs is the map of the import path and directory of the profiled packages.
+func coverFiles(profile []*cover.Profile, pkgDirs map[string]string) map[string]*cover.Profile {
+	files := make(map[string]*cover.Profile)
+	for _, prof := range profile {
+		dir, ok := pkgDirs[path.Dir(prof.FileName)]
+		if !ok {
+			continue
+		}
+		files[filepath.Join(dir, path.Base(prof.FileName))] = prof
+	}
+
+	return files
+}
+
+// coverHighlights returns the highlight group of each lines of the profile.
+// The line number is started by 0 same as nvim_buf_add_highlight.
+func coverHighlights(prof *cover.Profile) map[int]string {
+	hls := make(map[int]string)
+	for _, block := range prof.Blocks {
+		for line := block.StartLine - 1; line <= block.EndLine-1; line++ {
+			// not highlighting the last RBRACE of the function
+			if line == block.EndLine-1 && block.EndCol == 2 {
+				break
+			}
+
+			var hl string
+			switch {
+			case block.Count == 0:
+				hl = "GoCoverMiss"
+			case block.Count-block.NumStmt == 0:
+				hl = "GoCoverPartial"
+			default:
+				hl = "GoCoverHit"
+			}
+			if _, ok := hls[line]; !ok {
+				hls[line] = hl
+			}
+		}
+	}
+
+	return hls
+}
+
+// coverCounts returns the execution count of the first block that starts at
+// each lines of the profile. The line number is started by 0.
+func coverCounts(prof *cover.Profile) map[int]int {
+	counts := make(map[int]int)
+	for _, block := range prof.Blocks {
+		line := block.StartLine - 1
+		if _, ok := counts[line]; !ok {
+			counts[line] = block.Count
+		}
+	}
+
+	return counts
+}
+
+// storeCover caches the profile of each files and shows the coverage highlights.
+// The replaced profiles are kept as the previous run for GoCoverDiff.
+func (c *Command) storeCover(files map[string]*cover.Profile) {
+	c.coverMu.Lock()
+	defer c.coverMu.Unlock()
+
+	for file, prof := range files {
+		if old, ok := c.coverProfiles[file]; ok {
+			c.coverPrevious[file] = old
+		}
+		c.coverProfiles[file] = prof
+	}
+	c.coverHidden = false
+}
+
+// coverNamespace returns the highlight namespace of the coverage.
+// c.coverMu must be held.
+func (c *Command) coverNamespace() (int, error) {
+	if c.coverNS != 0 {
+		return c.coverNS, nil
+	}
+	if err := c.Nvim.Call("nvim_create_namespace", &c.coverNS, "nvim-go-cover"); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	return c.coverNS, nil
+}
+
+// coverHasVirtualText reports whether the Neovim supports the virtual text of
+// the hit counts. c.coverMu must be held.
+func (c *Command) coverHasVirtualText() bool {
+	if c.coverVirtualText == nil {
+		var has int
+		if err := c.Nvim.Call("has", &has, "nvim-0.3.2"); err != nil {
+			return false
+		}
+		virtualText := has == 1
+		c.coverVirtualText = &virtualText
+	}
+
+	return *c.coverVirtualText
+}
+
+// coverSignID is the first sign id of the coverage signs.
+const coverSignID = 20000
+
+// coverSigns is the sign text of the coverage highlight groups.
+var coverSigns = map[string]string{
+	"GoCoverHit":     "\u258e",
+	"GoCoverPartial": "\u258e",
+	"GoCoverMiss":    "\u258e",
+}
+
+// defineCoverSigns defines the coverage signs named same as the highlight group.
+// c.coverMu must be held.
+func (c *Command) defineCoverSigns() error {
+	if c.coverSignsDefined {
+		return nil
+	}
+	for hl, text := range coverSigns {
+		if _, err := nvimutil.NewSign(c.Nvim, hl, text, hl, ""); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	c.coverSignsDefined = true
+
+	return nil
+}
+
+// highlightCover highlights the b buffer with the cached profile of file.
+// Places the signs instead of the line highlights if config.CoverStyle is "sign".
+// Clears the coverage highlights of b if file has no cached profile.
+func (c *Command) highlightCover(b nvim.Buffer, file string) error {
+	c.coverMu.Lock()
+	defer c.coverMu.Unlock()
+
+	ns, err := c.coverNamespace()
 	if err != nil {
-		return errors.WithStack(err)
+		return err
+	}
+	signStyle := config.CoverStyle == "sign"
+	if signStyle {
+		if err := c.defineCoverSigns(); err != nil {
+			return err
+		}
 	}
 
-	highlighted := make(map[int]bool)
-	var res int // for ignore the msgpack decode errror. not used
 	batch := c.Nvim.NewBatch()
-	for _, prof := range profile {
-		if filepath.Base(prof.FileName) == filepath.Base(eval.File) {
+	clearCoverBuffer(batch, b, ns, c.coverBuffers[b])
+	delete(c.coverBuffers, b)
 
-			if config.DebugEnable {
-				log.Printf("prof.Blocks:\n%+v\n", spew.Sdump(prof.Blocks))
-				log.Printf("prof.Boundaries():\n%+v\n", spew.Sdump(prof.Boundaries(nvimutil.ToByteSlice(buf))))
+	prof, ok := c.coverProfiles[file]
+	if ok && !c.coverHidden {
+		if config.DebugEnable {
+			log.Printf("prof.Blocks:\n%+v\n", spew.Sdump(prof.Blocks))
+		}
+		var (
+			res   int // for ignore the msgpack decode errror. not used
+			signs []int
+		)
+		for line, hl := range coverHighlights(prof) {
+			if signStyle {
+				id := coverSignID + line
+				batch.Command(fmt.Sprintf("sign place %d line=%d name=%s buffer=%d", id, line+1, hl, b))
+				signs = append(signs, id)
+				continue
 			}
-			for _, block := range prof.Blocks {
-				for line := block.StartLine - 1; line <= block.EndLine-1; line++ { // nvim_buf_add_highlight line started by 0
-					// not highlighting the last RBRACE of the function
-					if line == block.EndLine-1 && block.EndCol == 2 {
-						break
-					}
-
-					var hl string
-					switch {
-					case block.Count == 0:
-						hl = "GoCoverMiss"
-					case block.Count-block.NumStmt == 0:
-						hl = "GoCoverPartial"
-					default:
-						hl = "GoCoverHit"
-					}
-					if !highlighted[line] {
-						batch.AddBufferHighlight(b, 0, hl, line, 0, -1, &res)
-						highlighted[line] = true
-					}
-				}
+			batch.AddBufferHighlight(b, ns, hl, line, 0, -1, &res)
+		}
+		if config.CoverCounts && prof.Mode != "set" && c.coverHasVirtualText() {
+			for line, count := range coverCounts(prof) {
+				chunks := [][]string{{fmt.Sprintf("\u00d7%d", count), "Comment"}}
+				batch.Call("nvim_buf_set_virtual_text", nil, b, ns, line, chunks, make(map[string]interface{}))
 			}
 		}
+		c.coverBuffers[b] = signs
 	}
 
 	return errors.WithStack(batch.Execute())
 }
+
+// clearCoverBuffer clears the coverage highlights, virtual texts and signs of b.
+func clearCoverBuffer(batch *nvim.Batch, b nvim.Buffer, ns int, signs []int) {
+	// the buffer might be already wiped out
+	batch.Command(fmt.Sprintf("silent! call nvim_buf_clear_highlight(%d, %d, 0, -1)", b, ns))
+	for _, id := range signs {
+		batch.Command(fmt.Sprintf("silent! sign unplace %d buffer=%d", id, b))
+	}
+}
+
+// clearCoverHighlights clears the coverage highlights of the all highlighted buffers.
+// c.coverMu must be held.
+func (c *Command) clearCoverHighlights() error {
+	if c.coverNS == 0 {
+		return nil
+	}
+
+	batch := c.Nvim.NewBatch()
+	for b, signs := range c.coverBuffers {
+		clearCoverBuffer(batch, b, c.coverNS, signs)
+	}
+	c.coverBuffers = make(map[nvim.Buffer][]int)
+
+	return errors.WithStack(batch.Execute())
+}
+
+// CoverBufEnter re-applies the coverage highlights of the cached profile to
+// the entered buffer without rerunning the tests.
+func (c *Command) CoverBufEnter(bufnr int, file string) error {
+	c.coverMu.Lock()
+	_, ok := c.coverProfiles[file]
+	hidden := c.coverHidden
+	c.coverMu.Unlock()
+
+	if !ok || hidden {
+		return nil
+	}
+
+	return c.highlightCover(nvim.Buffer(bufnr), file)
+}
+
+// DeleteCoverBuffer forgets the wiped out buffer.
+func (c *Command) DeleteCoverBuffer(bufnr int) {
+	c.coverMu.Lock()
+	delete(c.coverBuffers, nvim.Buffer(bufnr))
+	c.coverMu.Unlock()
+}
+
+// ----------------------------------------------------------------------------
+// GoCoverClear
+
+func (c *Command) cmdCoverClear() {
+	go func() {
+		if err := c.CoverClear(); err != nil {
+			nvimutil.ErrorWrap(c.Nvim, err)
+		}
+	}()
+}
+
+// CoverClear clears the all coverage highlights and the cached profiles.
+// The cleared profiles are kept as the previous run for GoCoverDiff.
+func (c *Command) CoverClear() error {
+	defer nvimutil.Profile(c.ctx, time.Now(), "GoCoverClear")
+
+	c.coverMu.Lock()
+	defer c.coverMu.Unlock()
+
+	for file, prof := range c.coverProfiles {
+		c.coverPrevious[file] = prof
+	}
+	c.coverProfiles = make(map[string]*cover.Profile)
+	c.coverHidden = false
+
+	return c.clearCoverHighlights()
+}
+
+// ----------------------------------------------------------------------------
+// GoCoverToggle
+
+func (c *Command) cmdCoverToggle(eval *cmdCoverEval) {
+	go func() {
+		if err := c.CoverToggle(eval); err != nil {
+			nvimutil.ErrorWrap(c.Nvim, err)
+		}
+	}()
+}
+
+// CoverToggle toggles the coverage highlights. Runs GoCover if the current
+// file has no cached profile.
+func (c *Command) CoverToggle(eval *cmdCoverEval) error {
+	defer nvimutil.Profile(c.ctx, time.Now(), "GoCoverToggle")
+
+	c.coverMu.Lock()
+	_, ok := c.coverProfiles[eval.File]
+	shown := !c.coverHidden && len(c.coverBuffers) > 0
+	if shown {
+		c.coverHidden = true
+		err := c.clearCoverHighlights()
+		c.coverMu.Unlock()
+		return err
+	}
+	c.coverHidden = false
+	c.coverMu.Unlock()
+
+	if !ok {
+		c.cmdCover(eval)
+		return nil
+	}
+
+	return c.highlightCover(nvim.Buffer(eval.BufNr), eval.File)
+}
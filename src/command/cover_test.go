@@ -0,0 +1,153 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/zchee/nvim-go/src/internal/cover"
+)
+
+func TestCoverFiles(t *testing.T) {
+	foo := &cover.Profile{FileName: "example.com/foo/foo.go"}
+	bar := &cover.Profile{FileName: "example.com/foo/bar/bar.go"}
+	baz := &cover.Profile{FileName: "example.com/baz/baz.go"}
+	pkgDirs := map[string]string{
+		"example.com/foo":     "/src/foo",
+		"example.com/foo/bar": "/src/foo/bar",
+	}
+
+	want := map[string]*cover.Profile{
+		"/src/foo/foo.go":     foo,
+		"/src/foo/bar/bar.go": bar,
+	}
+	if got := coverFiles([]*cover.Profile{foo, bar, baz}, pkgDirs); !reflect.DeepEqual(got, want) {
+		t.Errorf("coverFiles() = %v, want %v", got, want)
+	}
+}
+
+func TestCoverPackageDirs(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "nvim-go-cover")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(gopath)
+	defer os.Setenv("GO111MODULE", os.Getenv("GO111MODULE"))
+	os.Setenv("GO111MODULE", "off")
+
+	for _, dir := range []string{"example.com/foo", "example.com/foo/bar"} {
+		pkgDir := filepath.Join(gopath, "src", filepath.FromSlash(dir))
+		if err := os.MkdirAll(pkgDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(pkgDir, "x.go"), []byte("package x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctxt := build.Default
+	ctxt.GOPATH = gopath
+	srcDir := filepath.Join(gopath, "src", "example.com", "foo")
+
+	// the profile of the coverpkg flag has the other packages
+	profile := []*cover.Profile{
+		{FileName: "example.com/foo/foo.go"},
+		{FileName: "example.com/foo/bar/bar.go"},
+	}
+	want := map[string]string{
+		"example.com/foo":     srcDir,
+		"example.com/foo/bar": filepath.Join(srcDir, "bar"),
+	}
+	got, err := coverPackageDirs(&ctxt, srcDir, profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("coverPackageDirs() = %v, want %v", got, want)
+	}
+
+	if _, err := coverPackageDirs(&ctxt, srcDir, []*cover.Profile{{FileName: "example.com/baz/baz.go"}}); err == nil {
+		t.Error("coverPackageDirs() with the not found package: want error")
+	}
+}
+
+func TestCoverHighlights(t *testing.T) {
+	prof := &cover.Profile{
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 14, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 2},
+			{StartLine: 7, StartCol: 20, EndLine: 8, EndCol: 3, NumStmt: 1, Count: 0},
+			{StartLine: 8, StartCol: 3, EndLine: 9, EndCol: 10, NumStmt: 1, Count: 1},
+		},
+	}
+
+	want := map[int]string{
+		2: "GoCoverHit",
+		3: "GoCoverHit",
+		6: "GoCoverMiss",
+		7: "GoCoverMiss",
+		8: "GoCoverPartial",
+	}
+	if got := coverHighlights(prof); !reflect.DeepEqual(got, want) {
+		t.Errorf("coverHighlights() = %v, want %v", got, want)
+	}
+}
+
+func TestCoverCounts(t *testing.T) {
+	prof := &cover.Profile{
+		Mode: "count",
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 14, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 12},
+			{StartLine: 5, StartCol: 2, EndLine: 5, EndCol: 20, NumStmt: 1, Count: 3},
+			{StartLine: 5, StartCol: 20, EndLine: 6, EndCol: 3, NumStmt: 1, Count: 0},
+		},
+	}
+
+	want := map[int]int{2: 12, 4: 3}
+	if got := coverCounts(prof); !reflect.DeepEqual(got, want) {
+		t.Errorf("coverCounts() = %v, want %v", got, want)
+	}
+}
+
+func TestCoverChanges(t *testing.T) {
+	prev := &cover.Profile{
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 14, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 2},
+			{StartLine: 7, StartCol: 20, EndLine: 8, EndCol: 3, NumStmt: 1, Count: 0},
+			{StartLine: 8, StartCol: 3, EndLine: 9, EndCol: 10, NumStmt: 1, Count: 1},
+			{StartLine: 10, StartCol: 3, EndLine: 11, EndCol: 10, NumStmt: 1, Count: 1},
+		},
+	}
+	cur := &cover.Profile{
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 14, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 0},
+			{StartLine: 7, StartCol: 20, EndLine: 8, EndCol: 3, NumStmt: 1, Count: 4},
+			{StartLine: 8, StartCol: 3, EndLine: 9, EndCol: 10, NumStmt: 1, Count: 5},
+			{StartLine: 12, StartCol: 3, EndLine: 13, EndCol: 10, NumStmt: 1, Count: 0},
+		},
+	}
+
+	changes := coverChanges(prev, cur)
+	want := []*coverChange{
+		{ProfileBlock: cur.Blocks[0], PrevCount: 2},
+		{ProfileBlock: cur.Blocks[1], PrevCount: 0},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("coverChanges() = %v, want %v", changes, want)
+	}
+
+	errlist := coverDiffErrors(map[string][]*coverChange{"/src/foo/foo.go": changes})
+	wantErrs := []*nvim.QuickfixError{
+		{FileName: "/src/foo/foo.go", LNum: 3, Col: 14, Type: "W", Text: "coverage lost: count 2 -> 0"},
+		{FileName: "/src/foo/foo.go", LNum: 7, Col: 20, Type: "I", Text: "coverage gained: count 0 -> 4"},
+	}
+	if !reflect.DeepEqual(errlist, wantErrs) {
+		t.Errorf("coverDiffErrors() = %v, want %v", errlist, wantErrs)
+	}
+}
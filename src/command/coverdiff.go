@@ -0,0 +1,179 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/internal/cover"
+	"github.com/zchee/nvim-go/src/nvimutil"
+)
+
+// ----------------------------------------------------------------------------
+// GoCoverDiff
+
+// coverDiffHighlights is the highlight groups of the coverage diff and its default link.
+var coverDiffHighlights = map[string]string{
+	"GoCoverLost":   "DiffDelete",
+	"GoCoverGained": "DiffAdd",
+}
+
+// coverChange represents a block whose coverage changed between two runs.
+type coverChange struct {
+	cover.ProfileBlock
+	PrevCount int
+}
+
+// Lost reports whether the block lost the coverage.
+func (ch *coverChange) Lost() bool {
+	return ch.PrevCount > 0 && ch.Count == 0
+}
+
+func (c *Command) cmdCoverDiff() {
+	go func() {
+		if err := c.CoverDiff(); err != nil {
+			nvimutil.ErrorWrap(c.Nvim, err)
+		}
+	}()
+}
+
+// CoverDiff highlights the blocks whose coverage changed between the previous
+// and last coverage runs of each files, and lists them in the error list.
+func (c *Command) CoverDiff() error {
+	defer nvimutil.Profile(c.ctx, time.Now(), "GoCoverDiff")
+
+	c.coverMu.Lock()
+	changes := make(map[string][]*coverChange)
+	for file, prev := range c.coverPrevious {
+		cur, ok := c.coverProfiles[file]
+		if !ok {
+			continue
+		}
+		if ch := coverChanges(prev, cur); len(ch) > 0 {
+			changes[file] = ch
+		}
+	}
+	c.coverMu.Unlock()
+
+	if len(changes) == 0 {
+		c.errs.Delete("CoverDiff")
+		errmap := c.Errors()
+		errmap["CoverDiff"] = nil // clears the diagnostics
+		nvimutil.ErrorList(c.Nvim, errmap, true)
+		return nvimutil.EchoSuccess(c.Nvim, "GoCoverDiff", "no coverage changes")
+	}
+
+	if err := c.highlightCoverDiff(changes); err != nil {
+		return err
+	}
+
+	c.errs.Store("CoverDiff", coverDiffErrors(changes))
+	return nvimutil.ErrorList(c.Nvim, c.Errors(), true)
+}
+
+// coverChanges returns the blocks of cur whose count went from >0 to 0 or
+// vice versa from the prev profile. The blocks are matched by its position.
+func coverChanges(prev, cur *cover.Profile) []*coverChange {
+	type blockPos struct {
+		startLine, startCol, endLine, endCol int
+	}
+	prevCounts := make(map[blockPos]int)
+	for _, b := range prev.Blocks {
+		prevCounts[blockPos{b.StartLine, b.StartCol, b.EndLine, b.EndCol}] = b.Count
+	}
+
+	var changes []*coverChange
+	for _, b := range cur.Blocks {
+		count, ok := prevCounts[blockPos{b.StartLine, b.StartCol, b.EndLine, b.EndCol}]
+		if !ok || (count > 0) == (b.Count > 0) {
+			continue
+		}
+		changes = append(changes, &coverChange{ProfileBlock: b, PrevCount: count})
+	}
+
+	return changes
+}
+
+// coverDiffErrors returns the error list of the coverage changes sorted by file.
+// The lost blocks are the warnings.
+func coverDiffErrors(changes map[string][]*coverChange) []*nvim.QuickfixError {
+	files := make([]string, 0, len(changes))
+	for file := range changes {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var errlist []*nvim.QuickfixError
+	for _, file := range files {
+		for _, ch := range changes[file] {
+			e := &nvim.QuickfixError{
+				FileName: file,
+				LNum:     ch.StartLine,
+				Col:      ch.StartCol,
+			}
+			if ch.Lost() {
+				e.Type = nvimutil.SeverityWarning
+				e.Text = fmt.Sprintf("coverage lost: count %d -> 0", ch.PrevCount)
+			} else {
+				e.Type = nvimutil.SeverityInfo
+				e.Text = fmt.Sprintf("coverage gained: count 0 -> %d", ch.Count)
+			}
+			errlist = append(errlist, e)
+		}
+	}
+
+	return errlist
+}
+
+// highlightCoverDiff highlights the changed blocks of the loaded buffers
+// instead of the coverage highlights.
+func (c *Command) highlightCoverDiff(changes map[string][]*coverChange) error {
+	bufs, err := c.Nvim.Buffers()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	c.coverMu.Lock()
+	defer c.coverMu.Unlock()
+
+	ns, err := c.coverNamespace()
+	if err != nil {
+		return err
+	}
+
+	batch := c.Nvim.NewBatch()
+	for hl, link := range coverDiffHighlights {
+		batch.Command(fmt.Sprintf("highlight default link %s %s", hl, link))
+	}
+	for _, b := range bufs {
+		name, err := c.Nvim.BufferName(b)
+		if err != nil {
+			continue
+		}
+		ch, ok := changes[name]
+		if !ok {
+			continue
+		}
+
+		clearCoverBuffer(batch, b, ns, c.coverBuffers[b])
+		var res int // for ignore the msgpack decode errror. not used
+		for _, block := range ch {
+			hl := "GoCoverGained"
+			if block.Lost() {
+				hl = "GoCoverLost"
+			}
+			for line := block.StartLine - 1; line <= block.EndLine-1; line++ {
+				batch.AddBufferHighlight(b, ns, hl, line, 0, -1, &res)
+			}
+		}
+		c.coverBuffers[b] = nil
+	}
+
+	return errors.WithStack(batch.Execute())
+}
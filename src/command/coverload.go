@@ -0,0 +1,356 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/build"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/buildctx"
+	"github.com/zchee/nvim-go/src/internal/cover"
+	"github.com/zchee/nvim-go/src/nvimutil"
+)
+
+// ----------------------------------------------------------------------------
+// GoCoverLoad
+
+// cmdCoverLoadEval struct type for Eval of GoCoverLoad and GoCoverExport commands.
+type cmdCoverLoadEval struct {
+	Cwd   string `msgpack:",array"`
+	BufNr int
+}
+
+func (c *Command) cmdCoverLoad(args []string, eval *cmdCoverLoadEval) {
+	bctx := c.buildContexts.Context(eval.BufNr, eval.Cwd)
+	go func() {
+		if err := c.CoverLoad(bctx, args[0], eval.Cwd); err != nil {
+			nvimutil.ErrorWrap(c.Nvim, err)
+		}
+	}()
+}
+
+// CoverLoad loads the existing coverage profile such as produced by the CI,
+// and highlights the loaded buffers of the profiled files.
+func (c *Command) CoverLoad(bctx *buildctx.Context, file, cwd string) error {
+	defer nvimutil.Profile(c.ctx, time.Now(), "GoCoverLoad")
+
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(cwd, file)
+	}
+	profile, err := cover.ParseProfiles(file)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	pkgDirs := coverProfileDirs(bctx, cwd, profile)
+	files := coverFiles(profile, pkgDirs)
+	if len(files) == 0 {
+		return errors.Errorf("not found the local files of the %s profile", filepath.Base(file))
+	}
+	c.storeCover(files)
+
+	if err := c.highlightCoverBuffers(); err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("loaded %d files", len(files))
+	if n := len(profile) - len(files); n > 0 {
+		msg += fmt.Sprintf(", %d files not found", n)
+	}
+	return nvimutil.EchoSuccess(c.Nvim, "GoCoverLoad", msg)
+}
+
+// coverProfileDirs returns the map of the import path and local directory of
+// the profiled packages. The packages that could not resolve are ignored.
+func coverProfileDirs(bctx *buildctx.Context, cwd string, profile []*cover.Profile) map[string]string {
+	pkgDirs := make(map[string]string)
+	for _, prof := range profile {
+		pkg := path.Dir(prof.FileName)
+		if _, ok := pkgDirs[pkg]; ok {
+			continue
+		}
+		if dir, ok := coverPackageDir(bctx, cwd, pkg); ok {
+			pkgDirs[pkg] = dir
+		}
+	}
+
+	return pkgDirs
+}
+
+// coverPackageDir resolves the local directory of the pkg package from the
+// module or GOPATH of bctx.
+func coverPackageDir(bctx *buildctx.Context, cwd, pkg string) (string, bool) {
+	switch {
+	case filepath.IsAbs(pkg):
+		return pkg, true
+	case strings.HasPrefix(pkg, "_/"):
+		// go test writes the absolute path with "_" prefix if outside of GOPATH
+		return filepath.FromSlash(pkg[1:]), true
+	case bctx.Build.ModulePath != "" && (pkg == bctx.Build.ModulePath || strings.HasPrefix(pkg, bctx.Build.ModulePath+"/")):
+		rel := strings.TrimPrefix(pkg, bctx.Build.ModulePath)
+		return filepath.Join(bctx.Build.ModuleRoot, filepath.FromSlash(rel)), true
+	}
+
+	bp, err := bctx.BuildContext.Import(pkg, cwd, build.FindOnly)
+	if err != nil {
+		return "", false
+	}
+	return bp.Dir, true
+}
+
+// highlightCoverBuffers highlights the all loaded buffers that has the cached profile.
+func (c *Command) highlightCoverBuffers() error {
+	bufs, err := c.Nvim.Buffers()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, b := range bufs {
+		name, err := c.Nvim.BufferName(b)
+		if err != nil {
+			continue
+		}
+		c.coverMu.Lock()
+		_, ok := c.coverProfiles[name]
+		c.coverMu.Unlock()
+		if !ok {
+			continue
+		}
+		if err := c.highlightCover(b, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+// GoCoverExport
+
+func (c *Command) cmdCoverExport(args []string, eval *cmdCoverLoadEval) {
+	file := "coverage.html"
+	if len(args) > 0 {
+		file = args[0]
+	}
+	go func() {
+		if err := c.CoverExport(file, eval.Cwd); err != nil {
+			nvimutil.ErrorWrap(c.Nvim, err)
+		}
+	}()
+}
+
+// CoverExport writes the HTML coverage report of the cached profiles to file.
+func (c *Command) CoverExport(file, cwd string) error {
+	defer nvimutil.Profile(c.ctx, time.Now(), "GoCoverExport")
+
+	c.coverMu.Lock()
+	files := make(map[string]*cover.Profile, len(c.coverProfiles))
+	for name, prof := range c.coverProfiles {
+		files[name] = prof
+	}
+	c.coverMu.Unlock()
+
+	if len(files) == 0 {
+		return errors.New("not found the coverage profiles. run GoCover, GoCoverReport or GoCoverLoad first")
+	}
+
+	var buf bytes.Buffer
+	if err := coverHTML(&buf, files, cwd); err != nil {
+		return err
+	}
+
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(cwd, file)
+	}
+	if err := ioutil.WriteFile(file, buf.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nvimutil.EchoSuccess(c.Nvim, "GoCoverExport", "wrote "+file)
+}
+
+// coverHTMLFile represents a source file of the HTML coverage report.
+type coverHTMLFile struct {
+	Name     string
+	Body     template.HTML
+	Coverage float64
+}
+
+// coverHTML writes the HTML coverage report of files to w, same as the go tool cover -html.
+// The file names are shown as the relative path from cwd if possible.
+func coverHTML(w io.Writer, files map[string]*cover.Profile, cwd string) error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var htmlFiles []*coverHTMLFile
+	for _, name := range names {
+		prof := files[name]
+		src, err := ioutil.ReadFile(name)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		var body bytes.Buffer
+		if err := coverHTMLGen(&body, src, prof.Boundaries(src)); err != nil {
+			return err
+		}
+
+		var covered, total int
+		for _, b := range prof.Blocks {
+			total += b.NumStmt
+			if b.Count > 0 {
+				covered += b.NumStmt
+			}
+		}
+
+		display := name
+		if rel, err := filepath.Rel(cwd, name); err == nil && !strings.HasPrefix(rel, "..") {
+			display = rel
+		}
+		htmlFiles = append(htmlFiles, &coverHTMLFile{
+			Name:     display,
+			Body:     template.HTML(body.String()),
+			Coverage: coverPercent(covered, total),
+		})
+	}
+
+	return errors.WithStack(coverHTMLTemplate.Execute(w, htmlFiles))
+}
+
+// coverHTMLGen generates the HTML coverage report of src with the boundaries.
+func coverHTMLGen(w io.Writer, src []byte, boundaries []cover.Boundary) error {
+	dst := bufio.NewWriter(w)
+	for i := range src {
+		for len(boundaries) > 0 && boundaries[0].Offset == i {
+			b := boundaries[0]
+			if b.Start {
+				n := 0
+				if b.Count > 0 {
+					n = int(b.Norm*9) + 1
+				}
+				fmt.Fprintf(dst, `<span class="cov%v" title="%v">`, n, b.Count)
+			} else {
+				dst.WriteString("</span>")
+			}
+			boundaries = boundaries[1:]
+		}
+		switch b := src[i]; b {
+		case '>':
+			dst.WriteString("&gt;")
+		case '<':
+			dst.WriteString("&lt;")
+		case '&':
+			dst.WriteString("&amp;")
+		case '\t':
+			dst.WriteString("        ")
+		default:
+			dst.WriteByte(b)
+		}
+	}
+
+	return errors.WithStack(dst.Flush())
+}
+
+var coverHTMLTemplate = template.Must(template.New("html").Funcs(template.FuncMap{
+	"colors": func() template.CSS {
+		var buf bytes.Buffer
+		for i := 0; i < 11; i++ {
+			fmt.Fprintf(&buf, "\t\t\t.cov%v { color: %v }\n", i, coverHTMLColor(i))
+		}
+		return template.CSS(buf.String())
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+	<head>
+		<meta http-equiv="Content-Type" content="text/html; charset=utf-8">
+		<title>nvim-go coverage</title>
+		<style>
+			body { background: black; color: rgb(80, 80, 80); }
+			body, pre, #legend span { font-family: Menlo, monospace; font-weight: bold; }
+			#topbar { background: black; position: fixed; top: 0; left: 0; right: 0; height: 42px; border-bottom: 1px solid rgb(80, 80, 80); }
+			#content { margin-top: 50px; }
+			#nav, #legend { float: left; margin-left: 10px; }
+			#legend { margin-top: 12px; }
+			#nav { margin-top: 10px; }
+			#legend span { margin: 0 5px; }
+{{colors}}
+		</style>
+	</head>
+	<body>
+		<div id="topbar">
+			<div id="nav">
+				<select id="files">
+				{{range $i, $f := .}}
+				<option value="file{{$i}}">{{$f.Name}} ({{printf "%.1f" $f.Coverage}}%)</option>
+				{{end}}
+				</select>
+			</div>
+			<div id="legend">
+				<span>not tracked</span>
+				<span class="cov0">not covered</span>
+				<span class="cov8">covered</span>
+			</div>
+		</div>
+		<div id="content">
+		{{range $i, $f := .}}
+		<pre class="file" id="file{{$i}}" style="display: none">{{$f.Body}}</pre>
+		{{end}}
+		</div>
+	</body>
+	<script>
+	(function() {
+		var files = document.getElementById('files');
+		var visible;
+		files.addEventListener('change', onChange, false);
+		function select(part) {
+			if (visible)
+				visible.style.display = 'none';
+			visible = document.getElementById(part);
+			if (!visible)
+				return;
+			files.value = part;
+			visible.style.display = 'block';
+			location.hash = part;
+		}
+		function onChange() {
+			select(files.value);
+			window.scrollTo(0, 0);
+		}
+		if (location.hash != "") {
+			select(location.hash.substr(1));
+		}
+		if (!visible) {
+			select("file0");
+		}
+	})();
+	</script>
+</html>
+`))
+
+// coverHTMLColor returns the CSS color of the coverage heat level, same as the go tool cover.
+func coverHTMLColor(i int) string {
+	if i == 0 {
+		return "rgb(192, 0, 0)"
+	}
+	// gradient from gray to green
+	r := 128 - 12*(i-1)
+	g := 128 + 12*(i-1)
+	b := 128 + 3*(i-1)
+	return fmt.Sprintf("rgb(%v, %v, %v)", r, g, b)
+}
@@ -0,0 +1,68 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"go/build"
+	"testing"
+
+	"github.com/zchee/nvim-go/src/buildctx"
+	"github.com/zchee/nvim-go/src/internal/cover"
+)
+
+func TestCoverPackageDir(t *testing.T) {
+	ctxt := build.Default
+	ctxt.GOPATH = "/nonexistent/gopath"
+	bctx := &buildctx.Context{
+		Build: buildctx.Build{
+			Tool:       "mod",
+			ModulePath: "example.com/mod",
+			ModuleRoot: "/src/mod",
+		},
+		BuildContext: ctxt,
+	}
+
+	tests := []struct {
+		pkg    string
+		want   string
+		wantOK bool
+	}{
+		{pkg: "example.com/mod", want: "/src/mod", wantOK: true},
+		{pkg: "example.com/mod/internal/foo", want: "/src/mod/internal/foo", wantOK: true},
+		{pkg: "_/home/user/foo", want: "/home/user/foo", wantOK: true},
+		{pkg: "/home/user/bar", want: "/home/user/bar", wantOK: true},
+		{pkg: "example.com/module", wantOK: false},
+		{pkg: "example.com/other", wantOK: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.pkg, func(t *testing.T) {
+			got, ok := coverPackageDir(bctx, "/src/mod", tt.pkg)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("coverPackageDir(%q) = (%q, %v), want (%q, %v)", tt.pkg, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCoverHTMLGen(t *testing.T) {
+	src := []byte("package foo\n\nfunc Foo() {\n\tif a < b {\n\t}\n}\n")
+	prof := &cover.Profile{
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 12, EndLine: 4, EndCol: 11, NumStmt: 1, Count: 1},
+			{StartLine: 4, StartCol: 11, EndLine: 5, EndCol: 3, NumStmt: 0, Count: 0},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := coverHTMLGen(&buf, src, prof.Boundaries(src)); err != nil {
+		t.Fatal(err)
+	}
+	want := "package foo\n\nfunc Foo() <span class=\"cov8\" title=\"1\">{\n        if a &lt; b </span><span class=\"cov0\" title=\"0\">{\n        }</span>\n}\n"
+	if got := buf.String(); got != want {
+		t.Errorf("coverHTMLGen() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,414 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/buildctx"
+	"github.com/zchee/nvim-go/src/config"
+	"github.com/zchee/nvim-go/src/internal/cover"
+	"github.com/zchee/nvim-go/src/logger"
+	"github.com/zchee/nvim-go/src/nvimutil"
+	"go.uber.org/zap"
+)
+
+// ----------------------------------------------------------------------------
+// GoCoverReport
+
+// coverReportName is the buffer name of the GoCoverReport.
+const coverReportName = "__GO_COVER_REPORT__"
+
+// coverFunc represents the coverage of a function.
+type coverFunc struct {
+	File    string // absolute file name
+	Line    int
+	Col     int
+	Name    string
+	Covered int // number of the covered statements
+	Total   int // number of the statements
+}
+
+// coverPackage represents the coverage of a package and its functions.
+type coverPackage struct {
+	Path    string
+	Dir     string
+	Covered int
+	Total   int
+	Funcs   []*coverFunc
+}
+
+// coverReport represents a GoCoverReport buffer.
+type coverReport struct {
+	bctx           *buildctx.Context
+	buffer         *nvimutil.Buffer
+	pkgs           []*coverPackage
+	sortByCoverage bool
+	lines          []*coverFunc // function of each buffer lines. nil if not function line
+}
+
+// cmdCoverReportEval struct type for Eval of GoCoverReport command.
+type cmdCoverReportEval struct {
+	Dir   string `msgpack:",array"`
+	BufNr int
+}
+
+func (c *Command) cmdCoverReport(eval *cmdCoverReportEval) {
+	bctx := c.buildContexts.Context(eval.BufNr, eval.Dir)
+	go func() {
+		if err := c.CoverReport(bctx, eval.Dir); err != nil {
+			nvimutil.ErrorWrap(c.Nvim, err)
+		}
+	}()
+}
+
+// CoverReport runs the coverage of the all packages in the project of bctx
+// and opens the report buffer of the per-package and per-function coverage.
+func (c *Command) CoverReport(bctx *buildctx.Context, dir string) error {
+	defer nvimutil.Profile(c.ctx, time.Now(), "GoCoverReport")
+
+	if bctx.Build.Tool == "gb" {
+		return errors.New("GoCoverReport does not support gb")
+	}
+
+	root := testRunDir(bctx, dir)
+	pkgs, pkgDirs, err := testPackages(bctx, root)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if len(pkgs) == 0 {
+		return errors.New("not found the packages")
+	}
+
+	nvimutil.EchoProgress(c.Nvim, "GoCoverReport", "Running the coverage of %d packages", len(pkgs))
+	profile, err := c.runCoverProfile(bctx, root, pkgs)
+	if err != nil {
+		return err
+	}
+
+	files := coverFiles(profile, pkgDirs)
+	c.storeCover(files)
+
+	c.coverMu.Lock()
+	r := c.coverReport
+	if r == nil || !nvimutil.IsBufferValid(c.Nvim, r.buffer.Buffer()) {
+		r = &coverReport{buffer: nvimutil.NewBuffer(c.Nvim)}
+		if err := r.buffer.Create(coverReportName, nvimutil.FiletypeGoCoverReport, "silent botright 15 split", testBufferOption(nvimutil.FiletypeGoCoverReport)); err != nil {
+			c.coverMu.Unlock()
+			return errors.WithStack(err)
+		}
+		c.coverReport = r
+	}
+	r.bctx = bctx
+	r.pkgs = coverPackages(files, pkgs, pkgDirs)
+	c.coverMu.Unlock()
+
+	if err := r.buffer.SetLocalMapping(nvimutil.NoremapNormal, coverReportMapping()); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return c.renderCoverReport()
+}
+
+// runCoverProfile runs the go test -coverprofile command of pkgs packages and
+// returns the merged profiles.
+// The test failures are stored to the "Cover" error list, and the profiles of
+// the other packages are still returned.
+func (c *Command) runCoverProfile(bctx *buildctx.Context, dir string, pkgs []string) ([]*cover.Profile, error) {
+	coverFile, err := ioutil.TempFile(os.TempDir(), "nvim-go-cover")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	coverFile.Close()
+	defer os.Remove(coverFile.Name())
+
+	args := []string{"test", "-coverprofile=" + coverFile.Name()}
+	if config.CoverMode != "" {
+		args = append(args, "-covermode="+config.CoverMode)
+	}
+	args = append(args, config.CoverFlags...)
+	args = append(args, pkgs...)
+
+	cmd := exec.Command(bctx.Build.Cmd(), args...)
+	cmd.Dir = dir
+	cmd.Env = bctx.Environ()
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stdout
+
+	logger.FromContext(c.ctx).Info("runCoverProfile", zap.Strings("cmd", cmd.Args))
+	if coverErr := cmd.Run(); coverErr != nil {
+		if _, ok := coverErr.(*exec.ExitError); !ok {
+			return nil, errors.WithStack(coverErr)
+		}
+		errlist, err := nvimutil.ParseError(stdout.Bytes(), dir, &bctx.Build, nil)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		c.errs.Store("Cover", errlist)
+	} else {
+		c.errs.Delete("Cover")
+	}
+	errmap := c.Errors()
+	if _, ok := errmap["Cover"]; !ok {
+		errmap["Cover"] = nil // clears the diagnostics
+	}
+	nvimutil.ErrorList(c.Nvim, errmap, true)
+
+	profile, err := cover.ParseProfiles(coverFile.Name())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return profile, nil
+}
+
+// coverReportMapping returns the buffer local mappings of the GoCoverReport.
+func coverReportMapping() map[string]string {
+	action := func(name string) string {
+		return fmt.Sprintf(":<C-u>call rpcnotify(%d, 'GoCoverReportAction', '%s', line('.'))<CR>", config.ChannelID, name)
+	}
+
+	return map[string]string{
+		"<CR>": action("jump"),
+		"s":    action("sort"),
+		"q":    ":<C-u>close<CR>",
+	}
+}
+
+// renderCoverReport renders the coverage report to the GoCoverReport buffer if it opened.
+func (c *Command) renderCoverReport() error {
+	c.coverMu.Lock()
+	defer c.coverMu.Unlock()
+
+	r := c.coverReport
+	if r == nil || !nvimutil.IsBufferValid(c.Nvim, r.buffer.Buffer()) {
+		return nil
+	}
+
+	lines, funcs := renderCoverPackages(r.pkgs, r.sortByCoverage)
+	r.lines = funcs
+
+	defer nvimutil.Modifiable(c.Nvim, r.buffer.Buffer())()
+	return errors.WithStack(c.Nvim.SetBufferLines(r.buffer.Buffer(), 0, -1, true, lines))
+}
+
+// handleCoverReportAction handles the buffer local mapping action of the GoCoverReport.
+func (c *Command) handleCoverReportAction(action string, line int) {
+	go func() {
+		if err := c.coverReportAction(action, line); err != nil {
+			nvimutil.ErrorWrap(c.Nvim, err)
+		}
+	}()
+}
+
+// coverReportAction runs the action of the line.
+func (c *Command) coverReportAction(action string, line int) error {
+	c.coverMu.Lock()
+	r := c.coverReport
+	if r == nil {
+		c.coverMu.Unlock()
+		return nil
+	}
+	var fn *coverFunc
+	if line >= 1 && line <= len(r.lines) {
+		fn = r.lines[line-1]
+	}
+	if action == "sort" {
+		r.sortByCoverage = !r.sortByCoverage
+	}
+	c.coverMu.Unlock()
+
+	switch action {
+	case "sort":
+		return c.renderCoverReport()
+	case "jump":
+		if fn == nil {
+			return nil
+		}
+		if err := c.Nvim.Command("wincmd p"); err != nil {
+			return errors.WithStack(err)
+		}
+		w, err := c.Nvim.CurrentWindow()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		pos := token.Position{Filename: fn.File, Line: fn.Line, Column: fn.Col}
+		if err := nvimutil.GotoPos(c.Nvim, w, pos, filepath.Dir(fn.File)); err != nil {
+			return err
+		}
+		b, err := c.Nvim.CurrentBuffer()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return c.highlightCover(b, fn.File)
+	}
+
+	return nil
+}
+
+// coverPackages returns the coverage of pkgs packages from the profile of
+// each files. The packages that has no profile are ignored.
+func coverPackages(files map[string]*cover.Profile, pkgs []string, pkgDirs map[string]string) []*coverPackage {
+	var result []*coverPackage
+	for _, pkg := range pkgs {
+		dir := pkgDirs[pkg]
+		cp := &coverPackage{Path: pkg, Dir: dir}
+
+		var names []string
+		for file := range files {
+			if filepath.Dir(file) == dir {
+				names = append(names, file)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		sort.Strings(names)
+
+		for _, file := range names {
+			prof := files[file]
+			for _, b := range prof.Blocks {
+				cp.Total += b.NumStmt
+				if b.Count > 0 {
+					cp.Covered += b.NumStmt
+				}
+			}
+			funcs, err := coverFuncs(file, nil, prof)
+			if err != nil {
+				continue
+			}
+			cp.Funcs = append(cp.Funcs, funcs...)
+		}
+		result = append(result, cp)
+	}
+
+	return result
+}
+
+// coverFuncs returns the coverage of each functions in the file.
+// src is the source of file same as parser.ParseFile.
+func coverFuncs(file string, src interface{}, prof *cover.Profile) ([]*coverFunc, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, src, 0)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var funcs []*coverFunc
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		start, end := fset.Position(fn.Pos()), fset.Position(fn.End())
+		name := fn.Name.Name
+		if fn.Recv != nil && len(fn.Recv.List) > 0 {
+			name = recvTypeName(fn.Recv.List[0].Type) + "." + name
+		}
+
+		cf := &coverFunc{File: file, Line: start.Line, Col: start.Column, Name: name}
+		for _, b := range prof.Blocks {
+			if b.StartLine > end.Line || (b.StartLine == end.Line && b.StartCol >= end.Column) {
+				// past the end of the function
+				break
+			}
+			if b.EndLine < start.Line || (b.EndLine == start.Line && b.EndCol <= start.Column) {
+				// before the beginning of the function
+				continue
+			}
+			cf.Total += b.NumStmt
+			if b.Count > 0 {
+				cf.Covered += b.NumStmt
+			}
+		}
+		funcs = append(funcs, cf)
+	}
+
+	return funcs, nil
+}
+
+// recvTypeName returns the type name of the method receiver.
+func recvTypeName(expr ast.Expr) string {
+	switch x := expr.(type) {
+	case *ast.StarExpr:
+		return "*" + recvTypeName(x.X)
+	case *ast.Ident:
+		return x.Name
+	default:
+		return ""
+	}
+}
+
+// coverPercent returns the percentage of the covered statements.
+// Same as the go tool cover, returns 100 if there are no statements.
+func coverPercent(covered, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return 100 * float64(covered) / float64(total)
+}
+
+// renderCoverPackages renders the coverage report lines like the
+// "go tool cover -func" output. Returns the buffer lines and the function of
+// each lines.
+func renderCoverPackages(pkgs []*coverPackage, sortByCoverage bool) ([][]byte, []*coverFunc) {
+	pkgs = append([]*coverPackage(nil), pkgs...)
+	if sortByCoverage {
+		sort.SliceStable(pkgs, func(i, j int) bool {
+			return coverPercent(pkgs[i].Covered, pkgs[i].Total) < coverPercent(pkgs[j].Covered, pkgs[j].Total)
+		})
+	}
+
+	var (
+		buf     bytes.Buffer
+		funcs   []*coverFunc
+		covered int
+		total   int
+	)
+	w := tabwriter.NewWriter(&buf, 0, 8, 1, ' ', 0)
+	for _, pkg := range pkgs {
+		fmt.Fprintf(w, "%s\t\t%.1f%%\t\n", pkg.Path, coverPercent(pkg.Covered, pkg.Total))
+		funcs = append(funcs, nil)
+		covered += pkg.Covered
+		total += pkg.Total
+
+		pkgFuncs := append([]*coverFunc(nil), pkg.Funcs...)
+		if sortByCoverage {
+			sort.SliceStable(pkgFuncs, func(i, j int) bool {
+				return coverPercent(pkgFuncs[i].Covered, pkgFuncs[i].Total) < coverPercent(pkgFuncs[j].Covered, pkgFuncs[j].Total)
+			})
+		}
+		for _, fn := range pkgFuncs {
+			fmt.Fprintf(w, "  %s:%d:\t%s\t%.1f%%\t\n", path.Base(filepath.ToSlash(fn.File)), fn.Line, fn.Name, coverPercent(fn.Covered, fn.Total))
+			funcs = append(funcs, fn)
+		}
+	}
+	fmt.Fprintf(w, "total:\t(statements)\t%.1f%%\t\n", coverPercent(covered, total))
+	funcs = append(funcs, nil)
+	w.Flush()
+
+	var lines [][]byte
+	for _, l := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		lines = append(lines, []byte(strings.TrimRight(l, " ")))
+	}
+
+	return lines, funcs
+}
@@ -0,0 +1,111 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zchee/nvim-go/src/internal/cover"
+)
+
+const coverFuncSrc = `package foo
+
+func Foo(x int) int {
+	if x > 0 {
+		return x
+	}
+	return 0
+}
+
+type T struct{}
+
+func (t *T) Bar() {
+	println("bar")
+}
+`
+
+func TestCoverFuncs(t *testing.T) {
+	prof := &cover.Profile{
+		FileName: "example.com/foo/foo.go",
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 21, EndLine: 4, EndCol: 11, NumStmt: 1, Count: 1},
+			{StartLine: 4, StartCol: 11, EndLine: 6, EndCol: 3, NumStmt: 1, Count: 0},
+			{StartLine: 7, StartCol: 2, EndLine: 7, EndCol: 10, NumStmt: 1, Count: 1},
+			{StartLine: 12, StartCol: 19, EndLine: 14, EndCol: 2, NumStmt: 1, Count: 0},
+		},
+	}
+
+	got, err := coverFuncs("/src/foo/foo.go", coverFuncSrc, prof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*coverFunc{
+		{File: "/src/foo/foo.go", Line: 3, Col: 1, Name: "Foo", Covered: 2, Total: 3},
+		{File: "/src/foo/foo.go", Line: 12, Col: 1, Name: "*T.Bar", Covered: 0, Total: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		for _, fn := range got {
+			t.Logf("%+v", fn)
+		}
+		t.Errorf("coverFuncs() = %v, want %v", got, want)
+	}
+}
+
+func TestRenderCoverPackages(t *testing.T) {
+	foo := &coverFunc{File: "/src/foo/foo.go", Line: 3, Name: "Foo", Covered: 2, Total: 3}
+	bar := &coverFunc{File: "/src/foo/foo.go", Line: 12, Name: "*T.Bar", Covered: 0, Total: 1}
+	pkgs := []*coverPackage{
+		{Path: "example.com/foo", Covered: 2, Total: 4, Funcs: []*coverFunc{foo, bar}},
+		{Path: "example.com/foo/baz", Covered: 0, Total: 0},
+	}
+
+	tests := []struct {
+		name           string
+		sortByCoverage bool
+		want           []string
+		wantFuncs      []*coverFunc
+	}{
+		{
+			name: "source order",
+			want: []string{
+				"example.com/foo                  50.0%",
+				"  foo.go:3:         Foo          66.7%",
+				"  foo.go:12:        *T.Bar       0.0%",
+				"example.com/foo/baz              100.0%",
+				"total:              (statements) 50.0%",
+			},
+			wantFuncs: []*coverFunc{nil, foo, bar, nil, nil},
+		},
+		{
+			name:           "coverage order",
+			sortByCoverage: true,
+			want: []string{
+				"example.com/foo                  50.0%",
+				"  foo.go:12:        *T.Bar       0.0%",
+				"  foo.go:3:         Foo          66.7%",
+				"example.com/foo/baz              100.0%",
+				"total:              (statements) 50.0%",
+			},
+			wantFuncs: []*coverFunc{nil, bar, foo, nil, nil},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			lines, funcs := renderCoverPackages(pkgs, tt.sortByCoverage)
+			var got []string
+			for _, l := range lines {
+				got = append(got, string(l))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("renderCoverPackages() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(funcs, tt.wantFuncs) {
+				t.Errorf("renderCoverPackages() funcs = %v, want %v", funcs, tt.wantFuncs)
+			}
+		})
+	}
+}
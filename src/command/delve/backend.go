@@ -0,0 +1,74 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import (
+	delveapi "github.com/derekparker/delve/service/api"
+	delverpc2 "github.com/derekparker/delve/service/rpc2"
+)
+
+const (
+	// BackendRPC2 is the delve JSON-RPC API v2 backend.
+	BackendRPC2 = "rpc2"
+	// BackendDAP is the debug adapter protocol backend that speaks to "dlv dap".
+	BackendDAP = "dap"
+)
+
+// Backend represents a debugger backend of the debugger UI. The UI speaks the delve API types,
+// and each backend converts its protocol to them.
+type Backend interface {
+	// ProcessPid returns the pid of the debugging process, or 0 if unknown.
+	ProcessPid() int
+	// Detach detaches the debugger, optionally killing the process.
+	Detach(kill bool) error
+	// Restart restarts the process, and returns the breakpoints that could not be re-created.
+	Restart() ([]delveapi.DiscardedBreakpoint, error)
+	// GetState returns the current debugger state.
+	GetState() (*delveapi.DebuggerState, error)
+
+	// Continue resumes the process, and sends the states until stopped.
+	Continue() <-chan *delveapi.DebuggerState
+	// Next steps over to the next source line.
+	Next() (*delveapi.DebuggerState, error)
+	// Step steps into the function call.
+	Step() (*delveapi.DebuggerState, error)
+	// StepOut steps out of the current function.
+	StepOut() (*delveapi.DebuggerState, error)
+	// StepInstruction steps a single cpu instruction.
+	StepInstruction() (*delveapi.DebuggerState, error)
+	// SwitchGoroutine switches the current goroutine.
+	SwitchGoroutine(goroutineID int) (*delveapi.DebuggerState, error)
+	// Halt interrupts the running process.
+	Halt() (*delveapi.DebuggerState, error)
+
+	// CreateBreakpoint creates a new breakpoint.
+	CreateBreakpoint(bp *delveapi.Breakpoint) (*delveapi.Breakpoint, error)
+	// ListBreakpoints returns all breakpoints.
+	ListBreakpoints() ([]*delveapi.Breakpoint, error)
+	// ClearBreakpoint deletes the breakpoint of id.
+	ClearBreakpoint(id int) (*delveapi.Breakpoint, error)
+	// FindLocation returns the locations of the loc location spec.
+	FindLocation(scope delveapi.EvalScope, loc string) ([]delveapi.Location, error)
+
+	// EvalVariable evaluates the expr expression on scope.
+	EvalVariable(scope delveapi.EvalScope, expr string, cfg delveapi.LoadConfig) (*delveapi.Variable, error)
+	// SetVariable sets the value to the symbol variable on scope.
+	SetVariable(scope delveapi.EvalScope, symbol, value string) error
+	// ListLocalVariables returns the local variables of scope.
+	ListLocalVariables(scope delveapi.EvalScope, cfg delveapi.LoadConfig) ([]delveapi.Variable, error)
+	// ListFunctionArgs returns the function arguments of scope.
+	ListFunctionArgs(scope delveapi.EvalScope, cfg delveapi.LoadConfig) ([]delveapi.Variable, error)
+	// ListFunctions returns the functions that match filter.
+	ListFunctions(filter string) ([]string, error)
+	// ListGoroutines returns all goroutines.
+	ListGoroutines() ([]*delveapi.Goroutine, error)
+	// Stacktrace returns the stack frames of the goroutine of goroutineID.
+	Stacktrace(goroutineID, depth int, cfg *delveapi.LoadConfig) ([]delveapi.Stackframe, error)
+}
+
+var (
+	_ Backend = (*delverpc2.RPCClient)(nil)
+	_ Backend = (*dapClient)(nil)
+)
@@ -0,0 +1,526 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	delveapi "github.com/derekparker/delve/service/api"
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/config"
+	"github.com/zchee/nvim-go/src/nvimutil"
+	"github.com/zchee/nvim-go/src/pathutil"
+)
+
+// errNotRunning is the error of the commands that need the debug session.
+var errNotRunning = errors.New("delve is not running. start the debug session with DlvDebug, DlvExec, DlvTest, DlvAttach or DlvConnect")
+
+// ----------------------------------------------------------------------------
+// break(breakpoint)
+
+// breakpointEval represent a breakpoint commands Eval args.
+type breakpointEval struct {
+	File string `msgpack:",array"`
+}
+
+func (d *Delve) cmdBreakpoint(v *nvim.Nvim, args []string, eval *breakpointEval) {
+	go d.breakpoint(v, args, eval)
+}
+
+// parseArgs parses the "DlvBreakpoint" command args.
+// The empty location is the cursor line of the current file, otherwise the
+// location is the delve location spec such as "main.go:10", "pkg.Func" or "(*T).Method".
+func (d *Delve) parseArgs(v *nvim.Nvim, args []string, eval *breakpointEval) (*delveapi.Breakpoint, *breakpointSpec, error) {
+	spec, err := parseBreakpointSpec(args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bpInfo, err := d.findLocation(v, spec.Location, eval)
+	if err != nil {
+		return nil, nil, err
+	}
+	bpInfo.Cond = spec.Cond
+	if spec.Log != "" {
+		bpInfo.Tracepoint = true
+		bpInfo.Variables = logExprs(spec.Log)
+	}
+
+	return bpInfo, spec, nil
+}
+
+// findLocation returns the breakpoint of the loc location spec.
+func (d *Delve) findLocation(v *nvim.Nvim, loc string, eval *breakpointEval) (*delveapi.Breakpoint, error) {
+	// Ref: https://github.com/derekparker/delve/blob/master/Documentation/cli/locspec.md
+	switch loc {
+	case "":
+		cursor, err := v.WindowCursor(d.cw)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return &delveapi.Breakpoint{
+			File: eval.File,
+			Line: cursor[0],
+		}, nil
+	default:
+		locs, err := d.client.FindLocation(delveapi.EvalScope{GoroutineID: -1}, loc)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		switch len(locs) {
+		case 0:
+			return nil, errors.Errorf("location not found: %s", loc)
+		case 1:
+			// the backend that can't resolve the address returns the file and line, or function
+			if locs[0].PC == 0 {
+				bp := &delveapi.Breakpoint{File: locs[0].File, Line: locs[0].Line}
+				if bp.File == "" && locs[0].Function != nil {
+					bp.FunctionName = locs[0].Function.Name
+				}
+				return bp, nil
+			}
+			// use the address because delve treats the Line as the offset from the function entry if FunctionName is set
+			return &delveapi.Breakpoint{
+				Addr: locs[0].PC,
+			}, nil
+		default:
+			return nil, errors.Errorf("ambiguous location %s: %d locations found", loc, len(locs))
+		}
+	}
+}
+
+// breakpoint sets a breakpoint, and sets marker to Nvim sign area.
+// Note that 'break' name is reverved Go language spec.
+func (d *Delve) breakpoint(v *nvim.Nvim, args []string, eval *breakpointEval) error {
+	if d.client == nil {
+		return nvimutil.ErrorWrap(v, errNotRunning)
+	}
+
+	bpInfo, spec, err := d.parseArgs(v, args, eval)
+	if err != nil {
+		return nvimutil.ErrorWrap(v, err)
+	}
+
+	return d.createBreakpoint(v, bpInfo, spec, filepath.Dir(eval.File))
+}
+
+// createBreakpoint creates the bpInfo breakpoint with the client side spec conditions,
+// and places the sign, and prints the result to terminal buffer.
+func (d *Delve) createBreakpoint(v *nvim.Nvim, bpInfo *delveapi.Breakpoint, spec *breakpointSpec, dir string) error {
+	bp, err := d.client.CreateBreakpoint(bpInfo) // *delveapi.Breakpoint
+	if err != nil {
+		return nvimutil.ErrorWrap(v, errors.WithStack(err))
+	}
+	d.setBreakpointSpec(bp.ID, spec)
+	if err := d.placeBreakpointSign(v, bp); err != nil {
+		return nvimutil.ErrorWrap(v, err)
+	}
+
+	kind := "Breakpoint"
+	if bp.Tracepoint {
+		kind = "Logpoint"
+	}
+	filename := pathutil.ShortFilePath(bp.File, dir)
+	msg := fmt.Sprintf("%s %d set at %#v for %s() %s:%d", kind, bp.ID, bp.Addr, bp.FunctionName, filename, bp.Line)
+	if cond := spec.Condition(); cond != "" {
+		msg += " " + cond
+	}
+	if err := d.printTerminal("break "+bp.FunctionName, nvimutil.StrToByteSlice(msg)); err != nil {
+		return nvimutil.ErrorWrap(v, errors.WithStack(err))
+	}
+
+	return d.refreshBreakpoints(v)
+}
+
+// placeBreakpointSign places the breakpoint sign of bp, and saves it to bpSign.
+func (d *Delve) placeBreakpointSign(v *nvim.Nvim, bp *delveapi.Breakpoint) error {
+	if d.bpSign == nil {
+		d.bpSign = make(map[int]*nvimutil.Sign)
+	}
+
+	d.bpMu.Lock()
+	name, text, texthl := breakpointSign(bp, d.bpSpecs[bp.ID])
+	d.bpMu.Unlock()
+	sign, err := nvimutil.NewSign(v, name, text, texthl, "") // *nvim.Sign
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	d.bpSign[bp.ID] = sign
+
+	return sign.Place(v, bp.ID, bp.Line, bp.File, false)
+}
+
+// setBreakpointSpec saves the spec of the id breakpoint. The nil spec removes it.
+func (d *Delve) setBreakpointSpec(id int, spec *breakpointSpec) {
+	d.bpMu.Lock()
+	defer d.bpMu.Unlock()
+
+	if spec == nil || (spec.Hit == nil && spec.Log == "") {
+		delete(d.bpSpecs, id)
+		return
+	}
+	if d.bpSpecs == nil {
+		d.bpSpecs = make(map[int]*breakpointSpec)
+	}
+	d.bpSpecs[id] = spec
+}
+
+// clearBreakpoint clears the bp breakpoint and unplaces the sign.
+func (d *Delve) clearBreakpoint(v *nvim.Nvim, bp *delveapi.Breakpoint) error {
+	if _, err := d.client.ClearBreakpoint(bp.ID); err != nil {
+		return errors.WithStack(err)
+	}
+	d.setBreakpointSpec(bp.ID, nil)
+
+	return d.unplaceBreakpointSign(v, bp)
+}
+
+// unplaceBreakpointSign unplaces the breakpoint sign of bp, and removes it from bpSign.
+func (d *Delve) unplaceBreakpointSign(v *nvim.Nvim, bp *delveapi.Breakpoint) error {
+	sign, ok := d.bpSign[bp.ID]
+	if !ok {
+		return nil
+	}
+	delete(d.bpSign, bp.ID)
+
+	return sign.Unplace(v, bp.ID, bp.File)
+}
+
+// ----------------------------------------------------------------------------
+// toggle breakpoint
+
+// breakpointToggleEval represent a DlvBreakpointToggle command Eval args.
+type breakpointToggleEval struct {
+	File string `msgpack:",array"`
+	Line int
+}
+
+func (d *Delve) cmdBreakpointToggle(v *nvim.Nvim, eval *breakpointToggleEval) {
+	go d.breakpointToggle(v, eval)
+}
+
+// breakpointToggle clears the breakpoint at the cursor line if exists, otherwise sets the breakpoint.
+func (d *Delve) breakpointToggle(v *nvim.Nvim, eval *breakpointToggleEval) error {
+	if d.client == nil {
+		return nvimutil.ErrorWrap(v, errNotRunning)
+	}
+
+	bps, err := d.client.ListBreakpoints()
+	if err != nil {
+		return nvimutil.ErrorWrap(v, errors.WithStack(err))
+	}
+	for _, bp := range bps {
+		if bp.ID <= 0 || bp.File != eval.File || bp.Line != eval.Line {
+			continue
+		}
+		if err := d.clearBreakpoint(v, bp); err != nil {
+			return nvimutil.ErrorWrap(v, err)
+		}
+
+		msg := fmt.Sprintf("Breakpoint %d cleared at %s:%d", bp.ID, filepath.Base(bp.File), bp.Line)
+		if err := d.printTerminal("clear "+fmt.Sprint(bp.ID), nvimutil.StrToByteSlice(msg)); err != nil {
+			return nvimutil.ErrorWrap(v, errors.WithStack(err))
+		}
+		return d.refreshBreakpoints(v)
+	}
+
+	// forget the disabled breakpoint at the same location, the new one supersedes it
+	d.bpMu.Lock()
+	for i, e := range d.disabledBps {
+		if e.File == eval.File && e.Line == eval.Line {
+			d.disabledBps = append(d.disabledBps[:i], d.disabledBps[i+1:]...)
+			break
+		}
+	}
+	d.bpMu.Unlock()
+
+	return d.createBreakpoint(v, &delveapi.Breakpoint{File: eval.File, Line: eval.Line}, nil, filepath.Dir(eval.File))
+}
+
+// ----------------------------------------------------------------------------
+// clear all breakpoints
+
+func (d *Delve) cmdClearAll(v *nvim.Nvim) {
+	go d.clearAll(v)
+}
+
+// clearAll clears the all user breakpoints and the disabled breakpoints.
+func (d *Delve) clearAll(v *nvim.Nvim) error {
+	if d.client == nil {
+		return nvimutil.ErrorWrap(v, errNotRunning)
+	}
+
+	bps, err := d.client.ListBreakpoints()
+	if err != nil {
+		return nvimutil.ErrorWrap(v, errors.WithStack(err))
+	}
+
+	n := 0
+	for _, bp := range bps {
+		// the negative ID is the delve internal breakpoint such as "unrecovered-panic"
+		if bp.ID <= 0 {
+			continue
+		}
+		if err := d.clearBreakpoint(v, bp); err != nil {
+			return nvimutil.ErrorWrap(v, err)
+		}
+		n++
+	}
+
+	d.bpMu.Lock()
+	n += len(d.disabledBps)
+	d.disabledBps = nil
+	d.bpSpecs = nil
+	d.bpMu.Unlock()
+
+	msg := fmt.Sprintf("Cleared %d breakpoints", n)
+	if err := d.printTerminal("clearall", nvimutil.StrToByteSlice(msg)); err != nil {
+		return nvimutil.ErrorWrap(v, errors.WithStack(err))
+	}
+
+	return d.refreshBreakpoints(v)
+}
+
+// ----------------------------------------------------------------------------
+// breakpoints buffer
+
+// breakpointsEval represent a DlvBreakpoints command Eval args.
+type breakpointsEval struct {
+	Cwd string `msgpack:",array"`
+}
+
+// breakpointEntry represents a line of the breakpoints buffer.
+type breakpointEntry struct {
+	*delveapi.Breakpoint
+	Spec     *breakpointSpec
+	Disabled bool
+}
+
+func (d *Delve) cmdBreakpoints(v *nvim.Nvim, eval *breakpointsEval) {
+	go func() {
+		if err := d.openBreakpoints(v, eval.Cwd); err != nil {
+			nvimutil.ErrorWrap(v, err)
+		}
+	}()
+}
+
+// openBreakpoints opens the breakpoints buffer, or refreshes it if already opened.
+func (d *Delve) openBreakpoints(v *nvim.Nvim, cwd string) error {
+	if d.client == nil {
+		return errNotRunning
+	}
+
+	d.bpMu.Lock()
+	d.bpCwd = cwd
+	d.bpMu.Unlock()
+
+	if d.buffers == nil {
+		d.buffers = make(map[nvimutil.BufferName]*nvimutil.Buffer)
+	}
+	if buf, ok := d.buffers[Breakpoints]; !ok || !nvimutil.IsBufferValid(v, buf.Buffer()) {
+		buf = nvimutil.NewBuffer(v)
+		if err := buf.Create(string(Breakpoints), nvimutil.FiletypeDelve, "silent belowright 10 split", d.setBufferOption()); err != nil {
+			return errors.WithStack(err)
+		}
+
+		action := ":<C-u>call rpcnotify(%d, 'DlvBreakpointsAction', '%s', line('.'))<CR>"
+		nnoremap := map[string]string{
+			"<CR>": fmt.Sprintf(action, config.ChannelID, "jump"),
+			"d":    fmt.Sprintf(action, config.ChannelID, "delete"),
+			"x":    fmt.Sprintf(action, config.ChannelID, "disable"),
+			"q":    ":<C-u>quit<CR>",
+		}
+		if err := buf.SetLocalMapping(nvimutil.NoremapNormal, nnoremap); err != nil {
+			return errors.WithStack(err)
+		}
+		d.buffers[Breakpoints] = buf
+	}
+
+	return d.renderBreakpoints(v)
+}
+
+// refreshBreakpoints saves the breakpoints to the store, and re-renders the
+// breakpoints buffer if opened.
+func (d *Delve) refreshBreakpoints(v *nvim.Nvim) error {
+	if err := d.storeBreakpoints(); err != nil {
+		return nvimutil.ErrorWrap(v, err)
+	}
+
+	buf, ok := d.buffers[Breakpoints]
+	if !ok || !nvimutil.IsBufferValid(v, buf.Buffer()) {
+		return nil
+	}
+
+	if err := d.renderBreakpoints(v); err != nil {
+		return nvimutil.ErrorWrap(v, err)
+	}
+	return nil
+}
+
+// renderBreakpoints writes the current breakpoints to the breakpoints buffer.
+func (d *Delve) renderBreakpoints(v *nvim.Nvim) error {
+	bps, err := d.client.ListBreakpoints()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	d.bpMu.Lock()
+	d.bpEntries = breakpointEntries(bps, d.disabledBps, d.bpSpecs)
+	lines := formatBreakpoints(d.bpEntries, d.bpCwd)
+	d.bpMu.Unlock()
+
+	b := d.buffers[Breakpoints].Buffer()
+	defer nvimutil.Modifiable(v, b)()
+
+	return errors.WithStack(v.SetBufferLines(b, 0, -1, true, lines))
+}
+
+// breakpointEntries returns the user breakpoints sorted by ID followed by the
+// disabled breakpoints sorted by location.
+func breakpointEntries(bps []*delveapi.Breakpoint, disabled []*breakpointEntry, specs map[int]*breakpointSpec) []*breakpointEntry {
+	var entries []*breakpointEntry
+	for _, bp := range bps {
+		if bp.ID <= 0 {
+			continue
+		}
+		entries = append(entries, &breakpointEntry{Breakpoint: bp, Spec: specs[bp.ID]})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+
+	disabledEntries := append([]*breakpointEntry(nil), disabled...)
+	sort.Slice(disabledEntries, func(i, j int) bool {
+		a, b := disabledEntries[i], disabledEntries[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+
+	return append(entries, disabledEntries...)
+}
+
+// condition returns the description of the server side and client side conditions.
+func (e *breakpointEntry) condition() string {
+	spec := &breakpointSpec{Cond: e.Cond}
+	if e.Spec != nil {
+		spec.Hit = e.Spec.Hit
+		spec.Log = e.Spec.Log
+	}
+	return spec.Condition()
+}
+
+// formatBreakpoints formats the breakpoints buffer lines. The first line is the header,
+// and the file path is relative from cwd.
+func formatBreakpoints(entries []*breakpointEntry, cwd string) [][]byte {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "  ID\tLocation\tFunction\tCondition\tHits")
+	for _, e := range entries {
+		status, id := "\u25CF", fmt.Sprint(e.ID) // \u25CF: ●
+		if e.Disabled {
+			status, id = "\u25CB", "-" // \u25CB: ○
+		}
+		fmt.Fprintf(w, "%s %s\t%s:%d\t%s\t%s\t%d\n", status, id, pathutil.Rel(cwd, e.File), e.Line, e.FunctionName, e.condition(), e.TotalHitCount)
+	}
+	w.Flush()
+
+	return nvimutil.ToBufferLines(bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}))
+}
+
+// handleBreakpointsAction handles the mappings of the breakpoints buffer.
+// The line is the cursor line of the breakpoints buffer.
+func (d *Delve) handleBreakpointsAction(v *nvim.Nvim, action string, line int) {
+	go func() {
+		if err := d.breakpointsAction(v, action, line); err != nil {
+			nvimutil.ErrorWrap(v, err)
+		}
+	}()
+}
+
+func (d *Delve) breakpointsAction(v *nvim.Nvim, action string, line int) error {
+	d.bpMu.Lock()
+	idx := line - 2 // skip the header line
+	if idx < 0 || idx >= len(d.bpEntries) {
+		d.bpMu.Unlock()
+		return nil
+	}
+	e := d.bpEntries[idx]
+	cwd := d.bpCwd
+	d.bpMu.Unlock()
+
+	switch action {
+	case "jump":
+		if err := v.SetCurrentWindow(d.cw); err != nil {
+			return errors.WithStack(err)
+		}
+		return nvimutil.GotoPos(v, d.cw, token.Position{Filename: e.File, Line: e.Line, Column: 1}, cwd)
+	case "delete":
+		if e.Disabled {
+			d.removeDisabled(e)
+		} else if err := d.clearBreakpoint(v, e.Breakpoint); err != nil {
+			return err
+		}
+	case "disable":
+		if e.Disabled {
+			return d.enableBreakpoint(v, e)
+		}
+		if _, err := d.client.ClearBreakpoint(e.ID); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := d.unplaceBreakpointSign(v, e.Breakpoint); err != nil {
+			return err
+		}
+		d.bpMu.Lock()
+		d.disabledBps = append(d.disabledBps, &breakpointEntry{Breakpoint: e.Breakpoint, Spec: d.bpSpecs[e.ID], Disabled: true})
+		d.bpMu.Unlock()
+		d.setBreakpointSpec(e.ID, nil)
+	default:
+		return errors.Errorf("unknown breakpoints action: %s", action)
+	}
+
+	d.refreshBreakpoints(v)
+	return nil
+}
+
+// enableBreakpoint re-creates the disabled e breakpoint. Note that delve assigns the new ID.
+func (d *Delve) enableBreakpoint(v *nvim.Nvim, e *breakpointEntry) error {
+	req := *e.Breakpoint
+	req.ID = 0
+	req.HitCount = nil
+	req.TotalHitCount = 0
+	created, err := d.client.CreateBreakpoint(&req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	d.removeDisabled(e)
+	d.setBreakpointSpec(created.ID, e.Spec)
+
+	if err := d.placeBreakpointSign(v, created); err != nil {
+		return err
+	}
+
+	d.refreshBreakpoints(v)
+	return nil
+}
+
+// removeDisabled removes e from the disabled breakpoints.
+func (d *Delve) removeDisabled(e *breakpointEntry) {
+	d.bpMu.Lock()
+	defer d.bpMu.Unlock()
+
+	for i, disabled := range d.disabledBps {
+		if disabled == e {
+			d.disabledBps = append(d.disabledBps[:i], d.disabledBps[i+1:]...)
+			return
+		}
+	}
+}
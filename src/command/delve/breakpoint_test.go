@@ -0,0 +1,62 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import (
+	"reflect"
+	"testing"
+
+	delveapi "github.com/derekparker/delve/service/api"
+)
+
+func TestFormatBreakpoints(t *testing.T) {
+	tests := []struct {
+		name     string
+		bps      []*delveapi.Breakpoint
+		disabled []*breakpointEntry
+		specs    map[int]*breakpointSpec
+		want     []string
+	}{
+		{
+			name: "empty",
+			bps:  []*delveapi.Breakpoint{{ID: -1, Name: "unrecovered-panic"}},
+			want: []string{"  ID  Location  Function  Condition  Hits"},
+		},
+		{
+			name: "sorted",
+			bps: []*delveapi.Breakpoint{
+				{ID: 2, File: "/src/foo/foo.go", Line: 20, FunctionName: "foo.Foo", Cond: "i == 1"},
+				{ID: -1, Name: "unrecovered-panic"},
+				{ID: 1, File: "/src/foo/main.go", Line: 10, FunctionName: "main.main", TotalHitCount: 3},
+			},
+			disabled: []*breakpointEntry{
+				{Breakpoint: &delveapi.Breakpoint{ID: 4, File: "/src/foo/main.go", Line: 12, FunctionName: "main.main"}, Spec: &breakpointSpec{Log: "x = {x}"}, Disabled: true},
+				{Breakpoint: &delveapi.Breakpoint{ID: 3, File: "/src/foo/foo.go", Line: 5, FunctionName: "foo.init"}, Disabled: true},
+			},
+			specs: map[int]*breakpointSpec{
+				1: {Hit: &hitCond{Op: ">=", N: 2}},
+			},
+			want: []string{
+				"  ID  Location    Function   Condition    Hits",
+				"● 1   main.go:10  main.main  hits >= 2    3",
+				"● 2   foo.go:20   foo.Foo    if i == 1    0",
+				"○ -   foo.go:5    foo.init                0",
+				"○ -   main.go:12  main.main  log x = {x}  0",
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, line := range formatBreakpoints(breakpointEntries(tt.bps, tt.disabled, tt.specs), "/src/foo") {
+				got = append(got, string(line))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("formatBreakpoints() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -19,6 +19,10 @@ const (
 	Context nvimutil.BufferName = "context"
 	// Threads define threads buffer name.
 	Threads nvimutil.BufferName = "thread"
+	// Breakpoints define breakpoints buffer name.
+	Breakpoints nvimutil.BufferName = "breakpoints"
+	// Output define debuggee output buffer name.
+	Output nvimutil.BufferName = "output"
 )
 
 // openDebugBuffer opens the buffers that prints the debug information.
@@ -59,6 +63,23 @@ func (d *Delve) openDebugBuffer() error {
 		d.buffers[Threads].Create(string(Threads), nvimutil.FiletypeDelve, fmt.Sprintf("silent belowright %d split", (height*1/5)), option)
 		d.Nvim.SetWindowOption(d.buffers[Threads].Window, "winfixheight", true)
 
+		output := nvimutil.NewBuffer(d.Nvim)
+		output.Create(string(Output), nvimutil.FiletypeDelve, fmt.Sprintf("silent belowright %d split", (height*1/5)), option)
+		output.SetLocalMapping(nvimutil.NoremapNormal, map[string]string{
+			"i": fmt.Sprintf(":<C-u>call rpcrequest(%d, 'DlvInput')<CR>", config.ChannelID),
+		})
+		d.buffers[Output] = output
+		d.setOutputBuffer(output)
+
+		action := ":<C-u>call rpcnotify(%d, '%s', '%s', line('.'))<CR>"
+		d.buffers[Context].SetLocalMapping(nvimutil.NoremapNormal, map[string]string{
+			"<CR>": fmt.Sprintf(action, config.ChannelID, "DlvContextAction", "select"),
+			"s":    fmt.Sprintf(action, config.ChannelID, "DlvContextAction", "set"),
+		})
+		d.buffers[Threads].SetLocalMapping(nvimutil.NoremapNormal, map[string]string{
+			"<CR>": fmt.Sprintf(action, config.ChannelID, "DlvThreadsAction", "select"),
+		})
+
 	}()
 
 	d.pcSign, err = nvimutil.NewSign(d.Nvim, "delve_pc", nvimutil.ProgramCounterSymbol, "delvePCSign", "delvePCLine") // *nvim.Sign
@@ -66,6 +87,11 @@ func (d *Delve) openDebugBuffer() error {
 		return errors.WithStack(err)
 	}
 
+	d.frameSign, err = nvimutil.NewSign(d.Nvim, "delve_frame", nvimutil.ProgramCounterSymbolRing, "delveCallStackSign", "delveCallStackLine") // *nvim.Sign
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
 	return batch.Execute()
 }
 
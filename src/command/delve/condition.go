@@ -0,0 +1,260 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	delveapi "github.com/derekparker/delve/service/api"
+	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/nvimutil"
+)
+
+// breakpointSpec represents the parsed "DlvBreakpoint" command args.
+//
+//	DlvBreakpoint [location] [if <expr>] [hits <op> <n>] [log <message>]
+type breakpointSpec struct {
+	Location string // delve location spec, or empty for the cursor line
+	Cond     string
+	Hit      *hitCond
+	Log      string // logpoint message with the "{expr}" placeholders
+}
+
+// hitCond represents a hit count condition of the breakpoint such as "hits >= 5".
+// The vendored delve API has no hit condition, so nvim-go checks it with the
+// total hit count of the stopped breakpoint and continues if not matched.
+type hitCond struct {
+	Op string
+	N  uint64
+}
+
+// hitOps is the supported hit condition operators.
+var hitOps = map[string]func(hits, n uint64) bool{
+	"==": func(hits, n uint64) bool { return hits == n },
+	"!=": func(hits, n uint64) bool { return hits != n },
+	">":  func(hits, n uint64) bool { return hits > n },
+	">=": func(hits, n uint64) bool { return hits >= n },
+	"<":  func(hits, n uint64) bool { return hits < n },
+	"<=": func(hits, n uint64) bool { return hits <= n },
+	"%":  func(hits, n uint64) bool { return hits%n == 0 },
+}
+
+func (h *hitCond) String() string {
+	return fmt.Sprintf("hits %s %d", h.Op, h.N)
+}
+
+// Match reports whether the hits total hit count satisfies the condition.
+func (h *hitCond) Match(hits uint64) bool {
+	return hitOps[h.Op](hits, h.N)
+}
+
+// isSpecKeyword reports whether the s is the keyword of breakpointSpec.
+func isSpecKeyword(s string) bool {
+	return s == "if" || s == "hits" || s == "log"
+}
+
+// parseBreakpointSpec parses the "DlvBreakpoint" command args.
+// Note that the condition expression ends with the next "hits" or "log" keyword,
+// and the log message is the rest of args.
+func parseBreakpointSpec(args []string) (*breakpointSpec, error) {
+	spec := new(breakpointSpec)
+	if len(args) > 0 && !isSpecKeyword(args[0]) {
+		spec.Location = args[0]
+		args = args[1:]
+	}
+
+	for len(args) > 0 {
+		switch args[0] {
+		case "if":
+			i := 1
+			for i < len(args) && args[i] != "hits" && args[i] != "log" {
+				i++
+			}
+			spec.Cond = strings.Join(args[1:i], " ")
+			if spec.Cond == "" {
+				return nil, errors.New("missing the condition expression after 'if'")
+			}
+			args = args[i:]
+		case "hits":
+			if len(args) < 3 {
+				return nil, errors.New("hit condition must be 'hits <op> <n>'")
+			}
+			if _, ok := hitOps[args[1]]; !ok {
+				return nil, errors.Errorf("unknown hit condition operator: %s", args[1])
+			}
+			n, err := strconv.ParseUint(args[2], 10, 64)
+			if err != nil {
+				return nil, errors.Errorf("invalid hit count: %s", args[2])
+			}
+			if args[1] == "%" && n == 0 {
+				return nil, errors.New("hit count of '%' operator must be non-zero")
+			}
+			spec.Hit = &hitCond{Op: args[1], N: n}
+			args = args[3:]
+		case "log":
+			spec.Log = strings.Join(args[1:], " ")
+			if spec.Log == "" {
+				return nil, errors.New("missing the log message after 'log'")
+			}
+			args = nil
+		default:
+			return nil, errors.Errorf("unexpected argument: %s", args[0])
+		}
+	}
+
+	return spec, nil
+}
+
+// Condition returns the description of the conditions, or empty if spec has no conditions.
+func (spec *breakpointSpec) Condition() string {
+	if spec == nil {
+		return ""
+	}
+
+	var conds []string
+	if spec.Cond != "" {
+		conds = append(conds, "if "+spec.Cond)
+	}
+	if spec.Hit != nil {
+		conds = append(conds, spec.Hit.String())
+	}
+	if spec.Log != "" {
+		conds = append(conds, "log "+spec.Log)
+	}
+	return strings.Join(conds, " ")
+}
+
+// logExprs returns the expressions of the "{expr}" placeholders in msg.
+func logExprs(msg string) []string {
+	var exprs []string
+	for {
+		start := strings.IndexByte(msg, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(msg[start:], '}')
+		if end < 0 {
+			break
+		}
+		if expr := strings.TrimSpace(msg[start+1 : start+end]); expr != "" {
+			exprs = append(exprs, expr)
+		}
+		msg = msg[start+end+1:]
+	}
+
+	return exprs
+}
+
+// formatLogpoint replaces the "{expr}" placeholders in msg with the evaluated vars,
+// in the same order as the logExprs.
+func formatLogpoint(msg string, vars []delveapi.Variable) string {
+	var buf bytes.Buffer
+	for {
+		start := strings.IndexByte(msg, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(msg[start:], '}')
+		if end < 0 {
+			break
+		}
+		buf.WriteString(msg[:start])
+		if expr := strings.TrimSpace(msg[start+1 : start+end]); expr != "" {
+			val := "<missing>"
+			if len(vars) > 0 {
+				if vars[0].Unreadable != "" {
+					val = "<" + vars[0].Unreadable + ">"
+				} else {
+					val = vars[0].SinglelineString()
+				}
+				vars = vars[1:]
+			}
+			buf.WriteString(val)
+		}
+		msg = msg[start+end+1:]
+	}
+	buf.WriteString(msg)
+
+	return buf.String()
+}
+
+// breakpointSign returns the sign name, text and highlight of the bp breakpoint kind.
+func breakpointSign(bp *delveapi.Breakpoint, spec *breakpointSpec) (name, text, texthl string) {
+	switch {
+	case bp.Tracepoint:
+		return "delve_log", nvimutil.TracepointSymbol, "delveTracepointSign"
+	case bp.Cond != "" || (spec != nil && spec.Hit != nil):
+		return "delve_bp_cond", nvimutil.ConditionalBreakpointSymbol, "delveConditionalBreakpointSign"
+	default:
+		return "delve_bp", nvimutil.BreakpointSymbol, "delveBreakpointSign"
+	}
+}
+
+// continueStopped continues until stopped at the breakpoint that matches the hit condition,
+// and prints the logpoints message that hit during continue.
+func (d *Delve) continueStopped() *delveapi.DebuggerState {
+	for {
+		// the state channel sends the states until stopped except the tracepoint
+		var state *delveapi.DebuggerState
+		for s := range d.client.Continue() {
+			state = s
+			d.printLogpoints(s)
+		}
+		if state == nil || state.Err != nil || state.Exited || d.hitMatched(state) {
+			return state
+		}
+	}
+}
+
+// hitMatched reports whether the state should stop. It returns false if the all
+// stopped breakpoints have the unmatched hit condition.
+func (d *Delve) hitMatched(state *delveapi.DebuggerState) bool {
+	d.bpMu.Lock()
+	defer d.bpMu.Unlock()
+
+	stopped := false
+	for _, th := range state.Threads {
+		bp := th.Breakpoint
+		if bp == nil {
+			continue
+		}
+		if bp.Tracepoint {
+			continue
+		}
+		spec := d.bpSpecs[bp.ID]
+		if spec == nil || spec.Hit == nil || spec.Hit.Match(bp.TotalHitCount) {
+			return true
+		}
+		stopped = true
+	}
+
+	return !stopped
+}
+
+// printLogpoints prints the message of the logpoints that hit in state to the terminal buffer.
+func (d *Delve) printLogpoints(state *delveapi.DebuggerState) {
+	for _, th := range state.Threads {
+		bp := th.Breakpoint
+		if bp == nil || !bp.Tracepoint {
+			continue
+		}
+
+		d.bpMu.Lock()
+		spec := d.bpSpecs[bp.ID]
+		d.bpMu.Unlock()
+		if spec == nil || spec.Log == "" || (spec.Hit != nil && !spec.Hit.Match(bp.TotalHitCount)) {
+			continue
+		}
+
+		var vars []delveapi.Variable
+		if th.BreakpointInfo != nil {
+			vars = th.BreakpointInfo.Variables
+		}
+		d.printTerminal("", []byte(formatLogpoint(spec.Log, vars)))
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	delveapi "github.com/derekparker/delve/service/api"
+)
+
+func TestParseBreakpointSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    string
+		want    *breakpointSpec
+		wantErr bool
+	}{
+		{
+			name: "cursor",
+			args: "",
+			want: &breakpointSpec{},
+		},
+		{
+			name: "location",
+			args: "main.go:10",
+			want: &breakpointSpec{Location: "main.go:10"},
+		},
+		{
+			name: "condition of cursor",
+			args: "if i == 10 && err != nil",
+			want: &breakpointSpec{Cond: "i == 10 && err != nil"},
+		},
+		{
+			name: "all",
+			args: "main.main if i > 1 hits % 2 log i is {i}",
+			want: &breakpointSpec{Location: "main.main", Cond: "i > 1", Hit: &hitCond{Op: "%", N: 2}, Log: "i is {i}"},
+		},
+		{
+			name:    "empty condition",
+			args:    "main.go:10 if hits > 1",
+			wantErr: true,
+		},
+		{
+			name:    "unknown hit operator",
+			args:    "hits => 1",
+			wantErr: true,
+		},
+		{
+			name:    "zero modulo",
+			args:    "hits % 0",
+			wantErr: true,
+		},
+		{
+			name:    "unexpected",
+			args:    "main.go:10 main.go:11",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseBreakpointSpec(strings.Fields(tt.args))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseBreakpointSpec(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseBreakpointSpec(%q) = %#v, want %#v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHitCondMatch(t *testing.T) {
+	tests := []struct {
+		hit  hitCond
+		hits uint64
+		want bool
+	}{
+		{hit: hitCond{Op: "==", N: 3}, hits: 3, want: true},
+		{hit: hitCond{Op: "!=", N: 3}, hits: 3, want: false},
+		{hit: hitCond{Op: ">", N: 3}, hits: 3, want: false},
+		{hit: hitCond{Op: ">=", N: 3}, hits: 3, want: true},
+		{hit: hitCond{Op: "<", N: 3}, hits: 2, want: true},
+		{hit: hitCond{Op: "<=", N: 3}, hits: 4, want: false},
+		{hit: hitCond{Op: "%", N: 3}, hits: 6, want: true},
+		{hit: hitCond{Op: "%", N: 3}, hits: 7, want: false},
+	}
+	for _, tt := range tests {
+		if got := tt.hit.Match(tt.hits); got != tt.want {
+			t.Errorf("%s: Match(%d) = %v, want %v", tt.hit.String(), tt.hits, got, tt.want)
+		}
+	}
+}
+
+func TestFormatLogpoint(t *testing.T) {
+	tests := []struct {
+		name      string
+		msg       string
+		vars      []delveapi.Variable
+		wantExprs []string
+		want      string
+	}{
+		{
+			name: "plain",
+			msg:  "reached",
+			want: "reached",
+		},
+		{
+			name: "exprs",
+			msg:  "i={i} s={ s.Name }{}",
+			vars: []delveapi.Variable{
+				{Name: "i", Kind: reflect.Int, Value: "10"},
+				{Name: "s.Name", Unreadable: "could not find symbol value for s"},
+			},
+			wantExprs: []string{"i", "s.Name"},
+			want:      "i=10 s=<could not find symbol value for s>",
+		},
+		{
+			name:      "missing value",
+			msg:       "x={x} {unclosed",
+			wantExprs: []string{"x"},
+			want:      "x=<missing> {unclosed",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := logExprs(tt.msg); !reflect.DeepEqual(got, tt.wantExprs) {
+				t.Errorf("logExprs(%q) = %q, want %q", tt.msg, got, tt.wantExprs)
+			}
+			if got := formatLogpoint(tt.msg, tt.vars); got != tt.want {
+				t.Errorf("formatLogpoint(%q) = %q, want %q", tt.msg, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,1104 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	delveapi "github.com/derekparker/delve/service/api"
+	"github.com/pkg/errors"
+)
+
+// dapDialTimeout is the timeout of waiting for the "dlv dap" server.
+const dapDialTimeout = 10 * time.Second
+
+// dapMessage represents a debug adapter protocol message.
+//
+// Ref: https://microsoft.github.io/debug-adapter-protocol/specification
+type dapMessage struct {
+	Seq  int    `json:"seq"`
+	Type string `json:"type"` // "request", "response" or "event"
+
+	// request
+	Command   string      `json:"command,omitempty"`
+	Arguments interface{} `json:"arguments,omitempty"`
+
+	// response
+	RequestSeq int    `json:"request_seq,omitempty"`
+	Success    bool   `json:"success,omitempty"`
+	Message    string `json:"message,omitempty"`
+
+	// event
+	Event string `json:"event,omitempty"`
+
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// writeDAPMessage writes msg with the Content-Length header to w.
+func writeDAPMessage(w io.Writer, msg interface{}) error {
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(buf), buf); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// readDAPMessage reads a message with the Content-Length header from r.
+func readDAPMessage(r *bufio.Reader) (*dapMessage, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	n, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid Content-Length header")
+	}
+
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	msg := new(dapMessage)
+	if err := json.Unmarshal(buf, msg); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return msg, nil
+}
+
+// dapSource, dapBreakpoint, dapStackFrame and dapVariable are the debug adapter protocol types.
+type dapSource struct {
+	Path string `json:"path,omitempty"`
+}
+
+type dapBreakpoint struct {
+	ID       int        `json:"id,omitempty"`
+	Verified bool       `json:"verified"`
+	Message  string     `json:"message,omitempty"`
+	Source   *dapSource `json:"source,omitempty"`
+	Line     int        `json:"line,omitempty"`
+}
+
+type dapStackFrame struct {
+	ID     int        `json:"id"`
+	Name   string     `json:"name"`
+	Source *dapSource `json:"source,omitempty"`
+	Line   int        `json:"line"`
+}
+
+type dapVariable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	Type               string `json:"type"`
+	VariablesReference int    `json:"variablesReference"`
+	NamedVariables     int    `json:"namedVariables,omitempty"`
+	IndexedVariables   int    `json:"indexedVariables,omitempty"`
+}
+
+// dapStopped represents the body of the "stopped", "terminated" and "exited" events.
+type dapStopped struct {
+	event             string
+	Reason            string `json:"reason"`
+	ThreadID          int    `json:"threadId"`
+	HitBreakpointIDs  []int  `json:"hitBreakpointIds"`
+	ExitCode          int    `json:"exitCode"`
+	SystemProcessID   int    `json:"systemProcessId"`
+	Category          string `json:"category"`
+	Output            string `json:"output"`
+	AllThreadsStopped bool   `json:"allThreadsStopped"`
+}
+
+// dapClient is the Backend that speaks the debug adapter protocol to the "dlv dap" server.
+// DAP threads are the goroutines of delve.
+type dapClient struct {
+	conn net.Conn
+	wmu  sync.Mutex // guards conn writes
+
+	mu       sync.Mutex
+	seq      int
+	pending  map[int]chan *dapMessage
+	pid      int
+	selected int // the selected goroutine ID
+	stopped  *dapStopped
+	exitCode int
+	exited   bool
+	bps      []*delveapi.Breakpoint
+	nextID   int
+	bpIDs    map[int]int // map[breakpoint id]the server breakpoint id
+	output   func(category, output string)
+
+	initialized chan struct{}
+	stops       chan *dapStopped
+	closed      chan struct{}
+}
+
+// dialDAP connects to the "dlv dap" server of addr, retrying until the server starts.
+// Note that the server accepts only one client, so don't probe it before.
+func dialDAP(addr string) (*dapClient, error) {
+	deadline := time.Now().Add(dapDialTimeout)
+	for {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			return newDAPClient(conn), nil
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.Wrapf(err, "could not connect to dlv dap server %s", addr)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// newDAPClient returns the dapClient that communicates over conn.
+func newDAPClient(conn net.Conn) *dapClient {
+	c := &dapClient{
+		conn:        conn,
+		pending:     make(map[int]chan *dapMessage),
+		bpIDs:       make(map[int]int),
+		initialized: make(chan struct{}),
+		stops:       make(chan *dapStopped, 16),
+		closed:      make(chan struct{}),
+	}
+	go c.readLoop()
+
+	return c
+}
+
+// readLoop dispatches the responses to the waiting requests, and handles the events.
+func (c *dapClient) readLoop() {
+	defer close(c.closed)
+
+	r := bufio.NewReader(c.conn)
+	for {
+		msg, err := readDAPMessage(r)
+		if err != nil {
+			return
+		}
+
+		switch msg.Type {
+		case "response":
+			c.mu.Lock()
+			ch, ok := c.pending[msg.RequestSeq]
+			delete(c.pending, msg.RequestSeq)
+			c.mu.Unlock()
+			if ok {
+				ch <- msg
+			}
+		case "event":
+			c.handleEvent(msg)
+		}
+	}
+}
+
+func (c *dapClient) handleEvent(msg *dapMessage) {
+	ev := &dapStopped{event: msg.Event}
+	if len(msg.Body) > 0 {
+		json.Unmarshal(msg.Body, ev)
+	}
+
+	switch msg.Event {
+	case "initialized":
+		close(c.initialized)
+	case "process":
+		c.mu.Lock()
+		c.pid = ev.SystemProcessID
+		c.mu.Unlock()
+	case "output":
+		c.mu.Lock()
+		output := c.output
+		c.mu.Unlock()
+		if output != nil {
+			output(ev.Category, ev.Output)
+		}
+	case "exited":
+		c.mu.Lock()
+		c.exitCode = ev.ExitCode
+		c.mu.Unlock()
+	case "stopped":
+		c.mu.Lock()
+		c.stopped = ev
+		c.selected = ev.ThreadID
+		c.mu.Unlock()
+		c.pushStop(ev)
+	case "terminated":
+		c.mu.Lock()
+		c.exited = true
+		c.mu.Unlock()
+		c.pushStop(ev)
+	}
+}
+
+// pushStop sends ev to the stops channel without blocking the readLoop.
+// The oldest stale stop is dropped if nobody waits and the channel is full.
+func (c *dapClient) pushStop(ev *dapStopped) {
+	for {
+		select {
+		case c.stops <- ev:
+			return
+		default:
+		}
+		select {
+		case <-c.stops:
+		default:
+		}
+	}
+}
+
+// onOutput sets the handler of the "output" events, such as the debuggee stdout and stderr.
+func (c *dapClient) onOutput(fn func(category, output string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.output = fn
+}
+
+// call sends the command request with args, and decodes the response body to body if not nil.
+func (c *dapClient) call(command string, args, body interface{}) error {
+	c.mu.Lock()
+	c.seq++
+	seq := c.seq
+	ch := make(chan *dapMessage, 1)
+	c.pending[seq] = ch
+	c.mu.Unlock()
+
+	c.wmu.Lock()
+	err := writeDAPMessage(c.conn, &dapMessage{Seq: seq, Type: "request", Command: command, Arguments: args})
+	c.wmu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	var resp *dapMessage
+	select {
+	case resp = <-ch:
+	case <-c.closed:
+		return errors.Errorf("%s: connection closed", command)
+	}
+	if !resp.Success {
+		return errors.Errorf("%s: %s", command, resp.Message)
+	}
+	if body != nil && len(resp.Body) > 0 {
+		if err := json.Unmarshal(resp.Body, body); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// launch initializes the session, and launches or attaches the debuggee of cmd with cfg.
+// The process stops on entry.
+func (c *dapClient) launch(cmd string, cfg Config) error {
+	initArgs := map[string]interface{}{
+		"clientID":             "nvim-go",
+		"clientName":           "nvim-go",
+		"adapterID":            "go",
+		"pathFormat":           "path",
+		"linesStartAt1":        true,
+		"columnsStartAt1":      true,
+		"supportsVariableType": true,
+	}
+	if err := c.call("initialize", initArgs, nil); err != nil {
+		return err
+	}
+
+	var request string
+	args := make(map[string]interface{})
+	switch cmd {
+	case "attach":
+		request = "attach"
+		args["mode"] = "local"
+		args["processId"] = cfg.pid
+	case "debug", "test", "exec":
+		request = "launch"
+		args["mode"] = cmd
+		args["program"] = cfg.path
+		args["args"] = cfg.args
+		args["stopOnEntry"] = true
+		if cfg.dir != "" {
+			args["cwd"] = cfg.dir
+		}
+		if len(cfg.flags) > 0 {
+			args["buildFlags"] = strings.Join(cfg.flags, " ")
+		}
+	default:
+		return errors.Errorf("not supported dlv %s command with the dap backend", cmd)
+	}
+	args["request"] = request
+	if err := c.call(request, args, nil); err != nil {
+		return err
+	}
+
+	select {
+	case <-c.initialized:
+	case <-c.closed:
+		return errors.New("dlv dap server closed before initialized")
+	}
+
+	return c.call("configurationDone", nil, nil)
+}
+
+// ProcessPid implements Backend.
+func (c *dapClient) ProcessPid() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.pid
+}
+
+// Detach implements Backend.
+func (c *dapClient) Detach(kill bool) error {
+	err := c.call("disconnect", map[string]interface{}{"terminateDebuggee": kill}, nil)
+	c.conn.Close()
+
+	return err
+}
+
+// Restart implements Backend. DAP keeps the breakpoints, so never discards.
+func (c *dapClient) Restart() ([]delveapi.DiscardedBreakpoint, error) {
+	if err := c.call("restart", nil, nil); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.exited = false
+	c.mu.Unlock()
+
+	return nil, nil
+}
+
+// GetState implements Backend.
+func (c *dapClient) GetState() (*delveapi.DebuggerState, error) {
+	c.mu.Lock()
+	ev := c.stopped
+	c.mu.Unlock()
+
+	return c.state(ev)
+}
+
+// state returns the debugger state of ev stopped event.
+func (c *dapClient) state(ev *dapStopped) (*delveapi.DebuggerState, error) {
+	c.mu.Lock()
+	exited, exitCode, selected := c.exited, c.exitCode, c.selected
+	c.mu.Unlock()
+
+	if exited {
+		return &delveapi.DebuggerState{Exited: true, ExitStatus: exitCode}, nil
+	}
+	state := new(delveapi.DebuggerState)
+	if ev == nil {
+		return state, nil
+	}
+
+	th := &delveapi.Thread{ID: ev.ThreadID, GoroutineID: ev.ThreadID}
+	frames, err := c.stackFrames(ev.ThreadID, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(frames) > 0 {
+		loc := frameLocation(frames[0])
+		th.File, th.Line, th.Function = loc.File, loc.Line, loc.Function
+	}
+	if ev.Reason == "breakpoint" {
+		th.Breakpoint = c.hitBreakpoint(ev, th.File, th.Line)
+	}
+
+	state.CurrentThread = th
+	state.Threads = []*delveapi.Thread{th}
+	state.SelectedGoroutine = &delveapi.Goroutine{ID: selected, ThreadID: th.ID}
+	if selected == th.GoroutineID {
+		state.SelectedGoroutine.CurrentLoc = delveapi.Location{File: th.File, Line: th.Line, Function: th.Function}
+	}
+
+	return state, nil
+}
+
+// hitBreakpoint returns the copy of the breakpoint that ev stopped at file:line.
+// The breakpoint is found by the server breakpoint ids of ev, or by file:line if
+// no breakpoint has the ids.
+func (c *dapClient) hitBreakpoint(ev *dapStopped, file string, line int) *delveapi.Breakpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, id := range ev.HitBreakpointIDs {
+		for _, bp := range c.bps {
+			if serverID, ok := c.bpIDs[bp.ID]; ok && serverID == id {
+				copied := *bp
+				return &copied
+			}
+		}
+	}
+	for _, bp := range c.bps {
+		if bp.File == file && bp.Line == line {
+			copied := *bp
+			return &copied
+		}
+	}
+
+	return nil
+}
+
+// countHit increments the hit counts of the breakpoint that ev stopped.
+func (c *dapClient) countHit(ev *dapStopped) {
+	if ev.event != "stopped" || ev.Reason != "breakpoint" {
+		return
+	}
+	frames, err := c.stackFrames(ev.ThreadID, 1)
+	if err != nil || len(frames) == 0 {
+		return
+	}
+	loc := frameLocation(frames[0])
+	hit := c.hitBreakpoint(ev, loc.File, loc.Line)
+	if hit == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, bp := range c.bps {
+		if bp.ID == hit.ID {
+			if bp.HitCount == nil {
+				bp.HitCount = make(map[string]uint64)
+			}
+			bp.HitCount[strconv.Itoa(ev.ThreadID)]++
+			bp.TotalHitCount++
+		}
+	}
+}
+
+// resume sends the command request that resumes the process, and waits until stopped.
+func (c *dapClient) resume(command string, args map[string]interface{}) (*delveapi.DebuggerState, error) {
+	// discards the stale stopped events such as the entry
+	for len(c.stops) > 0 {
+		<-c.stops
+	}
+
+	c.mu.Lock()
+	args["threadId"] = c.selected
+	c.mu.Unlock()
+	if err := c.call(command, args, nil); err != nil {
+		return nil, err
+	}
+
+	var ev *dapStopped
+	select {
+	case ev = <-c.stops:
+	case <-c.closed:
+		return nil, errors.Errorf("%s: connection closed", command)
+	}
+	c.countHit(ev)
+
+	return c.state(ev)
+}
+
+// Continue implements Backend.
+func (c *dapClient) Continue() <-chan *delveapi.DebuggerState {
+	ch := make(chan *delveapi.DebuggerState, 1)
+	go func() {
+		defer close(ch)
+		state, err := c.resume("continue", map[string]interface{}{})
+		if err != nil {
+			state = &delveapi.DebuggerState{Err: err}
+		}
+		ch <- state
+	}()
+
+	return ch
+}
+
+// Next implements Backend.
+func (c *dapClient) Next() (*delveapi.DebuggerState, error) {
+	return c.resume("next", map[string]interface{}{})
+}
+
+// Step implements Backend.
+func (c *dapClient) Step() (*delveapi.DebuggerState, error) {
+	return c.resume("stepIn", map[string]interface{}{})
+}
+
+// StepOut implements Backend.
+func (c *dapClient) StepOut() (*delveapi.DebuggerState, error) {
+	return c.resume("stepOut", map[string]interface{}{})
+}
+
+// StepInstruction implements Backend.
+func (c *dapClient) StepInstruction() (*delveapi.DebuggerState, error) {
+	return c.resume("next", map[string]interface{}{"granularity": "instruction"})
+}
+
+// SwitchGoroutine implements Backend. DAP has no current thread, so the client
+// remembers the selected goroutine for the next requests.
+func (c *dapClient) SwitchGoroutine(goroutineID int) (*delveapi.DebuggerState, error) {
+	c.mu.Lock()
+	c.selected = goroutineID
+	ev := c.stopped
+	c.mu.Unlock()
+
+	return c.state(ev)
+}
+
+// Halt implements Backend. The resuming request receives the stopped state.
+func (c *dapClient) Halt() (*delveapi.DebuggerState, error) {
+	c.mu.Lock()
+	tid := c.selected
+	c.mu.Unlock()
+
+	return nil, c.call("pause", map[string]interface{}{"threadId": tid}, nil)
+}
+
+// ----------------------------------------------------------------------------
+// breakpoints
+
+// setBreakpoints sends the breakpoints of file, or the function breakpoints if file is empty.
+// It updates the breakpoints with the response, and returns the breakpoints that not verified.
+// Must be called with c.mu held.
+func (c *dapClient) setBreakpoints(file string) (map[*delveapi.Breakpoint]string, error) {
+	var bps []*delveapi.Breakpoint
+	var reqs []map[string]interface{}
+	for _, bp := range c.bps {
+		if bp.File != file || (file == "") != (bp.FunctionName != "") {
+			continue
+		}
+		req := make(map[string]interface{})
+		if file == "" {
+			req["name"] = bp.FunctionName
+		} else {
+			req["line"] = bp.Line
+		}
+		if bp.Cond != "" {
+			req["condition"] = bp.Cond
+		}
+		if bp.Tracepoint {
+			// the logpoint message is the placeholders of the variables
+			var msg []string
+			for _, expr := range bp.Variables {
+				msg = append(msg, "{"+expr+"}")
+			}
+			req["logMessage"] = strings.Join(msg, " ")
+		}
+		bps = append(bps, bp)
+		reqs = append(reqs, req)
+	}
+
+	command, args := "setBreakpoints", map[string]interface{}{"source": dapSource{Path: file}, "breakpoints": reqs}
+	if file == "" {
+		command, args = "setFunctionBreakpoints", map[string]interface{}{"breakpoints": reqs}
+	}
+
+	var body struct {
+		Breakpoints []dapBreakpoint `json:"breakpoints"`
+	}
+	c.mu.Unlock()
+	err := c.call(command, args, &body)
+	c.mu.Lock()
+	if err != nil {
+		return nil, err
+	}
+
+	unverified := make(map[*delveapi.Breakpoint]string)
+	for i, bp := range bps {
+		if i >= len(body.Breakpoints) {
+			break
+		}
+		res := body.Breakpoints[i]
+		if !res.Verified {
+			delete(c.bpIDs, bp.ID)
+			unverified[bp] = res.Message
+			continue
+		}
+		// the server numbers the breakpoints by itself
+		if res.ID != 0 {
+			c.bpIDs[bp.ID] = res.ID
+		} else {
+			delete(c.bpIDs, bp.ID)
+		}
+		if res.Line > 0 {
+			bp.Line = res.Line
+		}
+		if res.Source != nil && res.Source.Path != "" {
+			bp.File = res.Source.Path
+		}
+	}
+
+	return unverified, nil
+}
+
+// CreateBreakpoint implements Backend.
+func (c *dapClient) CreateBreakpoint(bpInfo *delveapi.Breakpoint) (*delveapi.Breakpoint, error) {
+	if bpInfo.File == "" && bpInfo.FunctionName == "" {
+		return nil, errors.New("the dap backend needs the file or function of the breakpoint")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	bp := *bpInfo
+	c.nextID++
+	bp.ID = c.nextID
+	bp.HitCount = make(map[string]uint64)
+	c.bps = append(c.bps, &bp)
+
+	unverified, err := c.setBreakpoints(bp.File)
+	if msg, ok := unverified[&bp]; err != nil || ok {
+		c.removeBreakpoint(bp.ID)
+		c.setBreakpoints(bp.File)
+		if err == nil {
+			err = errors.Errorf("could not set breakpoint at %s:%d: %s", bp.File, bp.Line, msg)
+		}
+		return nil, err
+	}
+
+	copied := bp
+	return &copied, nil
+}
+
+// removeBreakpoint removes the breakpoint of id, and returns it.
+// Must be called with c.mu held.
+func (c *dapClient) removeBreakpoint(id int) *delveapi.Breakpoint {
+	for i, bp := range c.bps {
+		if bp.ID == id {
+			c.bps = append(c.bps[:i], c.bps[i+1:]...)
+			delete(c.bpIDs, id)
+			return bp
+		}
+	}
+
+	return nil
+}
+
+// ListBreakpoints implements Backend.
+func (c *dapClient) ListBreakpoints() ([]*delveapi.Breakpoint, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	bps := make([]*delveapi.Breakpoint, len(c.bps))
+	for i, bp := range c.bps {
+		copied := *bp
+		bps[i] = &copied
+	}
+	sort.Slice(bps, func(i, j int) bool { return bps[i].ID < bps[j].ID })
+
+	return bps, nil
+}
+
+// ClearBreakpoint implements Backend.
+func (c *dapClient) ClearBreakpoint(id int) (*delveapi.Breakpoint, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	bp := c.removeBreakpoint(id)
+	if bp == nil {
+		return nil, errors.Errorf("no breakpoint with id %d", id)
+	}
+	if _, err := c.setBreakpoints(bp.File); err != nil {
+		return nil, err
+	}
+
+	return bp, nil
+}
+
+// FindLocation implements Backend. DAP can't resolve the location spec, so it supports
+// only the "<file>:<line>" and the function name location spec.
+func (c *dapClient) FindLocation(scope delveapi.EvalScope, loc string) ([]delveapi.Location, error) {
+	if i := strings.LastIndex(loc, ":"); i > 0 {
+		line, err := strconv.Atoi(loc[i+1:])
+		if err != nil {
+			return nil, errors.Errorf("invalid line of the location: %s", loc)
+		}
+		return []delveapi.Location{{File: loc[:i], Line: line}}, nil
+	}
+
+	return []delveapi.Location{{Function: &delveapi.Function{Name: loc}}}, nil
+}
+
+// ----------------------------------------------------------------------------
+// goroutines and stacktrace
+
+// ListGoroutines implements Backend.
+func (c *dapClient) ListGoroutines() ([]*delveapi.Goroutine, error) {
+	var body struct {
+		Threads []struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		} `json:"threads"`
+	}
+	if err := c.call("threads", nil, &body); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	var stopped int
+	if c.stopped != nil {
+		stopped = c.stopped.ThreadID
+	}
+	c.mu.Unlock()
+
+	goroutines := make([]*delveapi.Goroutine, len(body.Threads))
+	for i, th := range body.Threads {
+		g := &delveapi.Goroutine{ID: th.ID, CurrentLoc: delveapi.Location{Function: &delveapi.Function{Name: th.Name}}}
+		if th.ID == stopped {
+			g.ThreadID = th.ID
+			if frames, err := c.stackFrames(th.ID, 1); err == nil && len(frames) > 0 {
+				g.CurrentLoc = frameLocation(frames[0])
+			}
+		}
+		goroutines[i] = g
+	}
+
+	return goroutines, nil
+}
+
+// stackFrames returns the depth stack frames of the goroutine of goroutineID.
+func (c *dapClient) stackFrames(goroutineID, depth int) ([]dapStackFrame, error) {
+	var body struct {
+		StackFrames []dapStackFrame `json:"stackFrames"`
+	}
+	args := map[string]interface{}{"threadId": goroutineID, "startFrame": 0, "levels": depth}
+	if err := c.call("stackTrace", args, &body); err != nil {
+		return nil, err
+	}
+
+	return body.StackFrames, nil
+}
+
+// frameLocation converts the DAP stack frame to the delve location.
+func frameLocation(f dapStackFrame) delveapi.Location {
+	loc := delveapi.Location{Line: f.Line, Function: &delveapi.Function{Name: f.Name}}
+	if f.Source != nil {
+		loc.File = f.Source.Path
+	}
+	return loc
+}
+
+// Stacktrace implements Backend. The cfg is ignored because DAP loads the variables by scopes.
+func (c *dapClient) Stacktrace(goroutineID, depth int, cfg *delveapi.LoadConfig) ([]delveapi.Stackframe, error) {
+	frames, err := c.stackFrames(goroutineID, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	stacks := make([]delveapi.Stackframe, len(frames))
+	for i, f := range frames {
+		stacks[i].Location = frameLocation(f)
+	}
+
+	return stacks, nil
+}
+
+// frameID returns the DAP frame ID of scope.
+func (c *dapClient) frameID(scope delveapi.EvalScope) (int, error) {
+	gid := scope.GoroutineID
+	if gid <= 0 {
+		c.mu.Lock()
+		gid = c.selected
+		c.mu.Unlock()
+	}
+
+	frames, err := c.stackFrames(gid, scope.Frame+1)
+	if err != nil {
+		return 0, err
+	}
+	if scope.Frame >= len(frames) {
+		return 0, errors.Errorf("frame %d not found in goroutine %d", scope.Frame, gid)
+	}
+
+	return frames[scope.Frame].ID, nil
+}
+
+// ----------------------------------------------------------------------------
+// variables
+
+// basicKinds is the kinds of the Go predeclared types.
+var basicKinds = map[string]reflect.Kind{
+	"bool": reflect.Bool, "string": reflect.String,
+	"int": reflect.Int, "int8": reflect.Int8, "int16": reflect.Int16, "int32": reflect.Int32, "int64": reflect.Int64, "rune": reflect.Int32,
+	"uint": reflect.Uint, "uint8": reflect.Uint8, "uint16": reflect.Uint16, "uint32": reflect.Uint32, "uint64": reflect.Uint64, "byte": reflect.Uint8, "uintptr": reflect.Uintptr,
+	"float32": reflect.Float32, "float64": reflect.Float64,
+}
+
+// typeKind guesses the kind of the typ type. The ref is the DAP variables reference
+// that is non-zero if the variable has the children.
+// The kind that has no pretty printer without the children, such as complex and chan, is
+// reflect.Invalid so that the value is shown as is.
+func typeKind(typ string, ref int) reflect.Kind {
+	if k, ok := basicKinds[typ]; ok {
+		return k
+	}
+	if ref == 0 {
+		return reflect.Invalid
+	}
+
+	switch {
+	case strings.HasPrefix(typ, "[]"):
+		return reflect.Slice
+	case strings.HasPrefix(typ, "["):
+		return reflect.Array
+	case strings.HasPrefix(typ, "map["):
+		return reflect.Map
+	case strings.HasPrefix(typ, "*"):
+		return reflect.Ptr
+	case typ == "error" || strings.HasPrefix(typ, "interface"):
+		return reflect.Interface
+	case strings.HasPrefix(typ, "chan") || strings.HasPrefix(typ, "complex") || strings.HasPrefix(typ, "func"):
+		return reflect.Invalid
+	default:
+		return reflect.Struct
+	}
+}
+
+// variables returns the children variables of ref.
+func (c *dapClient) variables(ref int) ([]dapVariable, error) {
+	var body struct {
+		Variables []dapVariable `json:"variables"`
+	}
+	if err := c.call("variables", map[string]interface{}{"variablesReference": ref}, &body); err != nil {
+		return nil, err
+	}
+
+	return body.Variables, nil
+}
+
+// convertVariable converts the DAP variable to the delve variable, loading the children
+// until the recurse level. Pointers and interfaces load the children regardless of the level,
+// the same as delve.
+func (c *dapClient) convertVariable(dv dapVariable, recurse int, cfg delveapi.LoadConfig) delveapi.Variable {
+	v := delveapi.Variable{
+		Name:  dv.Name,
+		Addr:  1, // DAP has no address, but the nil address is printed as nil
+		Type:  dv.Type,
+		Kind:  typeKind(dv.Type, dv.VariablesReference),
+		Value: dv.Value,
+		Len:   int64(dv.NamedVariables + dv.IndexedVariables),
+	}
+	switch v.Kind {
+	case reflect.String:
+		if s, err := strconv.Unquote(dv.Value); err == nil {
+			v.Value = s
+		}
+		v.Len = int64(len(v.Value))
+		return v
+	case reflect.Invalid:
+		return v
+	}
+	if dv.VariablesReference == 0 {
+		return v
+	}
+	if v.Len == 0 {
+		v.Len = 1
+	}
+
+	transparent := v.Kind == reflect.Ptr || v.Kind == reflect.Interface
+	if recurse < 0 && !transparent {
+		return v
+	}
+	children, err := c.variables(dv.VariablesReference)
+	if err != nil {
+		v.Unreadable = err.Error()
+		return v
+	}
+	if cfg.MaxArrayValues > 0 && len(children) > cfg.MaxArrayValues {
+		children = children[:cfg.MaxArrayValues]
+	}
+
+	next := recurse - 1
+	if transparent {
+		next = recurse
+	}
+	for _, child := range children {
+		cv := c.convertVariable(child, next, cfg)
+		if v.Kind == reflect.Map {
+			v.Children = append(v.Children, mapKeyVariable(child.Name))
+		}
+		v.Children = append(v.Children, cv)
+	}
+	if (v.Kind == reflect.Ptr || v.Kind == reflect.Interface) && len(v.Children) == 0 {
+		v.Kind, v.Children = reflect.Invalid, nil
+	}
+
+	return v
+}
+
+// mapKeyVariable returns the key variable of the DAP map element name.
+func mapKeyVariable(name string) delveapi.Variable {
+	key := delveapi.Variable{Addr: 1, Value: name, Kind: reflect.Invalid}
+	if s, err := strconv.Unquote(name); err == nil {
+		key.Kind, key.Value, key.Len = reflect.String, s, int64(len(s))
+	} else if _, err := strconv.ParseFloat(name, 64); err == nil {
+		key.Kind = reflect.Int
+	}
+
+	return key
+}
+
+// scopeVariables returns the variables of the name scope such as "Locals" and "Arguments".
+func (c *dapClient) scopeVariables(scope delveapi.EvalScope, name string, cfg delveapi.LoadConfig) ([]delveapi.Variable, error) {
+	ref, err := c.scopeReference(scope, name)
+	if err != nil || ref == 0 {
+		return nil, err
+	}
+	dvs, err := c.variables(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := make([]delveapi.Variable, len(dvs))
+	for i, dv := range dvs {
+		vars[i] = c.convertVariable(dv, cfg.MaxVariableRecurse, cfg)
+	}
+
+	return vars, nil
+}
+
+// scopeReference returns the variables reference of the name scope, or 0 if not found.
+func (c *dapClient) scopeReference(scope delveapi.EvalScope, name string) (int, error) {
+	frameID, err := c.frameID(scope)
+	if err != nil {
+		return 0, err
+	}
+	var body struct {
+		Scopes []struct {
+			Name               string `json:"name"`
+			VariablesReference int    `json:"variablesReference"`
+		} `json:"scopes"`
+	}
+	if err := c.call("scopes", map[string]interface{}{"frameId": frameID}, &body); err != nil {
+		return 0, err
+	}
+	for _, s := range body.Scopes {
+		if s.Name == name {
+			return s.VariablesReference, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// ListLocalVariables implements Backend.
+func (c *dapClient) ListLocalVariables(scope delveapi.EvalScope, cfg delveapi.LoadConfig) ([]delveapi.Variable, error) {
+	return c.scopeVariables(scope, "Locals", cfg)
+}
+
+// ListFunctionArgs implements Backend.
+func (c *dapClient) ListFunctionArgs(scope delveapi.EvalScope, cfg delveapi.LoadConfig) ([]delveapi.Variable, error) {
+	return c.scopeVariables(scope, "Arguments", cfg)
+}
+
+// evaluate evaluates expr on scope, and returns the result as the DAP variable.
+func (c *dapClient) evaluate(scope delveapi.EvalScope, expr string) (dapVariable, error) {
+	frameID, err := c.frameID(scope)
+	if err != nil {
+		return dapVariable{}, err
+	}
+	var body struct {
+		Result             string `json:"result"`
+		Type               string `json:"type"`
+		VariablesReference int    `json:"variablesReference"`
+		NamedVariables     int    `json:"namedVariables"`
+		IndexedVariables   int    `json:"indexedVariables"`
+	}
+	args := map[string]interface{}{"expression": expr, "frameId": frameID, "context": "watch"}
+	if err := c.call("evaluate", args, &body); err != nil {
+		return dapVariable{}, err
+	}
+
+	return dapVariable{
+		Name:               expr,
+		Value:              body.Result,
+		Type:               body.Type,
+		VariablesReference: body.VariablesReference,
+		NamedVariables:     body.NamedVariables,
+		IndexedVariables:   body.IndexedVariables,
+	}, nil
+}
+
+// EvalVariable implements Backend.
+func (c *dapClient) EvalVariable(scope delveapi.EvalScope, expr string, cfg delveapi.LoadConfig) (*delveapi.Variable, error) {
+	dv, err := c.evaluate(scope, expr)
+	if err != nil {
+		return nil, err
+	}
+	v := c.convertVariable(dv, cfg.MaxVariableRecurse, cfg)
+
+	return &v, nil
+}
+
+// splitSetExpr splits the expr expression to the container expression and the child name
+// for the DAP setVariable request, such as "a.b[1]" to "a.b" and "[1]".
+// The container is empty if expr is the top level variable.
+func splitSetExpr(expr string) (container, name string) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasSuffix(expr, "]") {
+		depth := 0
+		for i := len(expr) - 1; i >= 0; i-- {
+			switch expr[i] {
+			case ']':
+				depth++
+			case '[':
+				depth--
+				if depth == 0 {
+					return expr[:i], expr[i:]
+				}
+			}
+		}
+	}
+
+	depth := 0
+	for i := len(expr) - 1; i >= 0; i-- {
+		switch expr[i] {
+		case ')', ']':
+			depth++
+		case '(', '[':
+			depth--
+		case '.':
+			if depth == 0 {
+				return expr[:i], expr[i+1:]
+			}
+		}
+	}
+
+	return "", expr
+}
+
+// SetVariable implements Backend. It sets the child of the container variable of symbol.
+func (c *dapClient) SetVariable(scope delveapi.EvalScope, symbol, value string) error {
+	container, name := splitSetExpr(symbol)
+
+	var ref int
+	var err error
+	if container == "" {
+		ref, err = c.scopeReference(scope, "Locals")
+	} else {
+		var dv dapVariable
+		dv, err = c.evaluate(scope, container)
+		ref = dv.VariablesReference
+	}
+	if err != nil {
+		return err
+	}
+	if ref == 0 {
+		return errors.Errorf("could not find the container of %s", symbol)
+	}
+
+	return c.call("setVariable", map[string]interface{}{"variablesReference": ref, "name": name, "value": value}, nil)
+}
+
+// ListFunctions implements Backend. DAP has no functions list, so returns empty.
+func (c *dapClient) ListFunctions(filter string) ([]string, error) {
+	return nil, nil
+}
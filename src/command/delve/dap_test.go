@@ -0,0 +1,274 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"reflect"
+	"testing"
+
+	delveapi "github.com/derekparker/delve/service/api"
+)
+
+// stubEvent is the event that the stub DAP server sends after the response.
+type stubEvent struct {
+	event string
+	body  interface{}
+}
+
+// stubDAPServer starts the stub "dlv dap" server that responds to the requests by handle,
+// and returns the address.
+func stubDAPServer(t *testing.T, handle func(req *dapMessage) (body interface{}, events []stubEvent)) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		seq := 0
+		send := func(msg *dapMessage, body interface{}) {
+			seq++
+			msg.Seq = seq
+			if body != nil {
+				msg.Body, _ = json.Marshal(body)
+			}
+			writeDAPMessage(conn, msg)
+		}
+
+		r := bufio.NewReader(conn)
+		for {
+			req, err := readDAPMessage(r)
+			if err != nil {
+				return
+			}
+			body, events := handle(req)
+			send(&dapMessage{Type: "response", RequestSeq: req.Seq, Command: req.Command, Success: true}, body)
+			for _, ev := range events {
+				send(&dapMessage{Type: "event", Event: ev.event}, ev.body)
+			}
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+// stubProgram handles the requests as the program that stopped at main.go:10 by the breakpoint.
+func stubProgram(req *dapMessage) (interface{}, []stubEvent) {
+	var args map[string]interface{}
+	if req.Arguments != nil {
+		args = req.Arguments.(map[string]interface{})
+	}
+
+	switch req.Command {
+	case "launch":
+		return nil, []stubEvent{
+			{event: "process", body: map[string]interface{}{"systemProcessId": 1234}},
+			{event: "initialized"},
+		}
+	case "configurationDone":
+		return nil, []stubEvent{{event: "stopped", body: map[string]interface{}{"reason": "entry", "threadId": 1}}}
+	case "setBreakpoints":
+		var bps []dapBreakpoint
+		for i, bp := range args["breakpoints"].([]interface{}) {
+			bps = append(bps, dapBreakpoint{ID: i + 1, Verified: true, Line: int(bp.(map[string]interface{})["line"].(float64))})
+		}
+		return map[string]interface{}{"breakpoints": bps}, nil
+	case "continue":
+		return nil, []stubEvent{{event: "stopped", body: map[string]interface{}{"reason": "breakpoint", "threadId": 1}}}
+	case "threads":
+		return map[string]interface{}{"threads": []map[string]interface{}{
+			{"id": 1, "name": "main.main"},
+			{"id": 2, "name": "runtime.gopark"},
+		}}, nil
+	case "stackTrace":
+		return map[string]interface{}{"stackFrames": []dapStackFrame{
+			{ID: 1000, Name: "main.main", Source: &dapSource{Path: "/src/foo/main.go"}, Line: 10},
+		}}, nil
+	case "scopes":
+		return map[string]interface{}{"scopes": []map[string]interface{}{
+			{"name": "Arguments", "variablesReference": 0},
+			{"name": "Locals", "variablesReference": 1},
+		}}, nil
+	case "variables":
+		switch int(args["variablesReference"].(float64)) {
+		case 1:
+			return map[string]interface{}{"variables": []dapVariable{
+				{Name: "s", Value: `"foo"`, Type: "string"},
+				{Name: "p", Value: "<*main.T>(0xc000010000)", Type: "*main.T", VariablesReference: 2},
+			}}, nil
+		case 2:
+			return map[string]interface{}{"variables": []dapVariable{
+				{Name: "", Value: "main.T {A: 1}", Type: "main.T", VariablesReference: 3, NamedVariables: 1},
+			}}, nil
+		case 3:
+			return map[string]interface{}{"variables": []dapVariable{{Name: "A", Value: "1", Type: "int"}}}, nil
+		}
+	case "evaluate":
+		return map[string]interface{}{"result": "1", "type": "int"}, nil
+	}
+
+	return nil, nil
+}
+
+func TestDAPClient(t *testing.T) {
+	addr := stubDAPServer(t, stubProgram)
+
+	c, err := dialDAP(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Detach(true)
+	if err := c.launch("debug", Config{path: "."}); err != nil {
+		t.Fatalf("launch() error = %v", err)
+	}
+	if pid := c.ProcessPid(); pid != 1234 {
+		t.Errorf("ProcessPid() = %d, want 1234", pid)
+	}
+
+	bp, err := c.CreateBreakpoint(&delveapi.Breakpoint{File: "/src/foo/main.go", Line: 10})
+	if err != nil {
+		t.Fatalf("CreateBreakpoint() error = %v", err)
+	}
+
+	// tests the UI continue with the dap backend
+	d := &Delve{client: c}
+	state := d.continueStopped()
+	if state == nil || state.Err != nil {
+		t.Fatalf("continueStopped() = %+v", state)
+	}
+	th := state.CurrentThread
+	if th == nil || th.File != "/src/foo/main.go" || th.Line != 10 || th.GoroutineID != 1 {
+		t.Fatalf("continueStopped() CurrentThread = %+v", th)
+	}
+	if th.Breakpoint == nil || th.Breakpoint.ID != bp.ID || th.Breakpoint.TotalHitCount != 1 {
+		t.Errorf("continueStopped() Breakpoint = %+v, want ID %d hit once", th.Breakpoint, bp.ID)
+	}
+
+	goroutines, err := c.ListGoroutines()
+	if err != nil {
+		t.Fatalf("ListGoroutines() error = %v", err)
+	}
+	if len(goroutines) != 2 || goroutines[0].ThreadID != 1 || goroutines[0].CurrentLoc.Line != 10 || goroutines[1].CurrentLoc.Function.Name != "runtime.gopark" {
+		t.Errorf("ListGoroutines() = %+v", goroutines)
+	}
+
+	locals, err := c.ListLocalVariables(delveapi.EvalScope{GoroutineID: 1}, loadConfig())
+	if err != nil {
+		t.Fatalf("ListLocalVariables() error = %v", err)
+	}
+	var got []string
+	for _, v := range locals {
+		got = append(got, v.Name+" = "+v.SinglelineString())
+	}
+	want := []string{`s = "foo"`, "p = *main.T {A: 1}"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListLocalVariables() = %q, want %q", got, want)
+	}
+
+	v, err := c.EvalVariable(delveapi.EvalScope{GoroutineID: 1}, "p.A", loadConfig())
+	if err != nil {
+		t.Fatalf("EvalVariable() error = %v", err)
+	}
+	if v.Kind != reflect.Int || v.Value != "1" {
+		t.Errorf("EvalVariable() = %+v, want int 1", v)
+	}
+}
+
+func TestDAPClientHitBreakpoint(t *testing.T) {
+	// the server numbers the breakpoints from 100, and stops at the second one
+	addr := stubDAPServer(t, func(req *dapMessage) (interface{}, []stubEvent) {
+		switch req.Command {
+		case "setBreakpoints":
+			var bps []dapBreakpoint
+			for i, bp := range req.Arguments.(map[string]interface{})["breakpoints"].([]interface{}) {
+				bps = append(bps, dapBreakpoint{ID: 100 + i, Verified: true, Line: int(bp.(map[string]interface{})["line"].(float64))})
+			}
+			return map[string]interface{}{"breakpoints": bps}, nil
+		case "continue":
+			return nil, []stubEvent{{event: "stopped", body: map[string]interface{}{"reason": "breakpoint", "threadId": 1, "hitBreakpointIds": []int{101}}}}
+		case "stackTrace":
+			return map[string]interface{}{"stackFrames": []dapStackFrame{
+				{ID: 1000, Name: "main.main", Source: &dapSource{Path: "/src/foo/main.go"}, Line: 20},
+			}}, nil
+		}
+		return stubProgram(req)
+	})
+
+	c, err := dialDAP(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Detach(true)
+	if err := c.launch("debug", Config{path: "."}); err != nil {
+		t.Fatalf("launch() error = %v", err)
+	}
+
+	var bps []*delveapi.Breakpoint
+	for _, line := range []int{10, 20} {
+		bp, err := c.CreateBreakpoint(&delveapi.Breakpoint{File: "/src/foo/main.go", Line: line})
+		if err != nil {
+			t.Fatalf("CreateBreakpoint() error = %v", err)
+		}
+		bps = append(bps, bp)
+	}
+
+	state := <-c.Continue()
+	if state.Err != nil {
+		t.Fatalf("Continue() error = %v", state.Err)
+	}
+	th := state.CurrentThread
+	if th == nil || th.Breakpoint == nil || th.Breakpoint.ID != bps[1].ID || th.Breakpoint.TotalHitCount != 1 {
+		t.Errorf("Continue() CurrentThread = %+v, want the breakpoint %d hit once", th, bps[1].ID)
+	}
+}
+
+func TestSplitSetExpr(t *testing.T) {
+	tests := []struct {
+		expr          string
+		wantContainer string
+		wantName      string
+	}{
+		{expr: "x", wantContainer: "", wantName: "x"},
+		{expr: "a.b", wantContainer: "a", wantName: "b"},
+		{expr: "(*a).b.c", wantContainer: "(*a).b", wantName: "c"},
+		{expr: "a.b[1]", wantContainer: "a.b", wantName: "[1]"},
+		{expr: `m["a.b"]`, wantContainer: "m", wantName: `["a.b"]`},
+		{expr: "s[i.j]", wantContainer: "s", wantName: "[i.j]"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.expr, func(t *testing.T) {
+			container, name := splitSetExpr(tt.expr)
+			if container != tt.wantContainer || name != tt.wantName {
+				t.Errorf("splitSetExpr(%q) = (%q, %q), want (%q, %q)", tt.expr, container, name, tt.wantContainer, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestPushStop(t *testing.T) {
+	c := &dapClient{stops: make(chan *dapStopped, 2)}
+	for i := 1; i <= 3; i++ {
+		c.pushStop(&dapStopped{event: "stopped", ThreadID: i})
+	}
+
+	// the oldest stop is dropped instead of blocking
+	var got []int
+	for len(c.stops) > 0 {
+		got = append(got, (<-c.stops).ThreadID)
+	}
+	if want := []int{2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pushStop() kept %v, want %v", got, want)
+	}
+}
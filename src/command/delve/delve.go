@@ -8,13 +8,15 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"go/build"
+	"go/parser"
+	"go/token"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 
 	delveterm "github.com/derekparker/delve/pkg/terminal"
 	delveapi "github.com/derekparker/delve/service/api"
@@ -22,6 +24,8 @@ import (
 	"github.com/neovim/go-client/nvim"
 	"github.com/pkg/errors"
 	"github.com/zchee/nvim-go/src/buildctx"
+	"github.com/zchee/nvim-go/src/config"
+	"github.com/zchee/nvim-go/src/internal/gotest"
 	"github.com/zchee/nvim-go/src/logger"
 	"github.com/zchee/nvim-go/src/nvimutil"
 	"github.com/zchee/nvim-go/src/pathutil"
@@ -35,16 +39,15 @@ type Delve struct {
 	ctx context.Context
 	log *zap.Logger
 
-	Nvim         *nvim.Nvim
-	buildContext *buildctx.Context
+	Nvim          *nvim.Nvim
+	buildContexts *buildctx.Registry
 
 	server     *exec.Cmd
-	client     *delverpc2.RPCClient
+	client     Backend
 	term       *delveterm.Term
 	debugger   *delveterm.Commands
 	processPid int
-	serverOut  bytes.Buffer
-	serverErr  bytes.Buffer
+	sessionCmd string // the dlv command that started the session such as "debug" or "attach"
 
 	channelID int
 
@@ -52,13 +55,20 @@ type Delve struct {
 
 	BufferContext
 	SignContext
+	BreakpointContext
+	WatchContext
+	FrameContext
+	VariableContext
+	StateContext
+	OutputContext
 }
 
 // BufferContext represents a each debug information buffers.
 type BufferContext struct {
-	cb      nvim.Buffer
-	cw      nvim.Window
-	buffers map[nvimutil.BufferName]*nvimutil.Buffer
+	cb         nvim.Buffer
+	cw         nvim.Window
+	buffers    map[nvimutil.BufferName]*nvimutil.Buffer
+	contextDir string // the directory of the short file path in context buffer
 }
 
 // SignContext represents a breakpoint and program counter sign.
@@ -67,32 +77,93 @@ type SignContext struct {
 	pcSign *nvimutil.Sign
 }
 
+// BreakpointContext represents a breakpoints buffer state.
+type BreakpointContext struct {
+	bpMu        sync.Mutex
+	disabledBps []*breakpointEntry      // cleared on the server, but kept for re-enable
+	bpEntries   []*breakpointEntry      // the breakpoints buffer lines
+	bpCwd       string
+	bpSpecs     map[int]*breakpointSpec // the client side conditions of the breakpoints
+	bpRoot      string                  // the project root directory of the breakpoints store
+	savedSigns  map[string][]int        // the placed sign IDs of the stored breakpoints before debugging
+}
+
+// WatchContext represents the watch expressions.
+type WatchContext struct {
+	watchMu sync.Mutex
+	watches []string
+}
+
+// FrameContext represents the selected goroutine and stack frame.
+type FrameContext struct {
+	frameMu      sync.Mutex
+	goroutineID  int                  // the selected goroutine ID
+	frame        int                  // the selected stack frame index of goroutineID
+	stoppedID    int                  // the goroutine ID of the stopped thread
+	contextLines map[int]*contextLine // map[context buffer line]*contextLine
+	threadIDs    []int                // the goroutine IDs of the thread buffer lines
+	frameSign    *nvimutil.Sign
+}
+
+// VariableContext represents the locals tree of the context buffer.
+type VariableContext struct {
+	varMu    sync.Mutex
+	expanded map[string]bool  // the expanded node paths
+	varLines map[int]*varNode // map[context buffer line]*varNode
+}
+
+// StateContext represents the debugging process state.
+type StateContext struct {
+	stateMu  sync.Mutex
+	runState debugState
+}
+
 // NewDelve represents a delve client interface.
-func NewDelve(ctx context.Context, n *nvim.Nvim, buildContext *buildctx.Context) *Delve {
+func NewDelve(ctx context.Context, n *nvim.Nvim, buildContexts *buildctx.Registry) *Delve {
 	return &Delve{
-		ctx:          ctx,
-		log:          logger.FromContext(ctx).Named("delve"),
-		Nvim:         n,
-		buildContext: buildContext,
+		ctx:           ctx,
+		log:           logger.FromContext(ctx).Named("delve"),
+		Nvim:          n,
+		buildContexts: buildContexts,
 	}
 }
 
 // init setup the delve client. Separate the NewDelveClient() function.
 // caused by neovim-go can't call the rpc2.NewClient?
-func (d *Delve) init(v *nvim.Nvim, addr string) error {
+func (d *Delve) init(v *nvim.Nvim, cmd string, cfg Config) error {
+	addr := cfg.addr
 	if !strings.Contains(addr, ":") {
 		addr = "localhost:" + addr
 	}
-	d.client = delverpc2.NewClient(addr)           // *rpc2.RPCClient
-	d.term = delveterm.New(d.client, nil)          // *terminal.Term
-	d.debugger = delveterm.DebugCommands(d.client) // *terminal.Commands
-	d.processPid = d.client.ProcessPid()           // int
-	if d.processPid == 0 {
-		return errors.New("Cannot setup delve server")
+
+	switch config.DelveBackend {
+	case BackendDAP:
+		client, err := dialDAP(addr)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		client.onOutput(func(category, output string) {
+			if category != "telemetry" {
+				d.writeOutput([]byte(output))
+			}
+		})
+		if err := client.launch(cmd, cfg); err != nil {
+			client.Detach(killOnDetach(cmd))
+			return errors.WithStack(err)
+		}
+		d.client = client
+		d.term, d.debugger = nil, nil // the dap backend has no delve terminal
+		d.processPid = client.ProcessPid()
+	default:
+		client := delverpc2.NewClient(addr) // *rpc2.RPCClient
+		d.client = client
+		d.term = delveterm.New(client, nil)          // *terminal.Term
+		d.debugger = delveterm.DebugCommands(client) // *terminal.Commands
+		d.processPid = client.ProcessPid()           // int
+		if d.processPid == 0 {
+			return errors.New("Cannot setup delve server")
+		}
 	}
-	// avoid setup logs by assigning after server starts up
-	d.server.Stdout = &d.serverOut
-	d.server.Stderr = &d.serverErr
 
 	return nil
 }
@@ -102,45 +173,71 @@ func (d *Delve) init(v *nvim.Nvim, addr string) error {
 
 // delveEval represent a setup delve server commands Eval args.
 type delveEval struct {
-	Cwd string `msgpack:",array"`
-	Dir string
+	Cwd   string `msgpack:",array"`
+	Dir   string
+	BufNr int
 }
 
-func (d *Delve) waitServer(addr string) error {
-	d.dialServer(d.Nvim, defaultAddr)
+func (d *Delve) waitServer(cmd string, cfg Config) error {
+	// "dlv dap" server accepts only one client, so init dials it
+	if config.DelveBackend != BackendDAP {
+		d.dialServer(d.Nvim, defaultAddr)
+	}
 
-	if err := d.init(d.Nvim, addr); err != nil {
+	if err := d.init(d.Nvim, cmd, cfg); err != nil {
 		return errors.WithStack(err)
 	}
+	d.setState(d.Nvim, stateStopped)
 
 	// TODO(zchee): check whether the exists terminal buffer created by d.createDebugBuffer()
-	return d.printTerminal("", []byte("Type 'help' for list of commands."))
+	if err := d.printTerminal("", []byte("Type 'help' for list of commands.")); err != nil {
+		return err
+	}
+
+	return d.restoreBreakpoints(d.Nvim)
 }
 
 // start starts the dlv debugging.
 func (d *Delve) start(cmd string, cfg Config, eval *delveEval) error {
+	d.bpRoot = pathutil.FindVCSRoot(eval.Dir)
+	d.sessionCmd = cmd
 	if err := d.startServer(cmd, cfg); err != nil {
 		return errors.WithStack(err)
 	}
-	defer d.waitServer(cfg.addr)
 
-	return d.openDebugBuffer()
+	bufErr := d.openDebugBuffer()
+	if err := d.waitServer(cmd, cfg); err != nil {
+		d.kill() // the session could not start
+		return err
+	}
+
+	return bufErr
 }
 
 // ----------------------------------------------------------------------------
 // attach
 
-// cmdAttach setup the debugging.
+// cmdAttach attaches to the running process and begin debugging.
 func (d *Delve) cmdAttach(v *nvim.Nvim, args []string, eval *delveEval) {
-	pid, err := strconv.ParseInt(args[0], 10, 64)
+	pid, err := strconv.Atoi(args[0])
 	if err != nil {
-		nvimutil.ErrorWrap(v, err)
+		nvimutil.ErrorWrap(v, errors.WithStack(err))
+		return
 	}
 	cfg := Config{
-		pid:   int(pid),
+		pid:   pid,
+		addr:  defaultAddr,
+		dir:   eval.Cwd,
 		flags: args[1:],
 	}
-	go d.start("attach", cfg, eval)
+	go d.startWrap(v, "attach", cfg, eval)
+}
+
+// startWrap starts the dlv debugging and shows the error if failed.
+func (d *Delve) startWrap(v *nvim.Nvim, cmd string, cfg Config, eval *delveEval) {
+	if err := d.start(cmd, cfg, eval); err != nil {
+		nvimutil.ErrorWrap(v, err)
+	}
 }
 
 // ----------------------------------------------------------------------------
@@ -157,105 +254,96 @@ func (d *Delve) cmdConnect(v *nvim.Nvim, args []string, eval *delveEval) {
 		addr:  addr,
 		flags: args[1:],
 	}
-	go d.start("connect", cfg, eval)
+	go d.startWrap(v, "connect", cfg, eval)
 }
 
 // ----------------------------------------------------------------------------
 // debug
 
-func (d *Delve) findRootDir(dir string) string {
+func (d *Delve) findRootDir(bctx *buildctx.Context, dir string) string {
 	rootDir := pathutil.FindVCSRoot(dir)
-	srcPath := filepath.Join(build.Default.GOPATH, "src") + string(filepath.Separator)
+	srcPath := filepath.Join(bctx.BuildContext.GOPATH, "src") + string(filepath.Separator)
 	return filepath.Clean(strings.TrimPrefix(rootDir, srcPath))
 }
 
 // cmdDebug setup the debugging.
 // TODO(zchee): If failed debug(build), even create each buffers.
 func (d *Delve) cmdDebug(v *nvim.Nvim, args []string, eval *delveEval) {
+	bctx := d.buildContexts.Context(eval.BufNr, eval.Dir)
 	cfg := Config{
-		path:  d.findRootDir(eval.Dir),
+		path:  d.findRootDir(bctx, eval.Dir),
 		addr:  defaultAddr,
 		flags: args,
 	}
-	go d.start("debug", cfg, eval)
+	go d.startWrap(v, "debug", cfg, eval)
 }
 
 // ----------------------------------------------------------------------------
-// break(breakpoint)
-
-// breakpointEval represent a breakpoint commands Eval args.
-type breakpointEval struct {
-	File string `msgpack:",array"`
+// exec
+
+// cmdExec executes the precompiled binary and begin debugging.
+// The rest of args are passed to the binary.
+func (d *Delve) cmdExec(v *nvim.Nvim, args []string, eval *delveEval) {
+	binary := args[0]
+	if !filepath.IsAbs(binary) {
+		binary = filepath.Join(eval.Cwd, binary)
+	}
+	cfg := Config{
+		path: binary,
+		addr: defaultAddr,
+		dir:  eval.Cwd,
+		args: args[1:],
+	}
+	go d.startWrap(v, "exec", cfg, eval)
 }
 
-func (d *Delve) cmdBreakpoint(v *nvim.Nvim, args []string, eval *breakpointEval) {
-	go d.breakpoint(v, args, eval)
+// ----------------------------------------------------------------------------
+// test
+
+// delveTestEval represent a DlvTest command Eval args.
+type delveTestEval struct {
+	Cwd    string `msgpack:",array"`
+	Dir    string
+	File   string
+	Offset int
+	BufNr  int
 }
 
-// parseArgs parses the "DlvBreak" command args.
-func (d *Delve) parseArgs(v *nvim.Nvim, args []string, eval *breakpointEval) (*delveapi.Breakpoint, error) {
-	var bpInfo *delveapi.Breakpoint
-
-	// Ref: https://github.com/derekparker/delve/blob/master/Documentation/cli/locspec.md
-	switch len(args) {
-	case 0:
-		cursor, err := v.WindowCursor(d.cw)
-		if err != nil {
-			return nil, errors.WithStack(err)
-		}
-
-		bpInfo = &delveapi.Breakpoint{
-			File: eval.File,
-			Line: cursor[0],
-		}
-	case 1:
-		// FIXME(zchee): more elegant way
-		splitargs := strings.Split(args[0], ".")
-		splitargs[1] = fmt.Sprintf("%s%s", strings.ToUpper(splitargs[1][:1]), splitargs[1][1:])
-		name := strings.Join(splitargs, "")
-
-		bpInfo = &delveapi.Breakpoint{
-			Name:         name,
-			FunctionName: args[0],
-		}
-	// TODO(zchee): Now support function only.
-	default:
-		return nil, errors.New("Too many arguments")
+// cmdTest compiles the test binary of the current package and begin debugging.
+// args are the go test flags such as "-run". Debugs the test function or
+// subtest under the cursor if args is empty.
+func (d *Delve) cmdTest(v *nvim.Nvim, args []string, eval *delveTestEval) {
+	if len(args) == 0 {
+		args = d.cursorTestArgs(v, eval)
 	}
-
-	return bpInfo, nil
+	cfg := Config{
+		path: ".",
+		addr: defaultAddr,
+		dir:  eval.Dir,
+		args: testBinaryArgs(args),
+	}
+	go d.startWrap(v, "test", cfg, &delveEval{Cwd: eval.Cwd, Dir: eval.Dir, BufNr: eval.BufNr})
 }
 
-// breakpoint sets a breakpoint, and sets marker to Nvim sign area.
-// Note that 'break' name is reverved Go language spec.
-func (d *Delve) breakpoint(v *nvim.Nvim, args []string, eval *breakpointEval) error {
-	bpInfo, err := d.parseArgs(v, args, eval)
+// cursorTestArgs returns the go test flags that runs the test under the
+// cursor, or nil if the cursor is not in the test function.
+func (d *Delve) cursorTestArgs(v *nvim.Nvim, eval *delveTestEval) []string {
+	buf, err := v.BufferLines(nvim.Buffer(eval.BufNr), 0, -1, true)
 	if err != nil {
-		nvimutil.ErrorWrap(v, errors.WithStack(err))
-	}
-
-	if d.bpSign == nil {
-		d.bpSign = make(map[int]*nvimutil.Sign)
+		return nil
 	}
 
-	bp, err := d.client.CreateBreakpoint(bpInfo) // *delveapi.Breakpoint
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, eval.File, nvimutil.ToByteSlice(buf), 0)
 	if err != nil {
-		return nvimutil.ErrorWrap(v, errors.WithStack(err))
+		return nil
 	}
-
-	d.bpSign[bp.ID], err = nvimutil.NewSign(v, "delve_bp", nvimutil.BreakpointSymbol, "delveBreakpointSign", "") // *nvim.Sign
+	args, err := gotest.FuncArgs(f, fset.File(f.Pos()).Pos(eval.Offset))
 	if err != nil {
-		return nvimutil.ErrorWrap(v, errors.WithStack(err))
-	}
-	d.bpSign[bp.ID].Place(v, bp.ID, bp.Line, bp.File, false)
-
-	filename := pathutil.ShortFilePath(bp.File, filepath.Dir(eval.File))
-	msg := fmt.Sprintf("Breakpoint %d set at %#v for %s() %s:%d", bp.ID, bp.Addr, bp.FunctionName, filename, bp.Line)
-	if err := d.printTerminal("break "+bp.FunctionName, nvimutil.StrToByteSlice(msg)); err != nil {
-		return nvimutil.ErrorWrap(v, errors.WithStack(err))
+		return nil
 	}
 
-	return nil
+	return args
 }
 
 // ----------------------------------------------------------------------------
@@ -274,16 +362,40 @@ func (d *Delve) cmdContinue(v *nvim.Nvim, args []string, eval *continueEval) {
 // sign marker to current stopping position.
 // Note that 'continue' name is reverved Go language spec.
 func (d *Delve) cont(v *nvim.Nvim, args []string, eval *continueEval) error {
-	stateCh := d.client.Continue()
-	state := <-stateCh
-	if err := d.printServerStderr(); err != nil {
+	if err := d.beginRun(v); err != nil {
+		return nvimutil.ErrorWrap(v, err)
+	}
+	state := d.continueStopped()
+
+	return d.stopped(v, "continue", eval.Dir, state, nil)
+}
+
+// stopped updates the context buffer, pc sign marker and cursor to the
+// stopped position of state, and prints the stopped message to the terminal buffer.
+// It also transitions the state to stopped, or exited if the process has exited.
+// err is the error of the command that returns state.
+func (d *Delve) stopped(v *nvim.Nvim, cmd, dir string, state *delveapi.DebuggerState, err error) error {
+	if state != nil && state.Exited {
+		return d.exited(v, cmd, state)
+	}
+	d.setState(v, stateStopped)
+
+	if err != nil {
 		return nvimutil.ErrorWrap(v, errors.WithStack(err))
 	}
-	if state == nil || state.Exited {
+	switch {
+	case state == nil:
+		return nvimutil.ErrorWrap(v, errors.New("not found the debugger state"))
+	case state.Err != nil:
 		return nvimutil.ErrorWrap(v, errors.WithStack(state.Err))
 	}
 
 	cThread := state.CurrentThread
+	if cThread == nil {
+		return d.printTerminal(cmd, nil)
+	}
+	d.contextDir = dir
+	d.resetFrame(v, cThread.GoroutineID)
 
 	go func() {
 		goroutines, err := d.client.ListGoroutines()
@@ -291,7 +403,7 @@ func (d *Delve) cont(v *nvim.Nvim, args []string, eval *continueEval) error {
 			nvimutil.ErrorWrap(v, errors.WithStack(err))
 			return
 		}
-		d.printContext(eval.Dir, cThread, goroutines)
+		d.printContext(dir, goroutines)
 	}()
 
 	go d.pcSign.Place(v, cThread.ID, cThread.Line, cThread.File, true)
@@ -307,86 +419,125 @@ func (d *Delve) cont(v *nvim.Nvim, args []string, eval *continueEval) error {
 		}
 	}()
 
-	var msg []byte
-	if hitCount, ok := cThread.Breakpoint.HitCount[strconv.Itoa(cThread.GoroutineID)]; ok {
-		msg = []byte(
-			fmt.Sprintf("> %s() %s:%d (hits goroutine(%d):%d total:%d) (PC: %#v)",
-				cThread.Function.Name,
-				pathutil.ShortFilePath(cThread.File, eval.Dir),
-				cThread.Line,
-				cThread.GoroutineID,
-				hitCount,
-				cThread.Breakpoint.TotalHitCount,
-				cThread.PC))
-	} else {
-		msg = []byte(
-			fmt.Sprintf("> %s() %s:%d (hits total:%d) (PC: %#v)",
-				cThread.Function.Name,
-				pathutil.ShortFilePath(cThread.File, eval.Dir),
-				cThread.Line,
-				cThread.Breakpoint.TotalHitCount,
-				cThread.PC))
-	}
-	return d.printTerminal("continue", msg)
+	return d.printTerminal(cmd, stoppedMessage(cThread, dir))
+}
+
+// stoppedMessage returns the message of the stopped thread position.
+func stoppedMessage(cThread *delveapi.Thread, dir string) []byte {
+	var funcName string
+	if cThread.Function != nil {
+		funcName = cThread.Function.Name
+	}
+	file := pathutil.ShortFilePath(cThread.File, dir)
+
+	bp := cThread.Breakpoint
+	if bp == nil {
+		return []byte(fmt.Sprintf("> %s() %s:%d goroutine(%d) (PC: %#v)", funcName, file, cThread.Line, cThread.GoroutineID, cThread.PC))
+	}
+	if hitCount, ok := bp.HitCount[strconv.Itoa(cThread.GoroutineID)]; ok {
+		return []byte(fmt.Sprintf("> %s() %s:%d (hits goroutine(%d):%d total:%d) (PC: %#v)", funcName, file, cThread.Line, cThread.GoroutineID, hitCount, bp.TotalHitCount, cThread.PC))
+	}
+	return []byte(fmt.Sprintf("> %s() %s:%d (hits total:%d) (PC: %#v)", funcName, file, cThread.Line, bp.TotalHitCount, cThread.PC))
 }
 
 // ----------------------------------------------------------------------------
-// next
+// next, step, stepout and step-instruction
 
-// breakpointEval represent a breakpoint commands Eval args.
+// nextEval represent a stepping commands Eval args.
 type nextEval struct {
 	Dir string `msgpack:",array"`
 }
 
 func (d *Delve) cmdNext(v *nvim.Nvim, eval *nextEval) {
-	go d.next(v, eval)
+	go d.step(v, "next", eval)
 }
 
-// next sends the 'next' signals to the delve headless server, and update sign
-// marker to current stopping position.
-func (d *Delve) next(v *nvim.Nvim, eval *nextEval) error {
-	state, err := d.client.Next()
-	// prints server stderr before the prints the error messages
-	if err := d.printServerStderr(); err != nil {
-		return nvimutil.ErrorWrap(v, errors.WithStack(err))
+func (d *Delve) cmdStep(v *nvim.Nvim, eval *nextEval) {
+	go d.step(v, "step", eval)
+}
+
+func (d *Delve) cmdStepOut(v *nvim.Nvim, eval *nextEval) {
+	go d.step(v, "stepout", eval)
+}
+
+func (d *Delve) cmdStepInstruction(v *nvim.Nvim, eval *nextEval) {
+	go d.step(v, "step-instruction", eval)
+}
+
+// step sends the cmd stepping signals to the delve headless server, and update
+// sign marker to current stopping position.
+func (d *Delve) step(v *nvim.Nvim, cmd string, eval *nextEval) error {
+	if err := d.beginRun(v); err != nil {
+		return nvimutil.ErrorWrap(v, err)
 	}
-	// handle the d.client.Next() error
-	if err != nil {
-		return nvimutil.ErrorWrap(v, errors.WithStack(err))
+
+	var (
+		state *delveapi.DebuggerState
+		err   error
+	)
+	switch cmd {
+	case "next":
+		state, err = d.client.Next()
+	case "step":
+		state, err = d.client.Step()
+	case "stepout":
+		state, err = d.client.StepOut()
+	case "step-instruction":
+		state, err = d.client.StepInstruction()
+	default:
+		d.setState(v, stateStopped)
+		return nvimutil.ErrorWrap(v, errors.Errorf("unknown stepping command: %s", cmd))
 	}
 
-	cThread := state.CurrentThread
+	return d.stopped(v, cmd, eval.Dir, state, err)
+}
 
-	go func() {
-		goroutines, err := d.client.ListGoroutines()
-		if err != nil {
-			nvimutil.ErrorWrap(v, errors.WithStack(err))
-			return
-		}
-		d.printContext(eval.Dir, cThread, goroutines)
-	}()
+// ----------------------------------------------------------------------------
+// run to cursor
 
-	go d.pcSign.Place(v, cThread.ID, cThread.Line, cThread.File, true)
+// runToCursorEval represent a DlvRunToCursor command Eval args.
+type runToCursorEval struct {
+	Dir  string `msgpack:",array"`
+	File string
+	Line int
+}
 
-	go func() {
-		if err := v.SetWindowCursor(d.cw, [2]int{cThread.Line, 0}); err != nil {
-			nvimutil.ErrorWrap(v, errors.WithStack(err))
-			return
+func (d *Delve) cmdRunToCursor(v *nvim.Nvim, eval *runToCursorEval) {
+	go d.runToCursor(v, eval)
+}
+
+// runToCursor sets the temporary breakpoint at the cursor position and
+// continues until stopped, then clears the temporary breakpoint.
+func (d *Delve) runToCursor(v *nvim.Nvim, eval *runToCursorEval) error {
+	if err := d.beginRun(v); err != nil {
+		return nvimutil.ErrorWrap(v, err)
+	}
+
+	bps, err := d.client.ListBreakpoints()
+	if err != nil {
+		d.setState(v, stateStopped)
+		return nvimutil.ErrorWrap(v, errors.WithStack(err))
+	}
+	exists := false
+	for _, bp := range bps {
+		if bp.File == eval.File && bp.Line == eval.Line {
+			exists = true
+			break
 		}
-		if err := v.Command("silent normal zz"); err != nil {
-			nvimutil.ErrorWrap(v, errors.WithStack(err))
-			return
+	}
+
+	if !exists {
+		bp, err := d.client.CreateBreakpoint(&delveapi.Breakpoint{File: eval.File, Line: eval.Line})
+		if err != nil {
+			d.setState(v, stateStopped)
+			return nvimutil.ErrorWrap(v, errors.WithStack(err))
 		}
-	}()
+		defer d.client.ClearBreakpoint(bp.ID)
+	}
 
-	msg := []byte(
-		fmt.Sprintf("> %s() %s:%d goroutine(%d) (PC: %d)",
-			cThread.Function.Name,
-			pathutil.ShortFilePath(cThread.File, eval.Dir),
-			cThread.Line,
-			cThread.GoroutineID,
-			cThread.PC))
-	return d.printTerminal("next", msg)
+	state := d.continueStopped()
+
+	return d.stopped(v, "continue", eval.Dir, state, nil)
 }
 
 // ----------------------------------------------------------------------------
@@ -397,21 +548,41 @@ func (d *Delve) cmdRestart(v *nvim.Nvim) {
 }
 
 func (d *Delve) restart(v *nvim.Nvim) error {
+	switch d.debugState() {
+	case stateIdle:
+		return nvimutil.ErrorWrap(v, errNotRunning)
+	case stateRunning:
+		return nvimutil.ErrorWrap(v, errRunning)
+	}
+
 	discarded, err := d.client.Restart()
 	if err != nil {
 		return nvimutil.ErrorWrap(v, errors.WithStack(err))
 	}
+	d.unplaceStoppedSigns(v)
+	d.setState(v, stateStopped)
 
 	var buf bytes.Buffer
 
 	d.processPid = d.client.ProcessPid()
 	buf.WriteString(fmt.Sprintf("Process restarted with PID %d\n", d.processPid))
 
-	for i := range discarded {
-		buf.WriteString(fmt.Sprintf("Discarded %s at %s: %v\n", discarded[i].Breakpoint, discarded[i].Breakpoint, discarded[i].Reason))
+	for _, discard := range discarded {
+		bp := discard.Breakpoint
+		buf.WriteString(fmt.Sprintf("Discarded breakpoint %d at %s:%d: %v\n", bp.ID, bp.File, bp.Line, discard.Reason))
+		d.setBreakpointSpec(bp.ID, nil)
+		d.unplaceBreakpointSign(v, bp)
 	}
 
-	return d.printTerminal("restart", buf.Bytes())
+	if err := d.printTerminal("restart", buf.Bytes()); err != nil {
+		return nvimutil.ErrorWrap(v, errors.WithStack(err))
+	}
+
+	// re-creates the discarded breakpoints from the store, and reports if could not
+	if err := d.restoreBreakpoints(v); err != nil {
+		return nvimutil.ErrorWrap(v, err)
+	}
+	return nil
 }
 
 // ----------------------------------------------------------------------------
@@ -427,7 +598,7 @@ func (d *Delve) state(v *nvim.Nvim) error {
 		return nvimutil.ErrorWrap(v, errors.WithStack(err))
 	}
 	printDebug("state: %+v\n", state)
-	return nil
+	return nvimutil.Echo(v, "delve: %s", d.debugState())
 }
 
 // ----------------------------------------------------------------------------
@@ -444,6 +615,10 @@ func (d *Delve) cmdStdin(v *nvim.Nvim) {
 //  :help input()
 //  :help command-completion-custom
 func (d *Delve) stdin(v *nvim.Nvim) error {
+	if d.term == nil {
+		return nvimutil.ErrorWrap(v, errors.Errorf("DlvStdin is not supported with the %s backend", config.DelveBackend))
+	}
+
 	var stdin interface{}
 	err := v.Call("input", &stdin, "(dlv) ", "")
 	if err != nil {
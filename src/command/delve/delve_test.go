@@ -0,0 +1,46 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import (
+	"testing"
+
+	delveapi "github.com/derekparker/delve/service/api"
+)
+
+func TestStoppedMessage(t *testing.T) {
+	fn := &delveapi.Function{Name: "main.main"}
+	tests := []struct {
+		name    string
+		cThread *delveapi.Thread
+		want    string
+	}{
+		{
+			name:    "step",
+			cThread: &delveapi.Thread{File: "/src/foo/main.go", Line: 10, Function: fn, GoroutineID: 1, PC: 0x10},
+			want:    "> main.main() ./main.go:10 goroutine(1) (PC: 0x10)",
+		},
+		{
+			name: "breakpoint of goroutine",
+			cThread: &delveapi.Thread{File: "/src/foo/main.go", Line: 12, Function: fn, GoroutineID: 1, PC: 0x20,
+				Breakpoint: &delveapi.Breakpoint{HitCount: map[string]uint64{"1": 2}, TotalHitCount: 3}},
+			want: "> main.main() ./main.go:12 (hits goroutine(1):2 total:3) (PC: 0x20)",
+		},
+		{
+			name: "breakpoint",
+			cThread: &delveapi.Thread{File: "/src/foo/main.go", Line: 12, GoroutineID: 5, PC: 0x20,
+				Breakpoint: &delveapi.Breakpoint{TotalHitCount: 3}},
+			want: "> () ./main.go:12 (hits total:3) (PC: 0x20)",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(stoppedMessage(tt.cThread, "/src/foo")); got != tt.want {
+				t.Errorf("stoppedMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
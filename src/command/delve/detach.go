@@ -18,8 +18,15 @@ func (d *Delve) cmdDetach(v *nvim.Nvim) {
 
 func (d *Delve) detach(v *nvim.Nvim) error {
 	defer d.kill()
+	if d.debugState() == stateIdle {
+		return nil
+	}
+	d.unplaceStoppedSigns(v)
+	d.setState(v, stateIdle)
+	d.resetOutput()
+
 	if d.processPid != 0 {
-		err := d.client.Detach(true)
+		err := d.client.Detach(killOnDetach(d.sessionCmd))
 		if err != nil {
 			return nvimutil.ErrorWrap(d.Nvim, errors.WithStack(err))
 		}
@@ -29,6 +36,12 @@ func (d *Delve) detach(v *nvim.Nvim) error {
 	return nil
 }
 
+// killOnDetach reports whether the debuggee of the cmd session is killed on detach.
+// The attached or connected process is not started by delve, so keeps it running.
+func killOnDetach(cmd string) bool {
+	return cmd != "attach" && cmd != "connect"
+}
+
 func (d *Delve) kill() error {
 	if d.server != nil {
 		err := d.server.Process.Kill()
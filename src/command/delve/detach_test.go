@@ -0,0 +1,28 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import "testing"
+
+func TestKillOnDetach(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want bool
+	}{
+		{cmd: "debug", want: true},
+		{cmd: "test", want: true},
+		{cmd: "exec", want: true},
+		{cmd: "attach", want: false},
+		{cmd: "connect", want: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.cmd, func(t *testing.T) {
+			if got := killOnDetach(tt.cmd); got != tt.want {
+				t.Errorf("killOnDetach(%q) = %v, want %v", tt.cmd, got, tt.want)
+			}
+		})
+	}
+}
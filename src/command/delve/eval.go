@@ -0,0 +1,276 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	delveapi "github.com/derekparker/delve/service/api"
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/config"
+	"github.com/zchee/nvim-go/src/nvimutil"
+)
+
+// evalScope returns the scope of the evaluate expressions, which is the selected goroutine
+// and stack frame of the context buffer.
+func (d *Delve) evalScope() delveapi.EvalScope {
+	d.frameMu.Lock()
+	defer d.frameMu.Unlock()
+
+	if d.goroutineID <= 0 {
+		return delveapi.EvalScope{GoroutineID: -1}
+	}
+	return delveapi.EvalScope{GoroutineID: d.goroutineID, Frame: d.frame}
+}
+
+// loadConfig returns the LoadConfig of the evaluate expressions from the user config.
+func loadConfig() delveapi.LoadConfig {
+	return delveapi.LoadConfig{
+		FollowPointers:     true,
+		MaxVariableRecurse: int(config.DelveMaxVariableRecurse),
+		MaxStringLen:       int(config.DelveMaxStringLen),
+		MaxArrayValues:     int(config.DelveMaxArrayValues),
+		MaxStructFields:    -1,
+	}
+}
+
+// ----------------------------------------------------------------------------
+// eval
+
+func (d *Delve) cmdEval(v *nvim.Nvim, args []string) {
+	go func() {
+		if err := d.eval(v, strings.Join(args, " ")); err != nil {
+			nvimutil.ErrorWrap(v, err)
+		}
+	}()
+}
+
+// eval evaluates the expr expression on the current scope, and shows the result in the
+// floating window or echo area. The empty expr prompts the expression.
+func (d *Delve) eval(v *nvim.Nvim, expr string) error {
+	if d.client == nil {
+		return errNotRunning
+	}
+	if d.debugState() == stateRunning {
+		return errRunning
+	}
+
+	if expr == "" {
+		if err := v.Call("input", &expr, "(dlv) eval "); err != nil {
+			return nil // canceled
+		}
+		if expr = strings.TrimSpace(expr); expr == "" {
+			return nil
+		}
+	}
+
+	variable, err := d.client.EvalVariable(d.evalScope(), expr, loadConfig())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// the floating window needs Neovim 0.4 or later
+	if !config.DelveEvalFloat || !hasFloatWindow(v) {
+		return nvimutil.Echo(v, "%s = %s", expr, variable.SinglelineString())
+	}
+
+	return openFloat(v, evalLines(expr, variable))
+}
+
+// evalLines returns the lines of the evaluated variable of expr.
+func evalLines(expr string, variable *delveapi.Variable) [][]byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s = %s", expr, variable.Type, variable.MultilineString(""))
+
+	return nvimutil.ToBufferLines(bytes.TrimRight(buf.Bytes(), "\n"))
+}
+
+// hasFloatWindow reports whether the Neovim supports the floating window.
+func hasFloatWindow(v *nvim.Nvim) bool {
+	var has int
+	if err := v.Call("has", &has, "nvim-0.4"); err != nil {
+		return false
+	}
+
+	return has == 1
+}
+
+// openFloat opens the floating window of lines under the cursor, which is closed
+// when the cursor moved.
+func openFloat(v *nvim.Nvim, lines [][]byte) error {
+	var b nvim.Buffer
+	if err := v.Call("nvim_create_buf", &b, false, true); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := v.SetBufferLines(b, 0, -1, true, lines); err != nil {
+		return errors.WithStack(err)
+	}
+
+	width := 1
+	for _, line := range lines {
+		if w := utf8.RuneCount(line); w > width {
+			width = w
+		}
+	}
+	winopts := map[string]interface{}{
+		"relative": "cursor",
+		"row":      1,
+		"col":      0,
+		"width":    width,
+		"height":   len(lines),
+		"style":    "minimal",
+	}
+	var w nvim.Window
+	if err := v.Call("nvim_open_win", &w, b, false, winopts); err != nil {
+		return errors.WithStack(err)
+	}
+	v.SetBufferOption(b, "filetype", "go")
+
+	autoclose := fmt.Sprintf("autocmd CursorMoved,CursorMovedI,InsertEnter,BufLeave <buffer> ++once silent! call nvim_win_close(%d, v:true)", w)
+	return errors.WithStack(v.Command(autoclose))
+}
+
+// ----------------------------------------------------------------------------
+// watch
+
+func (d *Delve) cmdWatch(v *nvim.Nvim, args []string) {
+	go func() {
+		if err := d.watch(v, strings.Join(args, " ")); err != nil {
+			nvimutil.ErrorWrap(v, err)
+		}
+	}()
+}
+
+// watch adds the expr expression to the watch expressions that evaluated on every stop.
+func (d *Delve) watch(v *nvim.Nvim, expr string) error {
+	d.watchMu.Lock()
+	for _, w := range d.watches {
+		if w == expr {
+			d.watchMu.Unlock()
+			return nil
+		}
+	}
+	d.watches = append(d.watches, expr)
+	d.watchMu.Unlock()
+
+	return d.refreshWatches(v)
+}
+
+func (d *Delve) cmdUnwatch(v *nvim.Nvim, args []string) {
+	go func() {
+		if err := d.unwatch(v, strings.Join(args, " ")); err != nil {
+			nvimutil.ErrorWrap(v, err)
+		}
+	}()
+}
+
+// unwatch removes the expr expression from the watch expressions. The empty expr removes all.
+func (d *Delve) unwatch(v *nvim.Nvim, expr string) error {
+	d.watchMu.Lock()
+	if expr == "" {
+		d.watches = nil
+	} else {
+		found := false
+		for i, w := range d.watches {
+			if w == expr {
+				d.watches = append(d.watches[:i], d.watches[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			d.watchMu.Unlock()
+			return errors.Errorf("not watched: %s", expr)
+		}
+	}
+	d.watchMu.Unlock()
+
+	return d.refreshWatches(v)
+}
+
+// WatchesCompletion return the watch expressions for command completion.
+func (d *Delve) WatchesCompletion(v *nvim.Nvim) ([]string, error) {
+	d.watchMu.Lock()
+	defer d.watchMu.Unlock()
+
+	return append([]string(nil), d.watches...), nil
+}
+
+// watchResult represents an evaluated watch expression.
+type watchResult struct {
+	Expr  string
+	Value string
+}
+
+// evalWatches evaluates the watch expressions on the current scope.
+func (d *Delve) evalWatches() []watchResult {
+	d.watchMu.Lock()
+	watches := append([]string(nil), d.watches...)
+	d.watchMu.Unlock()
+
+	results := make([]watchResult, len(watches))
+	for i, expr := range watches {
+		results[i].Expr = expr
+		variable, err := d.client.EvalVariable(d.evalScope(), expr, loadConfig())
+		switch {
+		case err != nil:
+			results[i].Value = "<" + err.Error() + ">"
+		case variable.Unreadable != "":
+			results[i].Value = "<" + variable.Unreadable + ">"
+		default:
+			results[i].Value = variable.SinglelineString()
+		}
+	}
+
+	return results
+}
+
+// formatWatches formats the watches section of the context buffer.
+func formatWatches(results []watchResult) [][]byte {
+	msg := []byte("Watches\n")
+	for _, r := range results {
+		msg = append(msg, []byte(fmt.Sprintf("\t\u25B6 %s = %s\n", r.Expr, r.Value))...) // \u25B6: ▶
+	}
+
+	return nvimutil.ToBufferLines(msg)
+}
+
+// printWatches appends the watches section to the context buffer.
+func (d *Delve) printWatches() error {
+	d.watchMu.Lock()
+	n := len(d.watches)
+	d.watchMu.Unlock()
+	if n == 0 {
+		return nil
+	}
+
+	lines := formatWatches(d.evalWatches())
+	return errors.WithStack(d.Nvim.SetBufferLines(d.buffers[Context].Buffer(), -1, -1, true, lines))
+}
+
+// refreshWatches redraws the context buffer with the watch expressions if stopped.
+func (d *Delve) refreshWatches(v *nvim.Nvim) error {
+	if d.client == nil || d.debugState() != stateStopped {
+		return nil
+	}
+	state, err := d.client.GetState()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if state.Exited || state.CurrentThread == nil {
+		return nil
+	}
+
+	goroutines, err := d.client.ListGoroutines()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return d.printContext(d.contextDir, goroutines)
+}
@@ -0,0 +1,67 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import (
+	"reflect"
+	"testing"
+
+	delveapi "github.com/derekparker/delve/service/api"
+)
+
+func TestEvalLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		variable *delveapi.Variable
+		want     []string
+	}{
+		{
+			name:     "int",
+			expr:     "i",
+			variable: &delveapi.Variable{Name: "i", Type: "int", Kind: reflect.Int, Value: "10"},
+			want:     []string{"i int = 10"},
+		},
+		{
+			name:     "string",
+			expr:     "s",
+			variable: &delveapi.Variable{Name: "s", Type: "string", Kind: reflect.String, Value: "foo", Len: 3},
+			want:     []string{`s string = "foo"`},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, line := range evalLines(tt.expr, tt.variable) {
+				got = append(got, string(line))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("evalLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatWatches(t *testing.T) {
+	results := []watchResult{
+		{Expr: "i", Value: "10"},
+		{Expr: "x", Value: "<could not find symbol value for x>"},
+	}
+	want := []string{
+		"Watches",
+		"\t▶ i = 10",
+		"\t▶ x = <could not find symbol value for x>",
+		"",
+	}
+
+	var got []string
+	for _, line := range formatWatches(results) {
+		got = append(got, string(line))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("formatWatches() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+
+	delveapi "github.com/derekparker/delve/service/api"
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/nvimutil"
+	"github.com/zchee/nvim-go/src/pathutil"
+)
+
+// frameSignID is the sign ID of the selected stack frame.
+const frameSignID = 40000
+
+// contextLine represents a goroutine or stack frame line of the context buffer.
+type contextLine struct {
+	GoroutineID int
+	Frame       int // the stack frame index, or -1 if the goroutine line
+	File        string
+	Line        int
+}
+
+// funcName returns the function name of loc, or empty if unknown.
+func funcName(loc delveapi.Location) string {
+	if loc.Function == nil {
+		return ""
+	}
+	return loc.Function.Name
+}
+
+// formatStacktrace formats the stacktraces section of the context buffer. The goroutine
+// of gid is expanded to the stacks frames, and the frame index of stacks is marked as selected.
+// It also returns the goroutine and frame of each buffer line, and the highlight line of gid.
+func formatStacktrace(goroutines []*delveapi.Goroutine, gid, frame int, stacks []delveapi.Stackframe, cwd string) ([]byte, map[int]*contextLine, int) {
+	msg := []byte("Stacktraces\n")
+	lines := make(map[int]*contextLine)
+	hlLine := 0
+
+	for _, g := range goroutines {
+		line := bytes.Count(msg, []byte{'\n'}) + 1
+		lines[line] = &contextLine{GoroutineID: g.ID, Frame: -1, File: g.CurrentLoc.File, Line: g.CurrentLoc.Line}
+
+		if g.ID != gid {
+			msg = append(msg, []byte(fmt.Sprintf("\t\u25B6 %s\n", funcName(g.CurrentLoc)))...) // \u25B6: ▶
+			continue
+		}
+		hlLine = line
+		msg = append(msg, []byte(fmt.Sprintf("*\t\u25BC %s\n", funcName(g.CurrentLoc)))...) // \u25BC: ▼
+
+		for i, s := range stacks {
+			line := bytes.Count(msg, []byte{'\n'}) + 1
+			lines[line] = &contextLine{GoroutineID: g.ID, Frame: i, File: s.File, Line: s.Line}
+
+			if i == frame {
+				msg = append(msg, '*')
+			}
+			msg = append(msg, []byte(fmt.Sprintf("\t\t\t%s()\t%s:%d\n", funcName(s.Location), pathutil.ShortFilePath(s.File, cwd), s.Line))...)
+		}
+	}
+
+	return msg, lines, hlLine
+}
+
+// formatThreads formats the thread buffer lines. The first line is the header,
+// and the goroutine of gid is marked as selected.
+func formatThreads(goroutines []*delveapi.Goroutine, gid int, cwd string) [][]byte {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "  Goroutine\tLocation\tFunction\tThread")
+	for _, g := range goroutines {
+		mark := " "
+		if g.ID == gid {
+			mark = "*"
+		}
+		thread := "-"
+		if g.ThreadID != 0 {
+			thread = fmt.Sprint(g.ThreadID)
+		}
+		fmt.Fprintf(w, "%s %d\t%s:%d\t%s\t%s\n", mark, g.ID, pathutil.ShortFilePath(g.CurrentLoc.File, cwd), g.CurrentLoc.Line, funcName(g.CurrentLoc), thread)
+	}
+	w.Flush()
+
+	return nvimutil.ToBufferLines(bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}))
+}
+
+// printThreads prints the goroutines to the thread buffer.
+// The goroutines must be sorted by ID.
+func (d *Delve) printThreads(cwd string, goroutines []*delveapi.Goroutine) error {
+	d.frameMu.Lock()
+	lines := formatThreads(goroutines, d.goroutineID, cwd)
+	d.threadIDs = d.threadIDs[:0]
+	for _, g := range goroutines {
+		d.threadIDs = append(d.threadIDs, g.ID)
+	}
+	d.frameMu.Unlock()
+
+	b := d.buffers[Threads].Buffer()
+	defer nvimutil.Modifiable(d.Nvim, b)()
+
+	return errors.WithStack(d.Nvim.SetBufferLines(b, 0, -1, true, lines))
+}
+
+// resetFrame selects the top frame of the stopped goroutine gid, and unplaces the frame sign.
+func (d *Delve) resetFrame(v *nvim.Nvim, gid int) {
+	d.frameMu.Lock()
+	d.goroutineID, d.frame, d.stoppedID = gid, 0, gid
+	d.frameMu.Unlock()
+
+	d.unplaceFrameSign(v)
+}
+
+// unplaceFrameSign unplaces the sign of the selected stack frame if placed.
+func (d *Delve) unplaceFrameSign(v *nvim.Nvim) {
+	if d.frameSign == nil || d.frameSign.LastFile == "" {
+		return
+	}
+	d.frameSign.Unplace(v, frameSignID, d.frameSign.LastFile)
+	d.frameSign.LastFile = ""
+}
+
+// ----------------------------------------------------------------------------
+// context and thread buffer actions
+
+// handleContextAction handles the mappings of the context buffer.
+// The line is the cursor line of the context buffer.
+func (d *Delve) handleContextAction(v *nvim.Nvim, action string, line int) {
+	go func() {
+		d.varMu.Lock()
+		n, ok := d.varLines[line]
+		d.varMu.Unlock()
+		if ok {
+			var err error
+			switch action {
+			case "select":
+				err = d.toggleVariable(v, n)
+			case "set":
+				err = d.promptVariable(v, n)
+			}
+			if err != nil {
+				nvimutil.ErrorWrap(v, err)
+			}
+			return
+		}
+
+		d.frameMu.Lock()
+		cl, ok := d.contextLines[line]
+		d.frameMu.Unlock()
+		if !ok || action != "select" {
+			return
+		}
+
+		frame := cl.Frame
+		if frame < 0 {
+			frame = 0
+		}
+		if err := d.selectFrame(v, cl.GoroutineID, frame); err != nil {
+			nvimutil.ErrorWrap(v, err)
+		}
+	}()
+}
+
+// handleThreadsAction handles the mappings of the thread buffer.
+// The line is the cursor line of the thread buffer.
+func (d *Delve) handleThreadsAction(v *nvim.Nvim, action string, line int) {
+	go func() {
+		d.frameMu.Lock()
+		idx := line - 2 // skip the header line
+		if idx < 0 || idx >= len(d.threadIDs) || action != "select" {
+			d.frameMu.Unlock()
+			return
+		}
+		gid := d.threadIDs[idx]
+		d.frameMu.Unlock()
+
+		if err := d.selectFrame(v, gid, 0); err != nil {
+			nvimutil.ErrorWrap(v, err)
+		}
+	}()
+}
+
+// selectFrame switches the current goroutine to gid if needed, and selects the frame index
+// of its stacktrace. It redraws the context buffer with the locals of the selected frame,
+// and shows the frame source line in the source window.
+func (d *Delve) selectFrame(v *nvim.Nvim, gid, frame int) error {
+	if d.client == nil {
+		return errNotRunning
+	}
+	if d.debugState() == stateRunning {
+		return errRunning
+	}
+
+	d.frameMu.Lock()
+	switched := gid != d.goroutineID
+	d.frameMu.Unlock()
+	if switched {
+		if _, err := d.client.SwitchGoroutine(gid); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	d.frameMu.Lock()
+	d.goroutineID, d.frame = gid, frame
+	d.frameMu.Unlock()
+
+	if err := d.refreshContext(v); err != nil {
+		return err
+	}
+
+	return d.showFrame(v)
+}
+
+// refreshContext redraws the context and thread buffers on the selected frame.
+func (d *Delve) refreshContext(v *nvim.Nvim) error {
+	goroutines, err := d.client.ListGoroutines()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return d.printContext(d.contextDir, goroutines)
+}
+
+// showFrame places the frame sign to the selected frame source line, and opens it in the
+// source window. The top frame of the stopped goroutine is shown by the pc sign instead.
+func (d *Delve) showFrame(v *nvim.Nvim) error {
+	d.frameMu.Lock()
+	var file string
+	var line int
+	for _, cl := range d.contextLines {
+		if cl.GoroutineID == d.goroutineID && cl.Frame == d.frame {
+			file, line = cl.File, cl.Line
+			break
+		}
+	}
+	stopped := d.goroutineID == d.stoppedID && d.frame == 0
+	d.frameMu.Unlock()
+
+	d.unplaceFrameSign(v)
+	if file == "" {
+		return nil
+	}
+	if !stopped {
+		if err := d.frameSign.Place(v, frameSignID, line, file, false); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if err := v.SetCurrentWindow(d.cw); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := v.Command("edit " + file); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := v.SetWindowCursor(d.cw, [2]int{line, 0}); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(v.Command("silent normal zz"))
+}
@@ -0,0 +1,122 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import (
+	"reflect"
+	"testing"
+
+	delveapi "github.com/derekparker/delve/service/api"
+)
+
+func testLocation(file string, line int, fn string) delveapi.Location {
+	return delveapi.Location{File: file, Line: line, Function: &delveapi.Function{Name: fn}}
+}
+
+func TestFormatStacktrace(t *testing.T) {
+	goroutines := []*delveapi.Goroutine{
+		{ID: 1, CurrentLoc: testLocation("/src/foo/main.go", 10, "main.main"), ThreadID: 100},
+		{ID: 2, CurrentLoc: testLocation("/usr/lib/go/src/runtime/proc.go", 20, "runtime.gopark")},
+	}
+	stacks := []delveapi.Stackframe{
+		{Location: testLocation("/src/foo/foo.go", 5, "main.foo")},
+		{Location: testLocation("/src/foo/main.go", 10, "main.main")},
+	}
+
+	tests := []struct {
+		name       string
+		gid        int
+		frame      int
+		stacks     []delveapi.Stackframe
+		wantMsg    string
+		wantLines  map[int]*contextLine
+		wantHLLine int
+	}{
+		{
+			name:   "top frame",
+			gid:    1,
+			frame:  0,
+			stacks: stacks,
+			wantMsg: "Stacktraces\n" +
+				"*\t▼ main.main\n" +
+				"*\t\t\tmain.foo()\t./foo.go:5\n" +
+				"\t\t\tmain.main()\t./main.go:10\n" +
+				"\t▶ runtime.gopark\n",
+			wantLines: map[int]*contextLine{
+				2: {GoroutineID: 1, Frame: -1, File: "/src/foo/main.go", Line: 10},
+				3: {GoroutineID: 1, Frame: 0, File: "/src/foo/foo.go", Line: 5},
+				4: {GoroutineID: 1, Frame: 1, File: "/src/foo/main.go", Line: 10},
+				5: {GoroutineID: 2, Frame: -1, File: "/usr/lib/go/src/runtime/proc.go", Line: 20},
+			},
+			wantHLLine: 2,
+		},
+		{
+			name:   "caller frame",
+			gid:    1,
+			frame:  1,
+			stacks: stacks,
+			wantMsg: "Stacktraces\n" +
+				"*\t▼ main.main\n" +
+				"\t\t\tmain.foo()\t./foo.go:5\n" +
+				"*\t\t\tmain.main()\t./main.go:10\n" +
+				"\t▶ runtime.gopark\n",
+			wantLines: map[int]*contextLine{
+				2: {GoroutineID: 1, Frame: -1, File: "/src/foo/main.go", Line: 10},
+				3: {GoroutineID: 1, Frame: 0, File: "/src/foo/foo.go", Line: 5},
+				4: {GoroutineID: 1, Frame: 1, File: "/src/foo/main.go", Line: 10},
+				5: {GoroutineID: 2, Frame: -1, File: "/usr/lib/go/src/runtime/proc.go", Line: 20},
+			},
+			wantHLLine: 2,
+		},
+		{
+			name:  "no selected goroutine",
+			gid:   -1,
+			frame: 0,
+			wantMsg: "Stacktraces\n" +
+				"\t▶ main.main\n" +
+				"\t▶ runtime.gopark\n",
+			wantLines: map[int]*contextLine{
+				2: {GoroutineID: 1, Frame: -1, File: "/src/foo/main.go", Line: 10},
+				3: {GoroutineID: 2, Frame: -1, File: "/usr/lib/go/src/runtime/proc.go", Line: 20},
+			},
+			wantHLLine: 0,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			msg, lines, hlLine := formatStacktrace(goroutines, tt.gid, tt.frame, tt.stacks, "/src/foo")
+			if string(msg) != tt.wantMsg {
+				t.Errorf("formatStacktrace() msg = %q, want %q", msg, tt.wantMsg)
+			}
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("formatStacktrace() lines = %v, want %v", lines, tt.wantLines)
+			}
+			if hlLine != tt.wantHLLine {
+				t.Errorf("formatStacktrace() hlLine = %d, want %d", hlLine, tt.wantHLLine)
+			}
+		})
+	}
+}
+
+func TestFormatThreads(t *testing.T) {
+	goroutines := []*delveapi.Goroutine{
+		{ID: 1, CurrentLoc: testLocation("/src/foo/main.go", 10, "main.main"), ThreadID: 100},
+		{ID: 2, CurrentLoc: testLocation("/src/foo/foo.go", 5, "main.foo")},
+	}
+	want := []string{
+		"  Goroutine  Location      Function   Thread",
+		"  1          ./main.go:10  main.main  100",
+		"* 2          ./foo.go:5    main.foo   -",
+	}
+
+	var got []string
+	for _, line := range formatThreads(goroutines, 2, "/src/foo") {
+		got = append(got, string(line))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("formatThreads() = %q, want %q", got, want)
+	}
+}
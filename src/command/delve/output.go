@@ -0,0 +1,190 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/nvimutil"
+	"go.uber.org/zap"
+)
+
+// OutputContext represents the debuggee stdout, stderr and stdin.
+type OutputContext struct {
+	outMu      sync.Mutex
+	outBuf     *nvimutil.Buffer // nil until the output buffer is created
+	outPending []byte           // the output written before the output buffer is created
+	outTail    []byte           // the last line of the output buffer that has no newline yet
+	stdinPipe  io.WriteCloser   // the stdin of the dlv server that the debuggee inherits
+}
+
+// splitOutput splits the tail line and the written p to the lines, and returns the
+// rest that has no newline yet. The lines include the rest if not empty, so that the
+// prompt of the interactive program is shown before the newline.
+func splitOutput(tail, p []byte) (lines [][]byte, rest []byte) {
+	text := append(append([]byte{}, tail...), p...)
+	lines = bytes.Split(text, []byte{'\n'})
+	rest = lines[len(lines)-1]
+	if len(rest) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	for i, line := range lines {
+		lines[i] = bytes.TrimSuffix(line, []byte{'\r'})
+	}
+
+	return lines, rest
+}
+
+// outputWriter is the io.Writer that streams the debuggee output to the output buffer.
+type outputWriter struct {
+	d *Delve
+}
+
+// Write implements io.Writer.
+func (w outputWriter) Write(p []byte) (int, error) {
+	return w.d.writeOutput(p)
+}
+
+// writeOutput appends p to the output buffer, or keeps it until the buffer is created.
+// The incomplete last line is updated by the next write.
+func (d *Delve) writeOutput(p []byte) (int, error) {
+	d.outMu.Lock()
+	defer d.outMu.Unlock()
+
+	if d.outBuf == nil {
+		d.outPending = append(d.outPending, p...)
+		return len(p), nil
+	}
+	if err := d.flushOutput(p); err != nil {
+		d.log.Debug("writeOutput", zap.Error(err))
+	}
+
+	return len(p), nil
+}
+
+// flushOutput replaces the tail line of the output buffer with the lines of p, and
+// scrolls the output window to the bottom. Must be called with d.outMu held.
+func (d *Delve) flushOutput(p []byte) error {
+	if len(p) == 0 {
+		return nil
+	}
+
+	buf := d.outBuf.Buffer()
+	lcount, err := d.Nvim.BufferLineCount(buf)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	start := lcount
+	if len(d.outTail) > 0 {
+		start-- // replaces the shown tail line
+	}
+	if lcount == 1 {
+		// the empty new buffer has the one empty line
+		if first, err := d.Nvim.BufferLines(buf, 0, 1, true); err == nil && len(first) == 1 && len(first[0]) == 0 {
+			start = 0
+		}
+	}
+
+	lines, rest := splitOutput(d.outTail, p)
+	d.outTail = rest
+
+	batch := d.Nvim.NewBatch()
+	batch.SetBufferOption(buf, "modifiable", true)
+	batch.SetBufferLines(buf, start, -1, true, lines)
+	batch.SetBufferOption(buf, "modifiable", false)
+	if err := batch.Execute(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// follows the output if the output window is not the current window
+	cw, err := d.Nvim.CurrentWindow()
+	if err != nil || cw == d.outBuf.Window {
+		return nil
+	}
+	end, err := d.Nvim.BufferLineCount(buf)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return d.Nvim.SetWindowCursor(d.outBuf.Window, [2]int{end, 0})
+}
+
+// setOutputBuffer sets the created output buffer, and flushes the pending output.
+func (d *Delve) setOutputBuffer(b *nvimutil.Buffer) {
+	d.outMu.Lock()
+	defer d.outMu.Unlock()
+
+	d.outBuf = b
+	d.outTail = nil
+	pending := d.outPending
+	d.outPending = nil
+	if err := d.flushOutput(pending); err != nil {
+		d.log.Debug("setOutputBuffer", zap.Error(err))
+	}
+}
+
+// resetOutput closes the debuggee stdin, and forgets the output state of the finished session.
+func (d *Delve) resetOutput() {
+	d.outMu.Lock()
+	defer d.outMu.Unlock()
+
+	if d.stdinPipe != nil {
+		d.stdinPipe.Close()
+		d.stdinPipe = nil
+	}
+	d.outBuf, d.outPending, d.outTail = nil, nil, nil
+}
+
+// ----------------------------------------------------------------------------
+// input
+
+func (d *Delve) cmdInput(v *nvim.Nvim, args []string) {
+	go func() {
+		var err error
+		if len(args) == 0 {
+			err = d.promptInput(v)
+		} else {
+			err = d.input(strings.Join(args, " "))
+		}
+		if err != nil {
+			nvimutil.ErrorWrap(v, err)
+		}
+	}()
+}
+
+// promptInput prompts the line and sends it to the debuggee stdin.
+func (d *Delve) promptInput(v *nvim.Nvim) error {
+	d.outMu.Lock()
+	prompt := string(d.outTail)
+	d.outMu.Unlock()
+
+	var line string
+	if err := v.Call("input", &line, "stdin> "+prompt); err != nil {
+		return nil // canceled
+	}
+
+	return d.input(line)
+}
+
+// input sends the line to the debuggee stdin, and echoes it to the output buffer as a terminal does.
+func (d *Delve) input(line string) error {
+	d.outMu.Lock()
+	stdin := d.stdinPipe
+	d.outMu.Unlock()
+	if stdin == nil {
+		return errNotRunning
+	}
+
+	if _, err := io.WriteString(stdin, line+"\n"); err != nil {
+		return errors.WithStack(err)
+	}
+	d.writeOutput([]byte(line + "\n"))
+
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestSplitOutput(t *testing.T) {
+	tests := []struct {
+		name      string
+		tail      string
+		p         string
+		wantLines []string
+		wantRest  string
+	}{
+		{name: "lines", p: "foo\nbar\n", wantLines: []string{"foo", "bar"}},
+		{name: "prompt", p: "foo\nname: ", wantLines: []string{"foo", "name: "}, wantRest: "name: "},
+		{name: "continue the tail", tail: "name: ", p: "bob\n", wantLines: []string{"name: bob"}},
+		{name: "crlf", p: "foo\r\n", wantLines: []string{"foo"}},
+		{name: "empty line", p: "\n", wantLines: []string{""}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			lines, rest := splitOutput([]byte(tt.tail), []byte(tt.p))
+			var got []string
+			for _, line := range lines {
+				got = append(got, string(line))
+			}
+			if !reflect.DeepEqual(got, tt.wantLines) || string(rest) != tt.wantRest {
+				t.Errorf("splitOutput(%q, %q) = (%q, %q), want (%q, %q)", tt.tail, tt.p, got, rest, tt.wantLines, tt.wantRest)
+			}
+		})
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestInput(t *testing.T) {
+	d := &Delve{}
+	if err := d.input("foo"); err != errNotRunning {
+		t.Fatalf("input() without the debuggee error = %v, want %v", err, errNotRunning)
+	}
+
+	var stdin bytes.Buffer
+	d.stdinPipe = nopWriteCloser{&stdin}
+	d.writeOutput([]byte("name: "))
+	if err := d.input("bob"); err != nil {
+		t.Fatalf("input() error = %v", err)
+	}
+	if got := stdin.String(); got != "bob\n" {
+		t.Errorf("input() wrote %q to stdin, want %q", got, "bob\n")
+	}
+	// the output is pending until the output buffer is created
+	if got := string(d.outPending); got != "name: bob\n" {
+		t.Errorf("input() echoed %q, want %q", got, "name: bob\n")
+	}
+}
@@ -7,15 +7,12 @@ package delve
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
 	"log"
 	"sort"
 
 	delveapi "github.com/derekparker/delve/service/api"
-	"github.com/neovim/go-client/nvim"
 	"github.com/pkg/errors"
 	"github.com/zchee/nvim-go/src/nvimutil"
-	"github.com/zchee/nvim-go/src/pathutil"
 )
 
 // printTerminal prints the message to terminal buffer with cmd prefix.
@@ -55,24 +52,14 @@ func (d *Delve) printTerminal(cmd string, message []byte) error {
 	return d.Nvim.SetWindowCursor(d.buffers[Terminal].Window, [2]int{len(afterBuf), 7})
 }
 
-// printServerStdout prints the server stdout results to terminal buffer.
-func (d *Delve) printServerStdout() error {
-	return d.printTerminal("", d.serverOut.Bytes())
-}
-
-// printServerStderr prints the server stderr results to terminal buffer.
-func (d *Delve) printServerStderr() error {
-	return d.printTerminal("", d.serverErr.Bytes())
-}
-
 // ----------------------------------------------------------------------------
 // context
 
-func (d *Delve) printContext(cwd string, cThread *delveapi.Thread, goroutines []*delveapi.Goroutine) error {
+func (d *Delve) printContext(cwd string, goroutines []*delveapi.Goroutine) error {
 	d.Nvim.SetBufferOption(d.buffers[Context].Buffer(), "modifiable", true)
 	defer d.Nvim.SetBufferOption(d.buffers[Context].Buffer(), "modifiable", false)
 
-	stackHeight, err := d.printStacktrace(cwd, cThread.Function, goroutines)
+	stackHeight, err := d.printStacktrace(cwd, goroutines)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -81,7 +68,11 @@ func (d *Delve) printContext(cwd string, cThread *delveapi.Thread, goroutines []
 		return errors.WithStack(err)
 	}
 
-	return nil
+	if err := d.printWatches(); err != nil {
+		return err
+	}
+
+	return d.printThreads(cwd, goroutines)
 }
 
 // ----------------------------------------------------------------------------
@@ -96,43 +87,32 @@ func (a byGroutineID) Less(i, j int) bool { return a[i].ID < a[j].ID }
 
 const goroutineDepth = 20
 
-func (d *Delve) printStacktrace(cwd string, currentFunc *delveapi.Function, goroutines []*delveapi.Goroutine) (int, error) {
+// printStacktrace prints the goroutines and the stacktrace of the selected goroutine to the
+// context buffer, and loads the locals of the selected frame.
+func (d *Delve) printStacktrace(cwd string, goroutines []*delveapi.Goroutine) (int, error) {
 	sort.Sort(byGroutineID(goroutines))
 
-	var locals []delveapi.Variable
-	var fade *nvimutil.Fade
-
-	stacksMsg := []byte("Stacktraces\n")
+	scope := d.evalScope()
 	end, _ := d.Nvim.BufferLineCount(d.buffers[Context].Buffer())
 
-	for _, g := range goroutines {
-		// Get the each threads function name.
-		if g.CurrentLoc.Function.Name == currentFunc.Name {
-			stacksMsg = append(stacksMsg, byte('*'))
-			hlLine := len(nvimutil.ToBufferLines(stacksMsg))
-			fade = nvimutil.NewFader(d.Nvim, d.buffers[Context].Buffer(), "delveFade", hlLine, hlLine, 3, -1, 80)
-		} else {
-			stacksMsg = append(stacksMsg, []byte(fmt.Sprintf("\t\u25B6 %s\n", g.CurrentLoc.Function.Name))...) // \u25B6: ▶
-			continue
+	// Gets the stacktrace of the selected goroutine if valid goroutine ID.
+	var stacks []delveapi.Stackframe
+	if scope.GoroutineID > 0 {
+		var err error
+		stacks, err = d.client.Stacktrace(scope.GoroutineID, goroutineDepth, nil) // []delveapi.Stackframe
+		if err != nil {
+			return end, errors.WithStack(err)
 		}
+	}
 
-		stacksMsg = append(stacksMsg, []byte(fmt.Sprintf("\t\u25BC %s\n", g.CurrentLoc.Function.Name))...) // \u25BC: ▼
-
-		// Appends the stacktrace from each threads goroutine if valid goroutine ID.
-		if g.ID != 0 {
-			stacks, err := d.client.Stacktrace(g.ID, goroutineDepth, &delveapi.LoadConfig{FollowPointers: true}) // []delveapi.Stackframe
-			if err != nil {
-				return end, errors.WithStack(err)
-			}
-			for _, s := range stacks {
-				stacksMsg = append(stacksMsg, []byte(
-					fmt.Sprintf("\t\t\t%s()\t%s:%d\n",
-						s.Function.Name,
-						pathutil.ShortFilePath(s.File, cwd),
-						s.Line))...)
-				locals = append(locals, s.Locals...)
-			}
-		}
+	stacksMsg, lines, hlLine := formatStacktrace(goroutines, scope.GoroutineID, scope.Frame, stacks, cwd)
+	d.frameMu.Lock()
+	d.contextLines = lines
+	d.frameMu.Unlock()
+
+	var fade *nvimutil.Fade
+	if hlLine > 0 {
+		fade = nvimutil.NewFader(d.Nvim, d.buffers[Context].Buffer(), "delveFade", hlLine, hlLine, 3, -1, 80)
 	}
 
 	stackData := nvimutil.ToBufferLines(stacksMsg)
@@ -164,7 +144,18 @@ func (d *Delve) printStacktrace(cwd string, currentFunc *delveapi.Function, goro
 	// 	l.Len,
 	// 	l.Cap,
 	// 	l.Unreadable))...)
-	d.Locals = locals
+	d.Locals = nil
+	if scope.GoroutineID > 0 && scope.Frame < len(stacks) {
+		args, err := d.client.ListFunctionArgs(scope, loadConfig())
+		if err != nil {
+			return end, errors.WithStack(err)
+		}
+		locals, err := d.client.ListLocalVariables(scope, loadConfig())
+		if err != nil {
+			return end, errors.WithStack(err)
+		}
+		d.Locals = append(args, locals...)
+	}
 
 	return end, nil
 }
@@ -172,25 +163,45 @@ func (d *Delve) printStacktrace(cwd string, currentFunc *delveapi.Function, goro
 // ----------------------------------------------------------------------------
 // locals
 
+// printLocals prints the locals tree to the context buffer after the stacktrace
+// of stackHeight lines. The -1 stackHeight means the stacktrace is the whole buffer.
 func (d *Delve) printLocals(cwd string, locals []delveapi.Variable, stackHeight int) error {
-	localsMsg := []byte("Local Variables\n")
-	for _, l := range locals {
-		localsMsg = append(localsMsg, []byte(fmt.Sprintf("\t\u25B6 %s %s\n", l.Name, l.Kind.String()))...) // \u25B6: ▶
+	start := stackHeight
+	if start < 0 {
+		lcount, err := d.Nvim.BufferLineCount(d.buffers[Context].Buffer())
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		start = lcount
+	}
 
+	nodes := make([]*varNode, len(locals))
+	for i := range locals {
+		nodes[i] = &varNode{Expr: locals[i].Name, Path: locals[i].Name, Name: locals[i].Name, Var: &locals[i]}
 	}
-	if err := d.Nvim.SetBufferLines(d.buffers[Context].Buffer(), stackHeight, -1, true, bytes.Split(localsMsg, []byte{'\n'})); err != nil {
-		return errors.WithStack(err)
+
+	d.varMu.Lock()
+	expanded := make(map[string]bool, len(d.expanded))
+	for path := range d.expanded {
+		expanded[path] = true
 	}
+	d.varMu.Unlock()
 
-	return nil
-}
+	lines, lineNodes := renderVariables(nodes, expanded, d.loadVariable)
 
-func (d *Delve) printThread(v *nvim.Nvim, cwd string, threads []*delveapi.Thread) error {
-	v.SetBufferOption(d.buffers[Context].Buffer(), "modifiable", true)
-	defer v.SetBufferOption(d.buffers[Context].Buffer(), "modifiable", false)
+	d.varMu.Lock()
+	d.varLines = make(map[int]*varNode, len(lineNodes))
+	for i, n := range lineNodes {
+		if n != nil {
+			d.varLines[start+2+i] = n // skip the header line
+		}
+	}
+	d.varMu.Unlock()
 
-	for _, thread := range threads {
-		printDebug("thread", thread.File)
+	localsMsg := append([][]byte{[]byte("Local Variables")}, lines...)
+	localsMsg = append(localsMsg, []byte{})
+	if err := d.Nvim.SetBufferLines(d.buffers[Context].Buffer(), stackHeight, -1, true, localsMsg); err != nil {
+		return errors.WithStack(err)
 	}
 
 	return nil
@@ -12,22 +12,61 @@ import (
 )
 
 // Register register nvim-go's delve command or function to Neovim over the msgpack-rpc plugin interface.
-func Register(ctx context.Context, p *plugin.Plugin, buildContext *buildctx.Context) {
-	d := NewDelve(ctx, p.Nvim, buildContext)
+func Register(ctx context.Context, p *plugin.Plugin, buildContexts *buildctx.Registry) {
+	d := NewDelve(ctx, p.Nvim, buildContexts)
 
 	// Debug compile and begin debugging program.
-	p.HandleCommand(&plugin.CommandOptions{Name: "DlvDebug", NArgs: "*", Eval: "[getcwd(), expand('%:p:h')]"}, d.cmdDebug)
+	p.HandleCommand(&plugin.CommandOptions{Name: "DlvDebug", NArgs: "*", Eval: "[getcwd(), expand('%:p:h'), bufnr('%')]"}, d.cmdDebug)
+	// Exec execute a precompiled binary, and begin a debug session.
+	p.HandleCommand(&plugin.CommandOptions{Name: "DlvExec", NArgs: "+", Eval: "[getcwd(), expand('%:p:h'), bufnr('%')]", Complete: "file"}, d.cmdExec)
+	// Test compile test binary and begin debugging program.
+	p.HandleCommand(&plugin.CommandOptions{Name: "DlvTest", NArgs: "*", Eval: "[getcwd(), expand('%:p:h'), expand('%:p'), line2byte(line('.')) + (col('.')-2), bufnr('%')]"}, d.cmdTest)
+	// Attach attach to running process and begin debugging.
+	p.HandleCommand(&plugin.CommandOptions{Name: "DlvAttach", NArgs: "+", Eval: "[getcwd(), expand('%:p:h'), bufnr('%')]"}, d.cmdAttach)
 	// Connect connect to a headless debug server.
-	p.HandleCommand(&plugin.CommandOptions{Name: "DlvConnect", NArgs: "*", Eval: "[getcwd(), expand('%:p:h')]"}, d.cmdConnect)
+	p.HandleCommand(&plugin.CommandOptions{Name: "DlvConnect", NArgs: "*", Eval: "[getcwd(), expand('%:p:h'), bufnr('%')]"}, d.cmdConnect)
 
-	// Breakpoint sets a breakpoint.
+	// Breakpoint sets a breakpoint, with the optional condition, hit count condition or log message.
+	// "DlvBreakpoint [location] [if <expr>] [hits <op> <n>] [log <message>]"
 	p.HandleCommand(&plugin.CommandOptions{Name: "DlvBreakpoint", NArgs: "*", Eval: "[expand('%:p')]", Complete: "customlist,FunctionsCompletion"}, d.cmdBreakpoint)
+	// BreakpointToggle sets or clears the breakpoint at the cursor line.
+	p.HandleCommand(&plugin.CommandOptions{Name: "DlvBreakpointToggle", Eval: "[expand('%:p'), line('.')]"}, d.cmdBreakpointToggle)
+	// ClearAll clears the all breakpoints.
+	p.HandleCommand(&plugin.CommandOptions{Name: "DlvClearAll"}, d.cmdClearAll)
+	// Breakpoints opens the breakpoints list buffer.
+	p.HandleCommand(&plugin.CommandOptions{Name: "DlvBreakpoints", Eval: "[getcwd()]"}, d.cmdBreakpoints)
+	// RPC export
+	p.Handle("DlvBreakpointsAction", d.handleBreakpointsAction)
+	p.Handle("DlvContextAction", d.handleContextAction)
+	p.Handle("DlvThreadsAction", d.handleThreadsAction)
 
 	// Stepping execution control
 	// Continue run until breakpoint or program termination.
 	p.HandleCommand(&plugin.CommandOptions{Name: "DlvContinue", NArgs: "*", Eval: "[expand('%:p:h')]"}, d.cmdContinue)
 	// Next step over to next source line.
 	p.HandleCommand(&plugin.CommandOptions{Name: "DlvNext", Eval: "[expand('%:p:h')]"}, d.cmdNext)
+	// Step single step through program.
+	p.HandleCommand(&plugin.CommandOptions{Name: "DlvStep", Eval: "[expand('%:p:h')]"}, d.cmdStep)
+	// StepOut step out of the current function.
+	p.HandleCommand(&plugin.CommandOptions{Name: "DlvStepOut", Eval: "[expand('%:p:h')]"}, d.cmdStepOut)
+	// StepInstruction single step a single cpu instruction.
+	p.HandleCommand(&plugin.CommandOptions{Name: "DlvStepInstruction", Eval: "[expand('%:p:h')]"}, d.cmdStepInstruction)
+	// Pause interrupts the running process.
+	p.HandleCommand(&plugin.CommandOptions{Name: "DlvPause"}, d.cmdPause)
+	// RunToCursor run until the cursor line.
+	p.HandleCommand(&plugin.CommandOptions{Name: "DlvRunToCursor", Eval: "[expand('%:p:h'), expand('%:p'), line('.')]"}, d.cmdRunToCursor)
+
+	// Eval evaluates the expression and shows the result. Prompts the expression if no args.
+	p.HandleCommand(&plugin.CommandOptions{Name: "DlvEval", NArgs: "*"}, d.cmdEval)
+	// Set sets the value to the scalar variable.
+	// "DlvSet {var} {value}"
+	p.HandleCommand(&plugin.CommandOptions{Name: "DlvSet", NArgs: "+"}, d.cmdSet)
+	// Watch adds the watch expression that evaluated on every stop.
+	p.HandleCommand(&plugin.CommandOptions{Name: "DlvWatch", NArgs: "+"}, d.cmdWatch)
+	// Unwatch removes the watch expression, or all watch expressions if no args.
+	p.HandleCommand(&plugin.CommandOptions{Name: "DlvUnwatch", NArgs: "*", Complete: "customlist,WatchesCompletion"}, d.cmdUnwatch)
+	// WatchesCompletion list of watch expressions for command completion.
+	p.HandleFunction(&plugin.FunctionOptions{Name: "WatchesCompletion"}, d.WatchesCompletion)
 
 	// restart restart the process.
 	p.HandleCommand(&plugin.CommandOptions{Name: "DlvRestart"}, d.cmdRestart) // Restart process.
@@ -37,6 +76,11 @@ func Register(ctx context.Context, p *plugin.Plugin, buildContext *buildctx.Cont
 	p.HandleCommand(&plugin.CommandOptions{Name: "DlvStdin"}, d.cmdStdin)
 	// RPC export
 	p.Handle("DlvStdin", d.stdin)
+	// input sends the line to the debuggee stdin. Prompts the line if no args.
+	// "DlvInput [line]"
+	p.HandleCommand(&plugin.CommandOptions{Name: "DlvInput", NArgs: "*"}, d.cmdInput)
+	// RPC export
+	p.Handle("DlvInput", d.promptInput)
 	// FunctionsCompletion list of functions for command completion.
 	p.HandleFunction(&plugin.FunctionOptions{Name: "FunctionsCompletion"}, d.FunctionsCompletion)
 
@@ -46,7 +90,12 @@ func Register(ctx context.Context, p *plugin.Plugin, buildContext *buildctx.Cont
 	// State (WIP: for debug)
 	p.HandleCommand(&plugin.CommandOptions{Name: "DlvState"}, d.cmdState)
 
+	// autocmd BufWinEnter
+	// re-places the breakpoint signs of the entered buffer.
+	// Note that the plugin manifest can't have the same event and pattern autocmd as command package BufEnter.
+	p.HandleAutocmd(&plugin.AutocmdOptions{Event: "BufWinEnter", Group: "nvim-go", Pattern: "*.go", Eval: "[expand('%:p')]"}, d.cmdBufEnter)
+
 	// autocmd VimLeavePre
 	// FIXME(zchee): Why "[delve]*" pattern dose not handle autocmd?
-	p.HandleAutocmd(&plugin.AutocmdOptions{Event: "VimLeavePre", Group: "nvim-go", Pattern: "*.go,terminal,context,thread"}, d.cmdDetach)
+	p.HandleAutocmd(&plugin.AutocmdOptions{Event: "VimLeavePre", Group: "nvim-go", Pattern: "*.go,terminal,context,thread,output"}, d.cmdDetach)
 }
@@ -7,9 +7,12 @@ package delve
 import (
 	"net"
 	"os/exec"
+	"strconv"
+	"strings"
 
 	"github.com/neovim/go-client/nvim"
 	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/config"
 	"github.com/zchee/nvim-go/src/nvimutil"
 )
 
@@ -19,6 +22,8 @@ type Config struct {
 	flags []string
 	path  string
 	pid   int
+	dir   string   // working directory of the dlv command
+	args  []string // arguments of the debuggee program
 }
 
 // startServer starts the delve headless server and replace server Stdout & Stderr.
@@ -28,28 +33,76 @@ func (d *Delve) startServer(cmd string, cfg Config) error {
 		return errors.WithStack(err)
 	}
 
+	if config.DelveBackend == BackendDAP {
+		return d.startDAPServer(dlv, cmd, cfg)
+	}
+
+	headless := []string{"--headless", "--listen=" + cfg.addr, "--accept-multiclient", "--api-version=2"}
+
 	switch cmd {
 	case "attach":
-		// TODO(zchee): implements
+		// attach command must be pid to the second argument
+		d.server = exec.Command(dlv, append([]string{cmd, strconv.Itoa(cfg.pid)}, headless...)...)
 	case "connect":
 		// connect command must be addr to the second argument
 		d.server = exec.Command(dlv, cmd, cfg.addr, "--log")
-	case "debug":
-		// debug command must be package path to the second argument, and need "--accept-multiclient" flag
-		d.server = exec.Command(dlv, cmd, cfg.path, "--headless", "--listen="+cfg.addr, "--accept-multiclient", "--api-version=2", "--log")
+	case "debug", "test":
+		// debug and test command must be package path to the second argument, and need "--accept-multiclient" flag
+		d.server = exec.Command(dlv, append([]string{cmd, cfg.path}, headless...)...)
 	case "exec":
-		// TODO(zchee): implements
-	case "test":
-		// TODO(zchee): implements
-	case "trace":
-		// TODO(zchee): implements
+		// exec command must be binary path to the second argument
+		d.server = exec.Command(dlv, append([]string{cmd, cfg.path}, headless...)...)
+	default:
+		// TODO(zchee): implements trace
+		return errors.Errorf("not supported dlv %s command", cmd)
 	}
+	d.server.Dir = cfg.dir
 	// append other flags such as build flags
 	d.server.Args = append(d.server.Args, cfg.flags...)
+	// the arguments of the debuggee program must be after the "--"
+	if len(cfg.args) > 0 {
+		d.server.Args = append(d.server.Args, "--")
+		d.server.Args = append(d.server.Args, cfg.args...)
+	}
+
+	return d.startServerProcess()
+}
+
+// startDAPServer starts the "dlv dap" server. The debuggee is launched or attached by the
+// DAP client, so the server only listens to addr in the working directory.
+func (d *Delve) startDAPServer(dlv, cmd string, cfg Config) error {
+	switch cmd {
+	case "attach", "debug", "test", "exec":
+		// nothing to do
+	default:
+		return errors.Errorf("not supported dlv %s command with the %s backend", cmd, BackendDAP)
+	}
+
+	d.server = exec.Command(dlv, "dap", "--listen="+cfg.addr)
+	d.server.Dir = cfg.dir
+
+	return d.startServerProcess()
+}
+
+// startServerProcess starts the d.server process that streams its stdout and stderr
+// to the output buffer. The debuggee inherits the stdin, stdout and stderr of the server,
+// so the pipes act as the redirects of the debuggee.
+func (d *Delve) startServerProcess() error {
+	out := outputWriter{d: d}
+	d.server.Stdout = out
+	d.server.Stderr = out
+	stdin, err := d.server.StdinPipe()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	d.resetOutput()
+	d.outMu.Lock()
+	d.stdinPipe = stdin
+	d.outMu.Unlock()
 
 	if err := d.server.Start(); err != nil {
-		err = errors.New(d.serverOut.String())
-		d.serverOut.Reset()
+		d.resetOutput()
 		return errors.WithStack(err)
 	}
 
@@ -74,3 +127,17 @@ func (d *Delve) dialServer(v *nvim.Nvim, addr string) error {
 
 	return nil
 }
+
+// testBinaryArgs converts the go test flags such as "-run" to the test binary
+// flags such as "-test.run".
+func testBinaryArgs(args []string) []string {
+	binArgs := make([]string, len(args))
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "-test.") && len(strings.TrimLeft(arg, "-")) > 0 {
+			arg = "-test." + strings.TrimLeft(arg, "-")
+		}
+		binArgs[i] = arg
+	}
+
+	return binArgs
+}
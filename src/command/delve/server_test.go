@@ -0,0 +1,47 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTestBinaryArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "run",
+			args: []string{"-run", "^TestFoo$/^bar$"},
+			want: []string{"-test.run", "^TestFoo$/^bar$"},
+		},
+		{
+			name: "bench and double dash",
+			args: []string{"-run", "^$", "--bench=^BenchmarkFoo$", "-v"},
+			want: []string{"-test.run", "^$", "-test.bench=^BenchmarkFoo$", "-test.v"},
+		},
+		{
+			name: "already test binary flag",
+			args: []string{"-test.count", "1", "-"},
+			want: []string{"-test.count", "1", "-"},
+		},
+		{
+			name: "empty",
+			args: nil,
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testBinaryArgs(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("testBinaryArgs(%v) = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
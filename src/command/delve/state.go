@@ -0,0 +1,149 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import (
+	"fmt"
+
+	delveapi "github.com/derekparker/delve/service/api"
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/nvimutil"
+	"go.uber.org/zap"
+)
+
+// debugState represents the state of the debugging process.
+type debugState int
+
+const (
+	// stateIdle is the state that has no debugging process.
+	stateIdle debugState = iota
+	// stateRunning is the state that the process is running by the continue or stepping command.
+	stateRunning
+	// stateStopped is the state that the process is stopped and can be inspected.
+	stateStopped
+	// stateExited is the state that the process has exited, and can be restarted.
+	stateExited
+)
+
+func (s debugState) String() string {
+	switch s {
+	case stateIdle:
+		return "idle"
+	case stateRunning:
+		return "running"
+	case stateStopped:
+		return "stopped"
+	case stateExited:
+		return "exited"
+	default:
+		return fmt.Sprintf("debugState(%d)", int(s))
+	}
+}
+
+// stateVar is the global variable name of the debugging state for the statusline, such as
+//
+//	set statusline+=%{get(g:,'go#delve#state','')}
+const stateVar = "go#delve#state"
+
+var (
+	errRunning = errors.New("the process is running, use DlvPause to interrupt")
+	errExited  = errors.New("the process has exited, use DlvRestart to restart")
+)
+
+// debugState returns the current debugging state.
+func (d *Delve) debugState() debugState {
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+
+	return d.runState
+}
+
+// setState sets the debugging state, and shows it in the statusline.
+func (d *Delve) setState(v *nvim.Nvim, s debugState) {
+	d.stateMu.Lock()
+	d.runState = s
+	d.stateMu.Unlock()
+
+	d.showState(v, s)
+}
+
+// showState shows the s state to the g:go#delve#state variable and the terminal buffer statusline.
+func (d *Delve) showState(v *nvim.Nvim, s debugState) {
+	batch := v.NewBatch()
+	batch.SetVar(stateVar, s.String())
+	if buf, ok := d.buffers[Terminal]; ok {
+		batch.SetWindowOption(buf.Window, "statusline", "delve: "+s.String())
+	}
+	batch.Command("redrawstatus!")
+	if err := batch.Execute(); err != nil {
+		d.log.Debug("showState", zap.Error(err))
+	}
+}
+
+// beginRun transitions the state to running if the process is stopped. It returns the error
+// if the process is not stopped, which rejects the continue and stepping commands.
+func (d *Delve) beginRun(v *nvim.Nvim) error {
+	d.stateMu.Lock()
+	switch d.runState {
+	case stateIdle:
+		d.stateMu.Unlock()
+		return errNotRunning
+	case stateRunning:
+		d.stateMu.Unlock()
+		return errRunning
+	case stateExited:
+		d.stateMu.Unlock()
+		return errExited
+	}
+	d.runState = stateRunning
+	d.stateMu.Unlock()
+
+	d.showState(v, stateRunning)
+	return nil
+}
+
+// exited cleans up the program counter and frame signs of the exited process, and prints
+// the exit status to the terminal buffer.
+func (d *Delve) exited(v *nvim.Nvim, cmd string, state *delveapi.DebuggerState) error {
+	d.setState(v, stateExited)
+	d.unplaceStoppedSigns(v)
+
+	return d.printTerminal(cmd, []byte(fmt.Sprintf("Process %d has exited with status %d", d.processPid, state.ExitStatus)))
+}
+
+// unplaceStoppedSigns unplaces the program counter and frame signs of the stopped position.
+func (d *Delve) unplaceStoppedSigns(v *nvim.Nvim) {
+	if d.pcSign != nil && d.pcSign.LastFile != "" {
+		d.pcSign.Unplace(v, d.pcSign.LastID, d.pcSign.LastFile)
+		d.pcSign.LastID, d.pcSign.LastFile = 0, ""
+	}
+	d.unplaceFrameSign(v)
+}
+
+// ----------------------------------------------------------------------------
+// pause
+
+func (d *Delve) cmdPause(v *nvim.Nvim) {
+	go func() {
+		if err := d.pause(v); err != nil {
+			nvimutil.ErrorWrap(v, err)
+		}
+	}()
+}
+
+// pause interrupts the running process. The interrupted continue or stepping command
+// prints the stopped position.
+func (d *Delve) pause(v *nvim.Nvim) error {
+	if d.client == nil {
+		return errNotRunning
+	}
+	if d.debugState() != stateRunning {
+		return errors.New("the process is not running")
+	}
+
+	_, err := d.client.Halt()
+	return errors.WithStack(err)
+}
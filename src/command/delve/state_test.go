@@ -0,0 +1,31 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import "testing"
+
+func TestBeginRunRejected(t *testing.T) {
+	tests := []struct {
+		state debugState
+		want  error
+	}{
+		{state: stateIdle, want: errNotRunning},
+		{state: stateRunning, want: errRunning},
+		{state: stateExited, want: errExited},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.state.String(), func(t *testing.T) {
+			d := new(Delve)
+			d.runState = tt.state
+			if err := d.beginRun(nil); err != tt.want {
+				t.Errorf("beginRun() = %v, want %v", err, tt.want)
+			}
+			if got := d.debugState(); got != tt.state {
+				t.Errorf("debugState() = %v, want %v", got, tt.state)
+			}
+		})
+	}
+}
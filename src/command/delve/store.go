@@ -0,0 +1,290 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	delveapi "github.com/derekparker/delve/service/api"
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/config"
+	"github.com/zchee/nvim-go/src/nvimutil"
+	"github.com/zchee/nvim-go/src/pathutil"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// breakpointsFile is the file that stores the breakpoints of each project root.
+var breakpointsFile = filepath.Join(config.ConfigHome, "breakpoints.yml")
+
+// savedSignID is the first sign ID of the stored breakpoints that placed before debugging.
+const savedSignID = 30000
+
+// storedBreakpoint represents a breakpoint of the breakpoints store.
+type storedBreakpoint struct {
+	File     string   `yaml:"file"`
+	Line     int      `yaml:"line"`
+	Cond     string   `yaml:"cond,omitempty"`
+	Hit      *hitCond `yaml:"hit,omitempty"`
+	Log      string   `yaml:"log,omitempty"`
+	Disabled bool     `yaml:"disabled,omitempty"`
+}
+
+// breakpoint returns the delve breakpoint and client side spec of sb.
+func (sb *storedBreakpoint) breakpoint() (*delveapi.Breakpoint, *breakpointSpec) {
+	bp := &delveapi.Breakpoint{
+		File: sb.File,
+		Line: sb.Line,
+		Cond: sb.Cond,
+	}
+	if sb.Log != "" {
+		bp.Tracepoint = true
+		bp.Variables = logExprs(sb.Log)
+	}
+
+	return bp, &breakpointSpec{Cond: sb.Cond, Hit: sb.Hit, Log: sb.Log}
+}
+
+// readBreakpointsStore reads the stored breakpoints of each project root from file.
+// It returns the empty store if file does not exist yet.
+func readBreakpointsStore(file string) (map[string][]*storedBreakpoint, error) {
+	store := make(map[string][]*storedBreakpoint)
+
+	buf, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+	if err := yaml.Unmarshal(buf, &store); err != nil {
+		return nil, errors.Wrapf(err, "could not unmarshal %s", file)
+	}
+
+	return store, nil
+}
+
+// writeBreakpointsStore replaces the stored breakpoints of the root project with bps.
+// The empty bps removes the root project from file.
+func writeBreakpointsStore(file, root string, bps []*storedBreakpoint) error {
+	store, err := readBreakpointsStore(file)
+	if err != nil {
+		return err
+	}
+	if len(bps) == 0 {
+		delete(store, root)
+	} else {
+		store[root] = bps
+	}
+
+	buf, err := yaml.Marshal(store)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal to yaml")
+	}
+	if err := pathutil.Mkdir(filepath.Dir(file), 0700); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(ioutil.WriteFile(file, buf, 0600))
+}
+
+// storedBreakpoints converts the user breakpoints and the disabled breakpoints to the stored form.
+func storedBreakpoints(bps []*delveapi.Breakpoint, disabled []*breakpointEntry, specs map[int]*breakpointSpec) []*storedBreakpoint {
+	var stored []*storedBreakpoint
+	for _, e := range breakpointEntries(bps, disabled, specs) {
+		if e.File == "" {
+			continue
+		}
+		sb := &storedBreakpoint{
+			File:     e.File,
+			Line:     e.Line,
+			Cond:     e.Cond,
+			Disabled: e.Disabled,
+		}
+		if e.Spec != nil {
+			sb.Hit = e.Spec.Hit
+			sb.Log = e.Spec.Log
+		}
+		stored = append(stored, sb)
+	}
+
+	return stored
+}
+
+// storeBreakpoints saves the current breakpoints of the debugging project to the store.
+func (d *Delve) storeBreakpoints() error {
+	if d.client == nil || d.bpRoot == "" {
+		return nil
+	}
+
+	bps, err := d.client.ListBreakpoints()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	d.bpMu.Lock()
+	stored := storedBreakpoints(bps, d.disabledBps, d.bpSpecs)
+	d.bpMu.Unlock()
+
+	return writeBreakpointsStore(breakpointsFile, d.bpRoot, stored)
+}
+
+// restoreBreakpoints creates the stored breakpoints of the debugging project that
+// are not set on the server yet, such as after start or restart.
+// The breakpoints that could not be set are reported to the terminal buffer, and
+// kept as the disabled breakpoints.
+func (d *Delve) restoreBreakpoints(v *nvim.Nvim) error {
+	store, err := readBreakpointsStore(breakpointsFile)
+	if err != nil {
+		return err
+	}
+	saved := store[d.bpRoot]
+	if len(saved) == 0 {
+		return nil
+	}
+
+	d.unplaceSavedSigns(v)
+
+	bps, err := d.client.ListBreakpoints()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	set := make(map[string]bool)
+	for _, bp := range bps {
+		set[fmt.Sprintf("%s:%d", bp.File, bp.Line)] = true
+	}
+	d.bpMu.Lock()
+	for _, e := range d.disabledBps {
+		set[fmt.Sprintf("%s:%d", e.File, e.Line)] = true
+	}
+	d.bpMu.Unlock()
+
+	var msg bytes.Buffer
+	n := 0
+	for _, sb := range saved {
+		if set[fmt.Sprintf("%s:%d", sb.File, sb.Line)] {
+			continue
+		}
+
+		bpInfo, spec := sb.breakpoint()
+		if !sb.Disabled {
+			bp, err := d.client.CreateBreakpoint(bpInfo)
+			if err == nil {
+				d.setBreakpointSpec(bp.ID, spec)
+				if err := d.placeBreakpointSign(v, bp); err != nil {
+					return err
+				}
+				n++
+				continue
+			}
+			fmt.Fprintf(&msg, "Could not set breakpoint at %s:%d, disabled: %v\n", pathutil.Rel(d.bpRoot, sb.File), sb.Line, err)
+		}
+
+		d.bpMu.Lock()
+		d.disabledBps = append(d.disabledBps, &breakpointEntry{Breakpoint: bpInfo, Spec: spec, Disabled: true})
+		d.bpMu.Unlock()
+	}
+	fmt.Fprintf(&msg, "Restored %d breakpoints", n)
+
+	if err := d.printTerminal("", msg.Bytes()); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return d.refreshBreakpoints(v)
+}
+
+// ----------------------------------------------------------------------------
+// BufWinEnter
+
+// bufEnterEval represent a BufWinEnter autocmd Eval args.
+type bufEnterEval struct {
+	File string `msgpack:",array"`
+}
+
+func (d *Delve) cmdBufEnter(v *nvim.Nvim, eval *bufEnterEval) {
+	go d.bufEnter(v, eval)
+}
+
+// bufEnter re-places the breakpoint signs of the entered file. It places the signs of the
+// server breakpoints while debugging, otherwise the signs of the stored breakpoints.
+func (d *Delve) bufEnter(v *nvim.Nvim, eval *bufEnterEval) error {
+	if d.debugState() == stateRunning {
+		return nil // re-placed on the next stop
+	}
+	if d.client != nil {
+		if bps, err := d.client.ListBreakpoints(); err == nil {
+			for _, bp := range bps {
+				if bp.ID <= 0 || bp.File != eval.File {
+					continue
+				}
+				if err := d.placeBreakpointSign(v, bp); err != nil {
+					return nvimutil.ErrorWrap(v, err)
+				}
+			}
+			return nil
+		}
+	}
+
+	store, err := readBreakpointsStore(breakpointsFile)
+	if err != nil {
+		return nvimutil.ErrorWrap(v, err)
+	}
+
+	d.bpMu.Lock()
+	if d.savedSigns == nil {
+		d.savedSigns = make(map[string][]int)
+	}
+	for _, id := range d.savedSigns[eval.File] {
+		v.Command(fmt.Sprintf("sign unplace %d file=%s", id, eval.File))
+	}
+	delete(d.savedSigns, eval.File)
+	d.bpMu.Unlock()
+
+	var ids []int
+	for root, bps := range store {
+		if !pathutil.IsSubdir(root, filepath.Dir(eval.File)) {
+			continue
+		}
+		for _, sb := range bps {
+			if sb.File != eval.File || sb.Disabled {
+				continue
+			}
+			bpInfo, spec := sb.breakpoint()
+			name, text, texthl := breakpointSign(bpInfo, spec)
+			sign, err := nvimutil.NewSign(v, name, text, texthl, "")
+			if err != nil {
+				return nvimutil.ErrorWrap(v, errors.WithStack(err))
+			}
+			id := savedSignID + len(ids)
+			if err := sign.Place(v, id, sb.Line, sb.File, false); err != nil {
+				return nvimutil.ErrorWrap(v, err)
+			}
+			ids = append(ids, id)
+		}
+	}
+
+	d.bpMu.Lock()
+	d.savedSigns[eval.File] = ids
+	d.bpMu.Unlock()
+
+	return nil
+}
+
+// unplaceSavedSigns unplaces the signs of the stored breakpoints that placed before debugging.
+func (d *Delve) unplaceSavedSigns(v *nvim.Nvim) {
+	d.bpMu.Lock()
+	defer d.bpMu.Unlock()
+
+	for file, ids := range d.savedSigns {
+		for _, id := range ids {
+			v.Command(fmt.Sprintf("sign unplace %d file=%s", id, file))
+		}
+	}
+	d.savedSigns = nil
+}
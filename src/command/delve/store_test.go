@@ -0,0 +1,78 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	delveapi "github.com/derekparker/delve/service/api"
+)
+
+func TestBreakpointsStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nvim-go-delve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "nvim-go", "breakpoints.yml")
+
+	bps := []*delveapi.Breakpoint{
+		{ID: -1, Name: "unrecovered-panic"},
+		{ID: 2, File: "/src/foo/main.go", Line: 12, Cond: "i == 1"},
+		{ID: 1, File: "/src/foo/main.go", Line: 10, Tracepoint: true, Variables: []string{"x"}},
+	}
+	disabled := []*breakpointEntry{
+		{Breakpoint: &delveapi.Breakpoint{File: "/src/foo/foo.go", Line: 5}, Spec: &breakpointSpec{Hit: &hitCond{Op: ">", N: 1}}, Disabled: true},
+	}
+	specs := map[int]*breakpointSpec{
+		1: {Log: "x is {x}"},
+	}
+	stored := storedBreakpoints(bps, disabled, specs)
+	want := []*storedBreakpoint{
+		{File: "/src/foo/main.go", Line: 10, Log: "x is {x}"},
+		{File: "/src/foo/main.go", Line: 12, Cond: "i == 1"},
+		{File: "/src/foo/foo.go", Line: 5, Hit: &hitCond{Op: ">", N: 1}, Disabled: true},
+	}
+	if !reflect.DeepEqual(stored, want) {
+		t.Fatalf("storedBreakpoints() = %#v, want %#v", stored, want)
+	}
+
+	bar := []*storedBreakpoint{{File: "/src/bar/main.go", Line: 3}}
+	if err := writeBreakpointsStore(file, "/src/foo", stored); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeBreakpointsStore(file, "/src/bar", bar); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readBreakpointsStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wantStore := map[string][]*storedBreakpoint{"/src/foo": stored, "/src/bar": bar}; !reflect.DeepEqual(got, wantStore) {
+		t.Errorf("readBreakpointsStore() = %#v, want %#v", got, wantStore)
+	}
+
+	// clearing the all breakpoints removes the project
+	if err := writeBreakpointsStore(file, "/src/foo", nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err = readBreakpointsStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got["/src/foo"]; ok {
+		t.Errorf("readBreakpointsStore() has the cleared project: %#v", got)
+	}
+
+	// restored breakpoint
+	bp, spec := want[0].breakpoint()
+	if !bp.Tracepoint || !reflect.DeepEqual(bp.Variables, []string{"x"}) || spec.Log != "x is {x}" {
+		t.Errorf("breakpoint() = %#v, %#v", bp, spec)
+	}
+}
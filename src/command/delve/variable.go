@@ -0,0 +1,261 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	delveapi "github.com/derekparker/delve/service/api"
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/nvimutil"
+)
+
+// maxValueLen is the max length of the variable value in the locals tree.
+const maxValueLen = 80
+
+// varNode represents a variable node of the locals tree in the context buffer.
+type varNode struct {
+	Expr  string // the expression to evaluate the variable, or empty if not addressable
+	Path  string // the unique key of the node in the tree
+	Name  string
+	Depth int
+	Var   *delveapi.Variable
+}
+
+// expandable reports whether the node has the children.
+func (n *varNode) expandable() bool {
+	switch n.Var.Kind {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Ptr, reflect.Interface:
+		if n.Expr == "" {
+			return len(n.Var.Children) > 0
+		}
+		return len(n.Var.Children) > 0 || n.Var.Len > 0
+	default:
+		return false
+	}
+}
+
+// settable reports whether the node is the scalar variable that can be modified.
+func (n *varNode) settable() bool {
+	if n.Expr == "" || n.Var.Unreadable != "" {
+		return false
+	}
+	k := n.Var.Kind
+	return (k >= reflect.Bool && k <= reflect.Complex128) || k == reflect.String
+}
+
+// expandLoadConfig returns the LoadConfig of the expanding variable node, which loads
+// the one more nested level than loadConfig.
+func expandLoadConfig() delveapi.LoadConfig {
+	cfg := loadConfig()
+	cfg.MaxVariableRecurse++
+	return cfg
+}
+
+// loadVariable lazily loads the variable of expr on the current scope to expand the node.
+func (d *Delve) loadVariable(expr string) (*delveapi.Variable, error) {
+	v, err := d.client.EvalVariable(d.evalScope(), expr, expandLoadConfig())
+	return v, errors.WithStack(err)
+}
+
+// childNodes returns the children nodes of the parent node whose loaded variable is v.
+// The pointer and interface are transparent, such as the children of the pointed struct.
+func childNodes(parent *varNode, v *delveapi.Variable) []*varNode {
+	child := func(expr, name string, cv *delveapi.Variable) *varNode {
+		if parent.Expr == "" {
+			expr = ""
+		}
+		return &varNode{Expr: expr, Path: parent.Path + "\x00" + name, Name: name, Depth: parent.Depth + 1, Var: cv}
+	}
+
+	var nodes []*varNode
+	switch v.Kind {
+	case reflect.Ptr:
+		if len(v.Children) == 1 {
+			pointee := &v.Children[0]
+			deref := &varNode{Path: parent.Path, Name: parent.Name, Depth: parent.Depth, Var: pointee}
+			if parent.Expr != "" {
+				deref.Expr = "(*" + parent.Expr + ")"
+			}
+			if deref.expandable() {
+				return childNodes(deref, pointee)
+			}
+			return []*varNode{child(deref.Expr, "*"+parent.Name, pointee)}
+		}
+	case reflect.Interface:
+		if len(v.Children) == 1 {
+			return childNodes(parent, &v.Children[0])
+		}
+	case reflect.Struct:
+		for i := range v.Children {
+			f := &v.Children[i]
+			nodes = append(nodes, child(parent.Expr+"."+f.Name, f.Name, f))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Children {
+			name := fmt.Sprintf("[%d]", i)
+			nodes = append(nodes, child(parent.Expr+name, name, &v.Children[i]))
+		}
+	case reflect.Map:
+		for i := 0; i+1 < len(v.Children); i += 2 {
+			key, val := &v.Children[i], &v.Children[i+1]
+			name := "[" + key.SinglelineString() + "]"
+			n := child("", name, val)
+			if k := mapKeyExpr(key); k != "" && parent.Expr != "" {
+				n.Expr = parent.Expr + "[" + k + "]"
+			}
+			nodes = append(nodes, n)
+		}
+	}
+
+	return nodes
+}
+
+// mapKeyExpr returns the expression of the map key, or empty if the key is not the scalar.
+func mapKeyExpr(key *delveapi.Variable) string {
+	switch {
+	case key.Kind == reflect.String:
+		return strconv.Quote(key.Value)
+	case key.Kind >= reflect.Bool && key.Kind <= reflect.Float64:
+		return key.Value
+	default:
+		return ""
+	}
+}
+
+// formatVariable formats the locals tree line of n node.
+func formatVariable(n *varNode, expanded bool) []byte {
+	mark := " "
+	if n.expandable() {
+		mark = "\u25B6" // \u25B6: ▶
+		if expanded {
+			mark = "\u25BC" // \u25BC: ▼
+		}
+	}
+
+	value := n.Var.SinglelineString()
+	if n.Var.Unreadable != "" {
+		value = "<" + n.Var.Unreadable + ">"
+	}
+	if r := []rune(value); len(r) > maxValueLen {
+		value = string(r[:maxValueLen]) + "..."
+	}
+
+	return []byte(fmt.Sprintf("\t%s%s %s %s = %s", strings.Repeat("  ", n.Depth), mark, n.Name, n.Var.Type, value))
+}
+
+// renderVariables renders the nodes tree lines with the expanded nodes that keyed by node Path.
+// The children of the expanded node are lazily loaded by load.
+// It also returns the node of each line, or nil if the line is not a node.
+func renderVariables(nodes []*varNode, expanded map[string]bool, load func(expr string) (*delveapi.Variable, error)) ([][]byte, []*varNode) {
+	var lines [][]byte
+	var lineNodes []*varNode
+
+	var render func(nodes []*varNode)
+	render = func(nodes []*varNode) {
+		for _, n := range nodes {
+			isExpanded := expanded[n.Path] && n.expandable()
+			lines = append(lines, formatVariable(n, isExpanded))
+			lineNodes = append(lineNodes, n)
+			if !isExpanded {
+				continue
+			}
+
+			v := n.Var
+			if n.Expr != "" {
+				loaded, err := load(n.Expr)
+				if err != nil {
+					lines = append(lines, []byte(fmt.Sprintf("\t%s  <%v>", strings.Repeat("  ", n.Depth+1), errors.Cause(err))))
+					lineNodes = append(lineNodes, nil)
+					continue
+				}
+				v = loaded
+			}
+			render(childNodes(n, v))
+		}
+	}
+	render(nodes)
+
+	return lines, lineNodes
+}
+
+// ----------------------------------------------------------------------------
+// locals tree actions
+
+// toggleVariable expands or collapses the n node, and redraws the context buffer.
+func (d *Delve) toggleVariable(v *nvim.Nvim, n *varNode) error {
+	if !n.expandable() {
+		return nil
+	}
+	if d.debugState() == stateRunning {
+		return errRunning
+	}
+
+	d.varMu.Lock()
+	if d.expanded == nil {
+		d.expanded = make(map[string]bool)
+	}
+	if d.expanded[n.Path] {
+		delete(d.expanded, n.Path)
+	} else {
+		d.expanded[n.Path] = true
+	}
+	d.varMu.Unlock()
+
+	return d.refreshContext(v)
+}
+
+// promptVariable prompts the new value of the n node and sets it.
+func (d *Delve) promptVariable(v *nvim.Nvim, n *varNode) error {
+	if !n.settable() {
+		return errors.Errorf("cannot set %s: not a scalar variable", n.Name)
+	}
+
+	var value string
+	if err := v.Call("input", &value, fmt.Sprintf("(dlv) set %s = ", n.Expr), n.Var.Value); err != nil {
+		return nil // canceled
+	}
+	if value = strings.TrimSpace(value); value == "" {
+		return nil
+	}
+
+	return d.setVariable(v, n.Expr, value)
+}
+
+// ----------------------------------------------------------------------------
+// set
+
+func (d *Delve) cmdSet(v *nvim.Nvim, args []string) {
+	go func() {
+		if len(args) < 2 {
+			nvimutil.ErrorWrap(v, errors.New("usage: DlvSet {var} {value}"))
+			return
+		}
+		if err := d.setVariable(v, args[0], strings.Join(args[1:], " ")); err != nil {
+			nvimutil.ErrorWrap(v, err)
+		}
+	}()
+}
+
+// setVariable sets the value to the expr scalar variable on the current scope,
+// and redraws the context buffer.
+func (d *Delve) setVariable(v *nvim.Nvim, expr, value string) error {
+	if d.client == nil {
+		return errNotRunning
+	}
+	if d.debugState() == stateRunning {
+		return errRunning
+	}
+
+	if err := d.client.SetVariable(d.evalScope(), expr, value); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return d.refreshContext(v)
+}
@@ -0,0 +1,114 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package delve
+
+import (
+	"reflect"
+	"testing"
+
+	delveapi "github.com/derekparker/delve/service/api"
+	"github.com/pkg/errors"
+)
+
+func TestRenderVariables(t *testing.T) {
+	// the locals loaded with the default LoadConfig, the nested struct is not loaded yet
+	locals := []delveapi.Variable{
+		{Name: "i", Type: "int", Kind: reflect.Int, Value: "1"},
+		{Name: "t", Type: "main.T", Kind: reflect.Struct, Len: 2, Children: []delveapi.Variable{
+			{Name: "Name", Addr: 0xc000010000, Type: "string", Kind: reflect.String, Value: "foo", Len: 3},
+			{Name: "Inner", Type: "*main.Inner", Kind: reflect.Ptr, Children: []delveapi.Variable{
+				{Type: "main.Inner", Kind: reflect.Struct, Len: 1},
+			}},
+		}},
+		{Name: "m", Type: "map[string]int", Kind: reflect.Map, Len: 1},
+	}
+	loaded := map[string]*delveapi.Variable{
+		"t.Inner": {Name: "Inner", Type: "*main.Inner", Kind: reflect.Ptr, Children: []delveapi.Variable{
+			{Type: "main.Inner", Kind: reflect.Struct, Len: 1, Children: []delveapi.Variable{
+				{Name: "N", Type: "int", Kind: reflect.Int, Value: "2"},
+			}},
+		}},
+		"t": &locals[1],
+	}
+	load := func(expr string) (*delveapi.Variable, error) {
+		if v, ok := loaded[expr]; ok {
+			return v, nil
+		}
+		return nil, errors.Errorf("could not find %s", expr)
+	}
+
+	tests := []struct {
+		name      string
+		expanded  map[string]bool
+		want      []string
+		wantExprs []string
+	}{
+		{
+			name: "collapsed",
+			want: []string{
+				"\t  i int = 1",
+				"\t▶ t main.T = main.T {Name: \"foo\", Inner: *main.Inner nil}",
+				"\t▶ m map[string]int = map[string]int [...]",
+			},
+			wantExprs: []string{"i", "t", "m"},
+		},
+		{
+			name:     "expanded",
+			expanded: map[string]bool{"t": true, "t\x00Inner": true, "m": true},
+			want: []string{
+				"\t  i int = 1",
+				"\t▼ t main.T = main.T {Name: \"foo\", Inner: *main.Inner nil}",
+				"\t    Name string = \"foo\"",
+				"\t  ▼ Inner *main.Inner = *main.Inner nil",
+				"\t      N int = 2",
+				"\t▼ m map[string]int = map[string]int [...]",
+				"\t    <could not find m>",
+			},
+			wantExprs: []string{"i", "t", "t.Name", "t.Inner", "(*t.Inner).N", "m", ""},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			nodes := make([]*varNode, len(locals))
+			for i := range locals {
+				nodes[i] = &varNode{Expr: locals[i].Name, Path: locals[i].Name, Name: locals[i].Name, Var: &locals[i]}
+			}
+
+			lines, lineNodes := renderVariables(nodes, tt.expanded, load)
+			var got, gotExprs []string
+			for i, line := range lines {
+				got = append(got, string(line))
+				expr := ""
+				if lineNodes[i] != nil {
+					expr = lineNodes[i].Expr
+				}
+				gotExprs = append(gotExprs, expr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("renderVariables() lines = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(gotExprs, tt.wantExprs) {
+				t.Errorf("renderVariables() exprs = %q, want %q", gotExprs, tt.wantExprs)
+			}
+		})
+	}
+}
+
+func TestMapKeyExpr(t *testing.T) {
+	tests := []struct {
+		key  delveapi.Variable
+		want string
+	}{
+		{key: delveapi.Variable{Kind: reflect.String, Value: `a"b`}, want: `"a\"b"`},
+		{key: delveapi.Variable{Kind: reflect.Int, Value: "10"}, want: "10"},
+		{key: delveapi.Variable{Kind: reflect.Struct}, want: ""},
+	}
+	for _, tt := range tests {
+		if got := mapKeyExpr(&tt.key); got != tt.want {
+			t.Errorf("mapKeyExpr(%v) = %q, want %q", tt.key.Kind, got, tt.want)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/neovim/go-client/nvim"
 	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/buildctx"
 	"github.com/zchee/nvim-go/src/config"
 	"github.com/zchee/nvim-go/src/nvimutil"
 	"golang.org/x/tools/imports"
@@ -22,11 +23,23 @@ var importsOptions = imports.Options{
 	TabWidth:  8,
 }
 
-func (c *Command) cmdFmt(dir string) {
-	delete(c.buildContext.Errlist, "Fmt")
-	err := c.Fmt(dir)
+// cmdFmtEval struct type for Eval of Gofmt command.
+type cmdFmtEval struct {
+	Dir   string `msgpack:",array"`
+	BufNr int
+}
+
+func (c *Command) cmdFmt(eval *cmdFmtEval) {
+	bctx := c.buildContexts.Context(eval.BufNr, eval.Dir)
+	delete(bctx.Errlist, "Fmt")
+	c.errs.Delete("Fmt")
+	err := c.Fmt(bctx, eval.Dir)
 
 	switch e := err.(type) {
+	case nil:
+		errlist := c.Errors()
+		errlist["Fmt"] = nil // clears the diagnostics
+		nvimutil.ErrorList(c.Nvim, errlist, true)
 	case error:
 		nvimutil.ErrorWrap(c.Nvim, e)
 	case []*nvim.QuickfixError:
@@ -41,9 +54,9 @@ func (c *Command) cmdFmt(dir string) {
 	}
 }
 
-// Fmt format to the current buffer source uses gofmt behavior.
-func (c *Command) Fmt(dir string) interface{} {
-	b := nvim.Buffer(c.buildContext.BufNr)
+// Fmt format to the bctx buffer source uses gofmt behavior.
+func (c *Command) Fmt(bctx *buildctx.Context, dir string) interface{} {
+	b := nvim.Buffer(bctx.BufNr)
 	in, err := c.Nvim.BufferLines(b, 0, -1, true)
 	if err != nil {
 		return errors.WithStack(err)
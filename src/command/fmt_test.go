@@ -92,8 +92,8 @@ func TestCommand_Fmt(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			c := NewCommand(tt.fields.ctx, tt.fields.Nvim, tt.fields.buildctxt)
-			err := c.Fmt(tt.args.dir)
+			c := NewCommand(tt.fields.ctx, tt.fields.Nvim, buildctx.NewRegistry())
+			err := c.Fmt(tt.fields.buildctxt, tt.args.dir)
 			switch e := err.(type) {
 			case error:
 				t.Errorf("%v. Commands.Fmt(%v), err %v wantErr %v", tt.name, tt.args.dir, e, tt.wantErr)
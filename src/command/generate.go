@@ -16,22 +16,30 @@ import (
 	"github.com/cweill/gotests/gotests/process"
 	"github.com/neovim/go-client/nvim"
 	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/buildctx"
 	"github.com/zchee/nvim-go/src/config"
 	"github.com/zchee/nvim-go/src/nvimutil"
 )
 
 var generateFuncRe = regexp.MustCompile(`(?m)^func\s(?:\(\w\s[[:graph:]]+\)\s)?([\w]+)\(`)
 
-func (c *Command) cmdGenerateTest(args []string, ranges [2]int, bang bool, dir string) {
-	go c.GenerateTest(args, ranges, bang, dir)
+// cmdGenerateTestEval struct type for Eval of GoGenerateTest command.
+type cmdGenerateTestEval struct {
+	Dir   string `msgpack:",array"`
+	BufNr int
 }
 
-// GenerateTest generates the test files based by current buffer or args files
+func (c *Command) cmdGenerateTest(args []string, ranges [2]int, bang bool, eval *cmdGenerateTestEval) {
+	bctx := c.buildContexts.Context(eval.BufNr, eval.Dir)
+	go c.GenerateTest(bctx, args, ranges, bang, eval.Dir)
+}
+
+// GenerateTest generates the test files based by bctx buffer or args files
 // functions.
-func (c *Command) GenerateTest(args []string, ranges [2]int, bang bool, dir string) error {
+func (c *Command) GenerateTest(bctx *buildctx.Context, args []string, ranges [2]int, bang bool, dir string) error {
 	defer nvimutil.Profile(c.ctx, time.Now(), "GenerateTest")
 
-	b := nvim.Buffer(c.buildContext.BufNr)
+	b := nvim.Buffer(bctx.BufNr)
 	if len(args) == 0 {
 		f, err := c.Nvim.BufferName(b)
 		if err != nil {
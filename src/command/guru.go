@@ -11,7 +11,6 @@ package command
 import (
 	"bytes"
 	"fmt"
-	"go/build"
 	"go/token"
 	"path/filepath"
 	"runtime"
@@ -20,6 +19,7 @@ import (
 
 	"github.com/neovim/go-client/nvim"
 	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/buildctx"
 	"github.com/zchee/nvim-go/src/config"
 	"github.com/zchee/nvim-go/src/internal/guru"
 	"github.com/zchee/nvim-go/src/logger"
@@ -35,10 +35,13 @@ type funcGuruEval struct {
 	File     string
 	Modified int
 	Offset   int
+	BufNr    int
+	WinID    int
 }
 
 func (c *Command) funcGuru(args []string, eval *funcGuruEval) {
-	err := c.Guru(args, eval)
+	bctx := c.buildContexts.Context(eval.BufNr, filepath.Dir(eval.File))
+	err := c.Guru(bctx, args, eval)
 
 	switch e := err.(type) {
 	case error:
@@ -56,7 +59,7 @@ func (c *Command) funcGuru(args []string, eval *funcGuruEval) {
 }
 
 // Guru go source analysis and output result to the quickfix or locationlist.
-func (c *Command) Guru(args []string, eval *funcGuruEval) interface{} {
+func (c *Command) Guru(bctx *buildctx.Context, args []string, eval *funcGuruEval) interface{} {
 	log := logger.FromContext(c.ctx).Named("Guru").With(zap.Any("funcGuruEval", eval))
 
 	mode := args[0]
@@ -77,11 +80,12 @@ func (c *Command) Guru(args []string, eval *funcGuruEval) interface{} {
 		return nil
 	}()
 
-	b := nvim.Buffer(c.buildContext.BufNr)
-	w := nvim.Window(c.buildContext.WinID)
+	b := nvim.Buffer(bctx.BufNr)
+	w := nvim.Window(eval.WinID)
 	batch := c.Nvim.NewBatch()
 
-	guruContext := &build.Default
+	buildContext := bctx.BuildContext
+	guruContext := &buildContext
 
 	// https://github.com/golang/tools/blob/master/cmd/guru/main.go
 	if eval.Modified != 0 {
@@ -127,7 +131,7 @@ func (c *Command) Guru(args []string, eval *funcGuruEval) interface{} {
 	}
 
 	var scopes []string
-	switch c.buildContext.Build.Tool {
+	switch bctx.Build.Tool {
 	case "go":
 		root := pathutil.FindVCSRoot(eval.File)
 		root, _ = filepath.Abs(root)
@@ -135,8 +139,14 @@ func (c *Command) Guru(args []string, eval *funcGuruEval) interface{} {
 		if vendorDir := filepath.Join(root, "vendor"); pathutil.IsDirExist(vendorDir) {
 			scopes = append(scopes, "-"+pathutil.ToWildcard(pathutil.TrimGoPath(vendorDir)))
 		}
+	case "mod":
+		modPath := bctx.Build.ModulePath
+		scopes = []string{modPath + "/..."}
+		if vendorDir := filepath.Join(bctx.Build.ModuleRoot, "vendor"); pathutil.IsDirExist(vendorDir) {
+			scopes = append(scopes, "-"+modPath+"/vendor/...")
+		}
 	case "gb":
-		root := c.buildContext.Build.ProjectRoot
+		root := bctx.Build.ProjectRoot
 		var err error
 		scopes, err = pathutil.GbPackages(root)
 		if err != nil {
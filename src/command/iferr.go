@@ -8,7 +8,6 @@ import (
 	"bytes"
 	"fmt"
 	"go/ast"
-	"go/build"
 	"go/format"
 	"go/parser"
 	"go/token"
@@ -20,29 +19,38 @@ import (
 	astmanip "github.com/motemen/go-astmanip"
 	"github.com/neovim/go-client/nvim"
 	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/buildctx"
 	"github.com/zchee/nvim-go/src/nvimutil"
 	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/go/loader"
 )
 
-func (c *Command) cmdIferr(file string) {
-	go c.Iferr(file)
+// cmdIferrEval struct type for Eval of GoIferr command.
+type cmdIferrEval struct {
+	File  string `msgpack:",array"`
+	BufNr int
 }
 
-// Iferr automatically insert 'if err' Go idiom by parse the current buffer's Go abstract syntax tree(AST).
-func (c *Command) Iferr(file string) error {
+func (c *Command) cmdIferr(eval *cmdIferrEval) {
+	bctx := c.buildContexts.Context(eval.BufNr, filepath.Dir(eval.File))
+	go c.Iferr(bctx, eval.File)
+}
+
+// Iferr automatically insert 'if err' Go idiom by parse the bctx buffer's Go abstract syntax tree(AST).
+func (c *Command) Iferr(bctx *buildctx.Context, file string) error {
 	defer nvimutil.Profile(c.ctx, time.Now(), "GoIferr")
 
-	b := nvim.Buffer(c.buildContext.BufNr)
+	b := nvim.Buffer(bctx.BufNr)
 	buflines, err := c.Nvim.BufferLines(b, 0, -1, true)
 	if err != nil {
 		return nvimutil.ErrorWrap(c.Nvim, errors.WithStack(err))
 	}
 
+	buildContext := bctx.BuildContext
 	conf := loader.Config{
 		ParserMode:  parser.ParseComments,
 		TypeChecker: types.Config{FakeImportC: true, DisableUnusedImportCheck: true},
-		Build:       &build.Default,
+		Build:       &buildContext,
 		Cwd:         filepath.Dir(file),
 		AllowErrors: true,
 	}
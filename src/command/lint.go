@@ -18,22 +18,30 @@ import (
 	"github.com/golang/lint"
 	"github.com/neovim/go-client/nvim"
 	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/buildctx"
 	"github.com/zchee/nvim-go/src/config"
 	"github.com/zchee/nvim-go/src/nvimutil"
 	"github.com/zchee/nvim-go/src/pathutil"
 )
 
-func (c *Command) cmdLint(v *nvim.Nvim, args []string, file string) {
+// cmdLintEval struct type for Eval of Golint command.
+type cmdLintEval struct {
+	File  string `msgpack:",array"`
+	BufNr int
+}
+
+func (c *Command) cmdLint(v *nvim.Nvim, args []string, eval *cmdLintEval) {
+	bctx := c.buildContexts.Context(eval.BufNr, filepath.Dir(eval.File))
 	// Cleanup error list
-	delete(c.buildContext.Errlist, "Lint")
+	delete(bctx.Errlist, "Lint")
 
 	go func() {
-		errlist, err := c.Lint(args, file)
+		errlist, err := c.Lint(bctx, args, eval.File)
 		if err != nil {
 			nvimutil.ErrorWrap(c.Nvim, err)
 		}
-		c.buildContext.Errlist["Lint"] = errlist
-		nvimutil.ErrorList(c.Nvim, c.buildContext.Errlist, true)
+		bctx.Errlist["Lint"] = errlist
+		nvimutil.ErrorList(c.Nvim, bctx.Errlist, true)
 	}()
 }
 
@@ -46,31 +54,46 @@ const (
 
 // Lint lints a go source file. The argument is a filename or directory path.
 // TODO(zchee): Support go packages.
-func (c *Command) Lint(args []string, file string) ([]*nvim.QuickfixError, error) {
+func (c *Command) Lint(bctx *buildctx.Context, args []string, file string) ([]*nvim.QuickfixError, error) {
 	defer nvimutil.Profile(c.ctx, time.Now(), "Lint")
 
 	var errlist []*nvim.QuickfixError
 	var err error
+	buildContext := bctx.BuildContext
 
 	switch len(args) {
 	case 0:
 		switch lintMode(config.GolintMode) {
 		case current:
-			errlist, err = c.lintDir(filepath.Dir(file))
+			errlist, err = c.lintDir(&buildContext, filepath.Dir(file))
 		case root:
 			var rootDir string
-			switch c.buildContext.Build.Tool {
+			switch bctx.Build.Tool {
 			case "go":
-				root, err := pathutil.PackageID(c.buildContext.Build.ProjectRoot)
+				root, err := pathutil.PackageIDContext(buildContext, bctx.Build.ProjectRoot)
 				if err != nil {
 					return nil, errors.WithStack(err)
 				}
 				rootDir = root
 			case "gb":
-				rootDir = filepath.Base(c.buildContext.Build.ProjectRoot)
+				rootDir = filepath.Base(bctx.Build.ProjectRoot)
+			case "mod":
+				// module packages are not in the GOPATH, so lint the each package directories of module root
+				pkgs, err := pathutil.FindAllPackage(bctx.Build.ModuleRoot, buildContext, nil, pathutil.ModeExcludeVendor)
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+				for _, pkg := range pkgs {
+					errors, err := c.lintImportedPackage(pkg, nil)
+					if err != nil {
+						return nil, err
+					}
+					errlist = append(errlist, errors...)
+				}
+				return errlist, nil
 			}
-			for _, pkgname := range importPaths([]string{rootDir + "/..."}) {
-				errors, err := c.lintPackage(pkgname)
+			for _, pkgname := range importPaths(&buildContext, []string{rootDir + "/..."}) {
+				errors, err := c.lintPackage(&buildContext, pkgname)
 				if err != nil {
 					return nil, err
 				}
@@ -84,12 +107,12 @@ func (c *Command) Lint(args []string, file string) ([]*nvim.QuickfixError, error
 		}
 		switch {
 		case pathutil.IsDir(path):
-			errlist, err = c.lintDir(path)
+			errlist, err = c.lintDir(&buildContext, path)
 		case pathutil.IsExist(path):
 			errlist, err = c.lintFiles(path)
 		default:
-			for _, pkgname := range importPaths(args) {
-				errlist, err = c.lintPackage(pkgname)
+			for _, pkgname := range importPaths(&buildContext, args) {
+				errlist, err = c.lintPackage(&buildContext, pkgname)
 			}
 		}
 	default: // more than 2
@@ -172,13 +195,13 @@ func contain(s string, ignore []string) bool {
 	return false
 }
 
-func (c *Command) lintDir(dirname string) ([]*nvim.QuickfixError, error) {
-	pkg, err := build.ImportDir(dirname, 0)
+func (c *Command) lintDir(ctxt *build.Context, dirname string) ([]*nvim.QuickfixError, error) {
+	pkg, err := ctxt.ImportDir(dirname, 0)
 	return c.lintImportedPackage(pkg, err)
 }
 
-func (c *Command) lintPackage(pkgname string) ([]*nvim.QuickfixError, error) {
-	pkg, err := build.Import(pkgname, ".", 0)
+func (c *Command) lintPackage(ctxt *build.Context, pkgname string) ([]*nvim.QuickfixError, error) {
+	pkg, err := ctxt.Import(pkgname, ".", 0)
 	return c.lintImportedPackage(pkg, err)
 }
 
@@ -215,7 +238,7 @@ var (
 
 // importPathsNoDotExpansion returns the import paths to use for the given
 // command line, but it does no ... expansion.
-func importPathsNoDotExpansion(args []string) []string {
+func importPathsNoDotExpansion(ctxt *build.Context, args []string) []string {
 	if len(args) == 0 {
 		return []string{"."}
 	}
@@ -238,7 +261,7 @@ func importPathsNoDotExpansion(args []string) []string {
 			a = pathpkg.Clean(a)
 		}
 		if a == "all" || a == "std" {
-			out = append(out, allPackages(a)...)
+			out = append(out, allPackages(ctxt, a)...)
 			continue
 		}
 		out = append(out, a)
@@ -247,15 +270,15 @@ func importPathsNoDotExpansion(args []string) []string {
 }
 
 // importPaths returns the import paths to use for the given command line.
-func importPaths(args []string) []string {
-	args = importPathsNoDotExpansion(args)
+func importPaths(ctxt *build.Context, args []string) []string {
+	args = importPathsNoDotExpansion(ctxt, args)
 	var out []string
 	for _, a := range args {
 		if strings.Contains(a, "...") {
 			if build.IsLocalImport(a) {
 				out = append(out, allPackagesInFS(a)...)
 			} else {
-				out = append(out, allPackages(a)...)
+				out = append(out, allPackages(ctxt, a)...)
 			}
 			continue
 		}
@@ -316,15 +339,15 @@ func treeCanMatchPattern(pattern string) func(name string) bool {
 // under the $GOPATH directories and $GOROOT matching pattern.
 // The pattern is either "all" (all packages), "std" (standard packages)
 // or a path including "...".
-func allPackages(pattern string) []string {
-	pkgs := matchPackages(pattern)
+func allPackages(ctxt *build.Context, pattern string) []string {
+	pkgs := matchPackages(ctxt, pattern)
 	if len(pkgs) == 0 {
 		// fmt.Fprintf(os.Stderr, "warning: %q matched no packages\n", pattern)
 	}
 	return pkgs
 }
 
-func matchPackages(pattern string) []string {
+func matchPackages(ctxt *build.Context, pattern string) []string {
 	match := func(string) bool { return true }
 	treeCanMatch := func(string) bool { return true }
 	if pattern != "all" && pattern != "std" {
@@ -335,7 +358,7 @@ func matchPackages(pattern string) []string {
 	have := map[string]bool{
 		"builtin": true, // ignore pseudo-package that exists only for documentation
 	}
-	buildContext := build.Default
+	buildContext := *ctxt
 	if !buildContext.CgoEnabled {
 		have["runtime/cgo"] = true // ignore during walk
 	}
@@ -81,10 +81,10 @@ func TestCommand_Lint(t *testing.T) {
 			// TODO(zchee): fix lint behaiviour
 			t.Skipf("TODO(zchee): fix lint behaiviour")
 			t.Parallel()
-			c := NewCommand(tt.fields.ctx, tt.fields.Nvim, tt.fields.buildctxt)
+			c := NewCommand(tt.fields.ctx, tt.fields.Nvim, buildctx.NewRegistry())
 			c.Nvim.SetCurrentDirectory(filepath.Dir(tt.args.file))
 
-			got, err := c.Lint(tt.args.args, tt.args.file)
+			got, err := c.Lint(tt.fields.buildctxt, tt.args.args, tt.args.file)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Command.Lint(%v, %v) error = %v, wantErr %v", tt.args.args, tt.args.file, err, tt.wantErr)
 				return
@@ -175,7 +175,7 @@ func TestCommand_cmdLintComplete(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			c := NewCommand(tt.fields.ctx, tt.fields.Nvim, tt.fields.buildctxt)
+			c := NewCommand(tt.fields.ctx, tt.fields.Nvim, buildctx.NewRegistry())
 
 			gotFilelist, err := c.cmdLintComplete(tt.args.a, tt.args.cwd)
 			if (err != nil) != tt.wantErr {
@@ -7,19 +7,30 @@ package command
 import (
 	"encoding/json"
 	"os/exec"
+	"path/filepath"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/neovim/go-client/nvim"
 	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/buildctx"
 	"github.com/zchee/nvim-go/src/config"
 	"github.com/zchee/nvim-go/src/nvimutil"
 	"github.com/zchee/nvim-go/src/pathutil"
 )
 
-func (c *Command) cmdMetalinter(cwd string) {
-	go c.Metalinter(cwd)
+// CmdMetalinterEval struct type for Eval of Gometalinter command.
+type CmdMetalinterEval struct {
+	Cwd   string `msgpack:",array"`
+	File  string
+	BufNr int
+	WinID int
+}
+
+func (c *Command) cmdMetalinter(eval *CmdMetalinterEval) {
+	bctx := c.buildContexts.Context(eval.BufNr, filepath.Dir(eval.File))
+	go c.Metalinter(bctx, eval)
 }
 
 type metalinterResult struct {
@@ -32,18 +43,17 @@ type metalinterResult struct {
 }
 
 // Metalinter lint the Go sources from current buffer's package use gometalinter tool.
-func (c *Command) Metalinter(cwd string) error {
+func (c *Command) Metalinter(bctx *buildctx.Context, eval *CmdMetalinterEval) error {
 	defer nvimutil.Profile(c.ctx, time.Now(), "GoMetaLinter")
 
 	var loclist []*nvim.QuickfixError
-	w := nvim.Window(c.buildContext.WinID)
 
 	var args []string
-	switch c.buildContext.Build.Tool {
-	case "go":
-		args = append(args, cwd+"/...")
+	switch bctx.Build.Tool {
+	case "go", "mod":
+		args = append(args, eval.Cwd+"/...")
 	case "gb":
-		args = append(args, c.buildContext.Build.ProjectRoot+"/...")
+		args = append(args, bctx.Build.ProjectRoot+"/...")
 	}
 	args = append(args, []string{"--json", "--disable-all", "--deadline", config.MetalinterDeadline}...)
 
@@ -57,6 +67,7 @@ func (c *Command) Metalinter(cwd string) error {
 	}
 
 	cmd := exec.Command("gometalinter", args...)
+	cmd.Env = bctx.Environ()
 	stdout, err := cmd.Output()
 	cmd.Run()
 
@@ -71,7 +82,7 @@ func (c *Command) Metalinter(cwd string) error {
 
 	for _, r := range result {
 		loclist = append(loclist, &nvim.QuickfixError{
-			FileName: pathutil.Rel(r.Path, cwd),
+			FileName: pathutil.Rel(r.Path, eval.Cwd),
 			LNum:     r.Line,
 			Col:      r.Col,
 			Text:     r.Linter + ": " + r.Message,
@@ -79,10 +90,17 @@ func (c *Command) Metalinter(cwd string) error {
 		})
 	}
 
-	if err := nvimutil.SetLoclist(c.Nvim, loclist); err != nil {
+	c.errs.Delete("Metalinter")
+	if len(loclist) > 0 {
+		c.errs.Store("Metalinter", loclist)
+	}
+	errmap := c.Errors()
+	errmap["Metalinter"] = loclist // clears the diagnostics if no errors
+	if err := nvimutil.ErrorList(c.Nvim, errmap, true); err != nil {
 		return nvimutil.ErrorWrap(c.Nvim, errors.WithStack(err))
 	}
-	return nvimutil.OpenLoclist(c.Nvim, w, loclist, true)
+
+	return nil
 }
 
 type byPath []metalinterResult
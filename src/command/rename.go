@@ -6,13 +6,14 @@ package command
 
 import (
 	"fmt"
-	"go/build"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/neovim/go-client/nvim"
 	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/buildctx"
 	"github.com/zchee/nvim-go/src/config"
 	"github.com/zchee/nvim-go/src/nvimutil"
 	"golang.org/x/tools/refactor/rename"
@@ -24,28 +25,31 @@ type cmdRenameEval struct {
 	Cwd        string `msgpack:",array"`
 	File       string
 	RenameFrom string
+	BufNr      int
+	WinID      int
 }
 
 func (c *Command) cmdRename(args []string, bang bool, eval *cmdRenameEval) {
 	go func() {
-		err := c.Rename(args, bang, eval)
+		bctx := c.buildContexts.Context(eval.BufNr, filepath.Dir(eval.File))
+		err := c.Rename(bctx, args, bang, eval)
 
 		switch e := err.(type) {
 		case error:
 			nvimutil.ErrorWrap(c.Nvim, e)
 		case []*nvim.QuickfixError:
-			c.buildContext.Errlist["Rename"] = e
-			nvimutil.ErrorList(c.Nvim, c.buildContext.Errlist, true)
+			bctx.Errlist["Rename"] = e
+			nvimutil.ErrorList(c.Nvim, bctx.Errlist, true)
 		}
 	}()
 }
 
 // Rename rename the current cursor word use golang.org/x/tools/refactor/rename.
-func (c *Command) Rename(args []string, bang bool, eval *cmdRenameEval) interface{} {
+func (c *Command) Rename(bctx *buildctx.Context, args []string, bang bool, eval *cmdRenameEval) interface{} {
 	defer nvimutil.Profile(c.ctx, time.Now(), "GoRename")
 
-	b := nvim.Buffer(c.buildContext.BufNr)
-	w := nvim.Window(c.buildContext.WinID)
+	b := nvim.Buffer(bctx.BufNr)
+	w := nvim.Window(eval.WinID)
 
 	offset, err := nvimutil.ByteOffset(c.Nvim, b, w)
 	if err != nil {
@@ -95,14 +99,15 @@ func (c *Command) Rename(args []string, bang bool, eval *cmdRenameEval) interfac
 	}()
 
 	// TODO(zchee): reached race limit, dying when race build
-	if err := rename.Main(&build.Default, pos, "", renameTo); err != nil {
+	buildContext := bctx.BuildContext
+	if err := rename.Main(&buildContext, pos, "", renameTo); err != nil {
 		write.Close()
 		renameErr, err := ioutil.ReadAll(read)
 		if err != nil {
 			return errors.WithStack(err)
 		}
 
-		loclist, _ := nvimutil.ParseError(renameErr, eval.Cwd, &c.buildContext.Build, nil)
+		loclist, _ := nvimutil.ParseError(renameErr, eval.Cwd, &bctx.Build, nil)
 		nvimutil.SetLoclist(c.Nvim, loclist)
 		nvimutil.OpenLoclist(c.Nvim, w, loclist, true)
 
@@ -7,7 +7,6 @@ package command
 import (
 	"fmt"
 	"go/ast"
-	"go/build"
 	"go/parser"
 	"go/token"
 	"log"
@@ -18,7 +17,9 @@ import (
 
 	"github.com/neovim/go-client/nvim"
 	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/buildctx"
 	"github.com/zchee/nvim-go/src/config"
+	"github.com/zchee/nvim-go/src/internal/gotest"
 	"github.com/zchee/nvim-go/src/nvimutil"
 	"github.com/zchee/nvim-go/src/pathutil"
 	"golang.org/x/tools/go/ast/astutil"
@@ -27,52 +28,116 @@ import (
 // ----------------------------------------------------------------------------
 // GoTest
 
-func (c *Command) cmdTest(args []string, dir string) {
-	go c.Test(args, dir)
+// cmdTestEval struct type for Eval of Gotest command.
+type cmdTestEval struct {
+	Dir   string `msgpack:",array"`
+	BufNr int
+}
+
+func (c *Command) cmdTest(args []string, eval *cmdTestEval) {
+	bctx := c.buildContexts.Context(eval.BufNr, eval.Dir)
+	go c.Test(bctx, args, eval.Dir)
 }
 
 // testTerm cache nvimutil.Terminal use global variable.
 var testTerm *nvimutil.Terminal
 
 // Test run the package test command use compile tool that determined from
-// the directory structure.
-func (c *Command) Test(args []string, dir string) error {
+// the directory structure of bctx.
+func (c *Command) Test(bctx *buildctx.Context, args []string, dir string) error {
 	defer nvimutil.Profile(c.ctx, time.Now(), "GoTest")
 
-	cmd := []string{c.buildContext.Build.Tool, "test", strings.Join(config.TestFlags, " ")}
+	cmd := []string{bctx.Build.Cmd(), "test"}
+	cmd = append(cmd, config.TestFlags...)
 	if len(args) > 0 {
 		cmd = append(cmd, args...)
 	}
 
 	var testPkgs []string
+	pkgDirs := make(map[string]string) // map[importPath]dir
 	if config.TestAll {
-		switch c.buildContext.Build.Tool {
-		case "go":
-			pkgs, err := pathutil.FindAllPackage(dir, build.Default, nil, pathutil.ModeExcludeVendor)
-			if err != nil {
-				return errors.WithStack(err)
-			}
-			for _, p := range pkgs {
-				testPkgs = append(testPkgs, pathutil.TrimGoPath(p.Dir))
-			}
-		case "gb":
-			// nothing to do
+		var err error
+		testPkgs, pkgDirs, err = testPackages(bctx, dir)
+		if err != nil {
+			return errors.WithStack(err)
 		}
 	} else {
-		pkgs, err := pathutil.PackageID(dir)
+		pkg, err := pathutil.PackageIDContext(bctx.BuildContext, dir)
 		if err != nil {
 			return errors.WithStack(err)
 		}
-		testPkgs = append(testPkgs, pkgs)
+		testPkgs = append(testPkgs, pkg)
+		pkgDirs[pkg] = dir
 	}
 
 	cmd = append(cmd, testPkgs...)
+
+	return c.runTest(bctx, cmd, dir, pkgDirs)
+}
+
+// testPackages returns the import paths of the all packages under the root
+// directory, and the map of the import path and directory.
+// testPackages returns nothing if the compile tool is gb, because gb test
+// runs all packages by default.
+func testPackages(bctx *buildctx.Context, root string) ([]string, map[string]string, error) {
+	var importPaths []string
+	pkgDirs := make(map[string]string)
+
+	switch bctx.Build.Tool {
+	case "go":
+		pkgs, err := pathutil.FindAllPackage(root, bctx.BuildContext, nil, pathutil.ModeExcludeVendor)
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+		for _, p := range pkgs {
+			importPath := pathutil.TrimGoPath(p.Dir)
+			importPaths = append(importPaths, importPath)
+			pkgDirs[importPath] = p.Dir
+		}
+	case "mod":
+		pkgs, err := pathutil.FindAllPackage(root, bctx.BuildContext, nil, pathutil.ModeExcludeVendor)
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+		for _, p := range pkgs {
+			importPath, err := pathutil.ModuleImportPath(bctx.Build.ModuleRoot, bctx.Build.ModulePath, p.Dir)
+			if err != nil {
+				return nil, nil, errors.WithStack(err)
+			}
+			importPaths = append(importPaths, importPath)
+			pkgDirs[importPath] = p.Dir
+		}
+	case "gb":
+		// nothing to do
+	}
+
+	return importPaths, pkgDirs, nil
+}
+
+// runTest runs the cmd test command on the terminal, or collects the
+// structured results if config.TestJSON is enabled.
+// pkgDirs is the map of the import path and directory of the test packages.
+func (c *Command) runTest(bctx *buildctx.Context, cmd []string, dir string, pkgDirs map[string]string) error {
+	// gb does not support the -json flag
+	if config.TestJSON && bctx.Build.Tool != "gb" {
+		return c.runTestJSON(bctx, cmd, dir, pkgDirs)
+	}
+	return c.runTestTerm(bctx, cmd, dir, pkgDirs)
+}
+
+// runTestTerm runs the cmd test command on the "__GO_TEST__" terminal.
+func (c *Command) runTestTerm(bctx *buildctx.Context, cmd []string, dir string, pkgDirs map[string]string) error {
 	log.Println(cmd)
 
+	// the terminal output is parsed by GoTestFailed
+	c.testMu.Lock()
+	c.testTermDirs = pkgDirs
+	c.testMu.Unlock()
+
 	if testTerm == nil {
 		testTerm = nvimutil.NewTerminal(c.Nvim, "__GO_TEST__", cmd, config.TerminalMode)
-		testTerm.Dir = pathutil.FindVCSRoot(dir)
 	}
+	testTerm.Dir = testRunDir(bctx, dir)
 
 	if err := testTerm.Run(cmd); err != nil {
 		return nvimutil.ErrorWrap(c.Nvim, errors.WithStack(err))
@@ -81,6 +146,182 @@ func (c *Command) Test(args []string, dir string) error {
 	return nil
 }
 
+// testRunDir returns the working directory of the test command.
+func testRunDir(bctx *buildctx.Context, dir string) string {
+	if bctx.Build.Tool == "mod" {
+		// go test must be run inside the module in module mode
+		return bctx.Build.ModuleRoot
+	}
+	return pathutil.FindVCSRoot(dir)
+}
+
+// ----------------------------------------------------------------------------
+// GoTestFailed
+
+func (c *Command) cmdTestFailed(eval *cmdTestEval) {
+	bctx := c.buildContexts.Context(eval.BufNr, eval.Dir)
+	go func() {
+		if err := c.TestFailed(bctx); err != nil {
+			nvimutil.ErrorWrap(c.Nvim, err)
+		}
+	}()
+}
+
+// TestFailed reruns only the failed tests of the last test results for each
+// package. The failed tests are parsed from the "__GO_TEST__" terminal output
+// if config.TestJSON is disabled.
+func (c *Command) TestFailed(bctx *buildctx.Context) error {
+	defer nvimutil.Profile(c.ctx, time.Now(), "GoTestFailed")
+
+	if !config.TestJSON || bctx.Build.Tool == "gb" {
+		return c.testFailedTerm(bctx)
+	}
+
+	c.testMu.Lock()
+	report := c.testReport
+	c.testMu.Unlock()
+
+	if report == nil || len(report.failedPackages()) == 0 {
+		return errors.New("not found the failed tests of the last test run")
+	}
+
+	return c.testFailed(bctx, report)
+}
+
+// testFailedTerm reruns the failed tests of the "__GO_TEST__" terminal output
+// on the terminal.
+func (c *Command) testFailedTerm(bctx *buildctx.Context) error {
+	if testTerm == nil || testTerm.Buffer == nil || !nvimutil.IsBufferValid(c.Nvim, testTerm.Buffer.Buffer()) {
+		return errors.New("not found the failed tests of the last test run")
+	}
+	buf, err := c.Nvim.BufferLines(testTerm.Buffer.Buffer(), 0, -1, true)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	lines := make([]string, len(buf))
+	for i, l := range buf {
+		lines[i] = string(l)
+	}
+
+	c.testMu.Lock()
+	pkgDirs := c.testTermDirs
+	c.testMu.Unlock()
+
+	report := parseTestOutput(lines)
+	pkgs := report.failedPackages()
+	if len(pkgs) == 0 {
+		return errors.New("not found the failed tests of the last test run")
+	}
+
+	// runs the failed tests of all packages at once since the terminal runs
+	// the one command
+	var dir string
+	runPkgs := pkgs
+	if bctx.Build.Tool == "gb" {
+		// gb test does not take the import paths of the go test output
+		runPkgs = nil
+	}
+	for _, pkg := range pkgs {
+		if d, ok := pkgDirs[pkg]; ok && dir == "" {
+			dir = d
+		}
+	}
+	if dir == "" {
+		dir = bctx.Build.ProjectRoot
+	}
+	args := []string{"-run", failedRunPattern(report, "")}
+
+	return c.runTestTerm(bctx, testCmd(bctx, args, runPkgs...), dir, pkgDirs)
+}
+
+var (
+	// testFailRe matches the failed test line such as "--- FAIL: TestFoo (0.00s)".
+	testFailRe = regexp.MustCompile(`^\s*--- FAIL: (\S+)`)
+	// testPkgRe matches the package result line such as "FAIL	example.com/foo	0.01s".
+	// The terminal buffer expands the tabs to spaces.
+	testPkgRe = regexp.MustCompile(`^(ok|FAIL)\s+(\S+)`)
+)
+
+// parseTestOutput parses the failed tests from the plain "go test" output lines.
+// The failed tests are assigned to the package of the following package
+// result line.
+func parseTestOutput(lines []string) *testReport {
+	report := newTestReport()
+
+	var failed []string
+	for _, line := range lines {
+		if m := testFailRe.FindStringSubmatch(line); m != nil {
+			failed = append(failed, m[1])
+			continue
+		}
+		m := testPkgRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if m[1] == "FAIL" {
+			for _, test := range failed {
+				report.add(&testEvent{Action: testFail, Package: m[2], Test: test})
+			}
+			report.add(&testEvent{Action: testFail, Package: m[2]})
+		}
+		failed = nil
+	}
+
+	return report
+}
+
+// ----------------------------------------------------------------------------
+// GoTestFunc
+
+// cmdTestFuncEval struct type for Eval of GoTestFunc command.
+type cmdTestFuncEval struct {
+	Dir    string `msgpack:",array"`
+	File   string
+	Offset int
+	BufNr  int
+}
+
+func (c *Command) cmdTestFunc(args []string, eval *cmdTestFuncEval) {
+	bctx := c.buildContexts.Context(eval.BufNr, eval.Dir)
+	go c.TestFunc(bctx, args, eval)
+}
+
+// TestFunc runs the Test, Benchmark, Example function or subtest that encloses
+// the current cursor position.
+func (c *Command) TestFunc(bctx *buildctx.Context, args []string, eval *cmdTestFuncEval) error {
+	defer nvimutil.Profile(c.ctx, time.Now(), "GoTestFunc")
+
+	buf, err := c.Nvim.BufferLines(nvim.Buffer(bctx.BufNr), 0, -1, true)
+	if err != nil {
+		return nvimutil.ErrorWrap(c.Nvim, errors.WithStack(err))
+	}
+
+	fset := token.NewFileSet()
+	f := parse(eval.File, fset, nvimutil.ToByteSlice(buf))
+	if f == nil {
+		return nvimutil.ErrorWrap(c.Nvim, errors.New("couldn't parse of the current buffer"))
+	}
+	offset := fset.File(f.Pos()).Pos(eval.Offset)
+
+	runArgs, err := gotest.FuncArgs(f, offset)
+	if err != nil {
+		return nvimutil.ErrorWrap(c.Nvim, err)
+	}
+
+	pkg, err := pathutil.PackageIDContext(bctx.BuildContext, eval.Dir)
+	if err != nil {
+		return nvimutil.ErrorWrap(c.Nvim, errors.WithStack(err))
+	}
+
+	cmd := []string{bctx.Build.Cmd(), "test"}
+	cmd = append(cmd, config.TestFlags...)
+	cmd = append(cmd, args...)
+	cmd = append(cmd, runArgs...)
+	cmd = append(cmd, pkg)
+
+	return c.runTest(bctx, cmd, eval.Dir, map[string]string{pkg: eval.Dir})
+}
+
 // ----------------------------------------------------------------------------
 // GoSwitchTest
 
@@ -101,14 +342,17 @@ type cmdTestSwitchEval struct {
 	Cwd    string `msgpack:",array"`
 	File   string
 	Offset int
+	BufNr  int
+	WinID  int
 }
 
 func (c *Command) cmdSwitchTest(eval *cmdTestSwitchEval) {
-	go c.SwitchTest(eval)
+	bctx := c.buildContexts.Context(eval.BufNr, filepath.Dir(eval.File))
+	go c.SwitchTest(bctx, eval)
 }
 
 // SwitchTest switch to the corresponds current cursor (Test)function.
-func (c *Command) SwitchTest(eval *cmdTestSwitchEval) error {
+func (c *Command) SwitchTest(bctx *buildctx.Context, eval *cmdTestSwitchEval) error {
 	defer nvimutil.Profile(c.ctx, time.Now(), "GoSwitchTest")
 
 	fname := eval.File
@@ -130,8 +374,8 @@ func (c *Command) SwitchTest(eval *cmdTestSwitchEval) error {
 		return errors.New("Does not exist the switching destination file")
 	}
 
-	b := nvim.Buffer(c.buildContext.BufNr)
-	w := nvim.Window(c.buildContext.WinID)
+	b := nvim.Buffer(bctx.BufNr)
+	w := nvim.Window(eval.WinID)
 
 	// Get the 2D byte slice of current buffer
 	buf, err := c.Nvim.BufferLines(b, 0, -1, true)
@@ -0,0 +1,423 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/buildctx"
+	"github.com/zchee/nvim-go/src/config"
+	"github.com/zchee/nvim-go/src/internal/gotest"
+	"github.com/zchee/nvim-go/src/nvimutil"
+)
+
+// ----------------------------------------------------------------------------
+// GoTestExplorer
+
+const (
+	// testExplorerName is the buffer name of the GoTestExplorer.
+	testExplorerName = "__GO_TEST_EXPLORER__"
+	// testOutputName is the buffer name of the captured test output.
+	testOutputName = "__GO_TEST_OUTPUT__"
+)
+
+// Kind of the testNode.
+const (
+	testNodePackage = iota
+	testNodeFunc
+	testNodeSubtest
+)
+
+// testNode represents a node of the test explorer tree.
+type testNode struct {
+	Kind     int
+	Package  string         // import path of the package
+	Dir      string         // directory of the package
+	Name     string         // full test name such as "TestFoo/sub". empty if package node
+	Pos      token.Position // position of the test function or t.Run call
+	Children []*testNode
+}
+
+// testExplorer represents a GoTestExplorer buffer.
+type testExplorer struct {
+	bctx   *buildctx.Context
+	buffer *nvimutil.Buffer
+	tree   []*testNode
+	lines  []*testNode // node of each buffer lines
+}
+
+// cmdTestExplorerEval struct type for Eval of GoTestExplorer command.
+type cmdTestExplorerEval struct {
+	Dir   string `msgpack:",array"`
+	BufNr int
+}
+
+func (c *Command) cmdTestExplorer(eval *cmdTestExplorerEval) {
+	bctx := c.buildContexts.Context(eval.BufNr, eval.Dir)
+	go func() {
+		if err := c.TestExplorer(bctx, eval.Dir); err != nil {
+			nvimutil.ErrorWrap(c.Nvim, err)
+		}
+	}()
+}
+
+// TestExplorer opens the tree buffer of the packages, test functions and
+// subtests in the project of bctx, with the last test results status.
+func (c *Command) TestExplorer(bctx *buildctx.Context, dir string) error {
+	defer nvimutil.Profile(c.ctx, time.Now(), "GoTestExplorer")
+
+	if bctx.Build.Tool == "gb" {
+		return errors.New("GoTestExplorer does not support gb")
+	}
+
+	pkgs, pkgDirs, err := testPackages(bctx, testRunDir(bctx, dir))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	tree := testTree(&bctx.BuildContext, pkgs, pkgDirs)
+
+	c.testMu.Lock()
+	e := c.testExplorer
+	if e == nil || !nvimutil.IsBufferValid(c.Nvim, e.buffer.Buffer()) {
+		e = &testExplorer{buffer: nvimutil.NewBuffer(c.Nvim)}
+		if err := e.buffer.Create(testExplorerName, nvimutil.FiletypeGoTestExplorer, "silent topleft 50 vsplit", testBufferOption(nvimutil.FiletypeGoTestExplorer)); err != nil {
+			c.testMu.Unlock()
+			return errors.WithStack(err)
+		}
+		c.testExplorer = e
+	}
+	e.bctx = bctx
+	e.tree = tree
+	c.testMu.Unlock()
+
+	if err := e.buffer.SetLocalMapping(nvimutil.NoremapNormal, testExplorerMapping()); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return c.renderTestExplorer()
+}
+
+// testExplorerMapping returns the buffer local mappings of the GoTestExplorer.
+func testExplorerMapping() map[string]string {
+	action := func(name string) string {
+		return fmt.Sprintf(":<C-u>call rpcnotify(%d, 'GoTestExplorerAction', '%s', line('.'))<CR>", config.ChannelID, name)
+	}
+
+	return map[string]string{
+		"<CR>": action("jump"),
+		"r":    action("run"),
+		"F":    action("failed"),
+		"o":    action("output"),
+		"R":    action("refresh"),
+		"q":    ":<C-u>close<CR>",
+	}
+}
+
+// testBufferOption returns the buffer options of the GoTestExplorer and test output buffers.
+func testBufferOption(filetype string) map[nvimutil.NvimOption]map[string]interface{} {
+	return map[nvimutil.NvimOption]map[string]interface{}{
+		nvimutil.BufferOption: {
+			nvimutil.BufOptionBufhidden:  nvimutil.BufhiddenWipe,
+			nvimutil.BufOptionBuflisted:  false,
+			nvimutil.BufOptionBuftype:    nvimutil.BuftypeNofile,
+			nvimutil.BufOptionFiletype:   filetype,
+			nvimutil.BufOptionModifiable: false,
+			nvimutil.BufOptionSwapfile:   false,
+		},
+		nvimutil.WindowOption: {
+			nvimutil.WinOptionList:           false,
+			nvimutil.WinOptionNumber:         false,
+			nvimutil.WinOptionRelativenumber: false,
+		},
+	}
+}
+
+// renderTestExplorer renders the test tree with the last results to the
+// GoTestExplorer buffer if it opened.
+func (c *Command) renderTestExplorer() error {
+	c.testMu.Lock()
+	defer c.testMu.Unlock()
+
+	e := c.testExplorer
+	if e == nil || !nvimutil.IsBufferValid(c.Nvim, e.buffer.Buffer()) {
+		return nil
+	}
+
+	lines, nodes := renderTestTree(e.tree, c.testReport)
+	if len(lines) == 0 {
+		lines = [][]byte{[]byte("no test packages")}
+	}
+	e.lines = nodes
+
+	defer nvimutil.Modifiable(c.Nvim, e.buffer.Buffer())()
+	return errors.WithStack(c.Nvim.SetBufferLines(e.buffer.Buffer(), 0, -1, true, lines))
+}
+
+// handleTestExplorerAction handles the buffer local mapping action of the GoTestExplorer.
+func (c *Command) handleTestExplorerAction(action string, line int) {
+	go func() {
+		if err := c.testExplorerAction(action, line); err != nil {
+			nvimutil.ErrorWrap(c.Nvim, err)
+		}
+	}()
+}
+
+// testExplorerAction runs the action of the line node.
+func (c *Command) testExplorerAction(action string, line int) error {
+	c.testMu.Lock()
+	e := c.testExplorer
+	if e == nil {
+		c.testMu.Unlock()
+		return nil
+	}
+	bctx := e.bctx
+	var node *testNode
+	if line >= 1 && line <= len(e.lines) {
+		node = e.lines[line-1]
+	}
+	report := c.testReport
+	c.testMu.Unlock()
+
+	switch action {
+	case "refresh":
+		return c.TestExplorer(bctx, bctx.Buffer.Dir)
+	case "failed":
+		return c.testFailed(bctx, report)
+	}
+
+	if node == nil {
+		return nil
+	}
+	switch action {
+	case "jump":
+		if err := c.Nvim.Command("wincmd p"); err != nil {
+			return errors.WithStack(err)
+		}
+		w, err := c.Nvim.CurrentWindow()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return nvimutil.GotoPos(c.Nvim, w, node.Pos, node.Dir)
+	case "run":
+		var args []string
+		if node.Kind != testNodePackage {
+			args = gotest.RunArgs(node.Name)
+		}
+		return c.runTestJSON(bctx, testCmd(bctx, args, node.Package), node.Dir, map[string]string{node.Package: node.Dir})
+	case "output":
+		return c.openTestOutput(testNodeOutput(node, report))
+	}
+
+	return nil
+}
+
+// testCmd returns the test command of the pkg package with config.TestFlags.
+func testCmd(bctx *buildctx.Context, args []string, pkgs ...string) []string {
+	cmd := []string{bctx.Build.Cmd(), "test"}
+	cmd = append(cmd, config.TestFlags...)
+	cmd = append(cmd, args...)
+	return append(cmd, pkgs...)
+}
+
+// testFailed reruns the failed tests of the report.
+func (c *Command) testFailed(bctx *buildctx.Context, report *testReport) error {
+	if report == nil {
+		return errors.New("no test results")
+	}
+
+	for _, pkg := range report.failedPackages() {
+		dir, ok := report.Dirs[pkg]
+		if !ok {
+			continue
+		}
+		args := []string{"-run", failedRunPattern(report, pkg)}
+		if err := c.runTestJSON(bctx, testCmd(bctx, args, pkg), dir, map[string]string{pkg: dir}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// openTestOutput opens the test output buffer with lines.
+func (c *Command) openTestOutput(lines []string) error {
+	c.testMu.Lock()
+	defer c.testMu.Unlock()
+
+	if c.testOutput == nil || !nvimutil.IsBufferValid(c.Nvim, c.testOutput.Buffer()) {
+		c.testOutput = nvimutil.NewBuffer(c.Nvim)
+		if err := c.testOutput.Create(testOutputName, "goterminal", "silent botright 15 split", testBufferOption("goterminal")); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if len(lines) == 0 {
+		lines = []string{"no output"}
+	}
+
+	buf := make([][]byte, len(lines))
+	for i, l := range lines {
+		buf[i] = []byte(l)
+	}
+
+	defer nvimutil.Modifiable(c.Nvim, c.testOutput.Buffer())()
+	return errors.WithStack(c.Nvim.SetBufferLines(c.testOutput.Buffer(), 0, -1, true, buf))
+}
+
+// testTree parses the test files of pkgs packages and returns the tree of the
+// test functions and subtests. The packages that has no test files are ignored.
+func testTree(ctxt *build.Context, pkgs []string, pkgDirs map[string]string) []*testNode {
+	var tree []*testNode
+	for _, pkg := range pkgs {
+		dir := pkgDirs[pkg]
+		bp, err := ctxt.ImportDir(dir, build.ImportMode(0))
+		if err != nil {
+			continue
+		}
+		files := append(bp.TestGoFiles, bp.XTestGoFiles...)
+		if len(files) == 0 {
+			continue
+		}
+
+		pkgNode := &testNode{
+			Kind:    testNodePackage,
+			Package: pkg,
+			Dir:     dir,
+			Pos:     token.Position{Filename: filepath.Join(dir, files[0]), Line: 1, Column: 1},
+		}
+		fset := token.NewFileSet()
+		for _, file := range files {
+			f, err := parser.ParseFile(fset, filepath.Join(dir, file), nil, 0)
+			if err != nil {
+				continue
+			}
+			for _, decl := range f.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv != nil || gotest.FuncKind(fn.Name.Name) == "" {
+					continue
+				}
+				fnNode := &testNode{
+					Kind:    testNodeFunc,
+					Package: pkg,
+					Dir:     dir,
+					Name:    fn.Name.Name,
+					Pos:     fset.Position(fn.Name.Pos()),
+				}
+				if fn.Body != nil {
+					fnNode.Children = subtestNodes(fset, fn.Body, fnNode)
+				}
+				pkgNode.Children = append(pkgNode.Children, fnNode)
+			}
+		}
+		tree = append(tree, pkgNode)
+	}
+
+	return tree
+}
+
+// subtestNodes returns the subtest nodes of t.Run calls in the node.
+func subtestNodes(fset *token.FileSet, node ast.Node, parent *testNode) []*testNode {
+	var nodes []*testNode
+	ast.Inspect(node, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		name, ok := gotest.SubtestName(call)
+		if !ok {
+			return true
+		}
+		sub := &testNode{
+			Kind:    testNodeSubtest,
+			Package: parent.Package,
+			Dir:     parent.Dir,
+			Name:    parent.Name + "/" + name,
+			Pos:     fset.Position(call.Pos()),
+		}
+		sub.Children = subtestNodes(fset, call.Args[1], sub)
+		nodes = append(nodes, sub)
+		// the nested subtests are already walked
+		return false
+	})
+
+	return nodes
+}
+
+// renderTestTree renders the tree with the status icon of the report results.
+// Returns the buffer lines and the node of each lines.
+func renderTestTree(tree []*testNode, report *testReport) ([][]byte, []*testNode) {
+	var (
+		lines [][]byte
+		nodes []*testNode
+	)
+
+	var render func(n *testNode, parent string, depth int)
+	render = func(n *testNode, parent string, depth int) {
+		label := n.Package
+		if n.Kind != testNodePackage {
+			label = strings.TrimPrefix(n.Name, parent+"/")
+		}
+		line := strings.Repeat("  ", depth) + testStatusIcon(report.status(n.Package, n.Name)) + " " + label
+		lines = append(lines, []byte(line))
+		nodes = append(nodes, n)
+
+		for _, child := range n.Children {
+			render(child, n.Name, depth+1)
+		}
+	}
+	for _, n := range tree {
+		render(n, "", 0)
+	}
+
+	return lines, nodes
+}
+
+// testStatusIcon returns the icon of the test status.
+func testStatusIcon(status string) string {
+	switch status {
+	case testPass:
+		return nvimutil.PassSymbol
+	case testFail:
+		return nvimutil.FailSymbol
+	case testSkip:
+		return "-"
+	case testRun:
+		// not finished such as the timeout
+		return "?"
+	default:
+		return " "
+	}
+}
+
+// testNodeOutput returns the captured output of the node and its subtests.
+func testNodeOutput(node *testNode, report *testReport) []string {
+	if report == nil {
+		return nil
+	}
+
+	var output []string
+	for _, res := range report.Tests {
+		if res.Package != node.Package {
+			continue
+		}
+		if node.Kind == testNodePackage || res.Test == node.Name || strings.HasPrefix(res.Test, node.Name+"/") {
+			output = append(output, res.Output...)
+		}
+	}
+	if node.Kind == testNodePackage {
+		if res, ok := report.results[[2]string{node.Package, ""}]; ok {
+			output = append(output, res.Output...)
+		}
+	}
+
+	return output
+}
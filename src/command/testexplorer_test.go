@@ -0,0 +1,92 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testExplorerSrc = `package foo
+
+import "testing"
+
+func TestFoo(t *testing.T) {
+	t.Run("bar baz", func(t *testing.T) {
+		t.Run("qux", func(t *testing.T) {
+		})
+	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+		})
+	}
+}
+
+func BenchmarkFoo(b *testing.B) {
+	b.Run("small", func(b *testing.B) {
+	})
+}
+
+func ExampleFoo() {}
+
+func Testfoo(t *testing.T) {}
+`
+
+func TestTestTree(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nvim-go-testexplorer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "foo.go"), []byte("package foo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "foo_test.go"), []byte(testExplorerSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tree := testTree(&build.Default, []string{"example.com/foo"}, map[string]string{"example.com/foo": dir})
+	report, err := parseTestEvents(strings.NewReader(`{"Action":"pass","Package":"example.com/foo","Test":"TestFoo/bar_baz"}
+{"Action":"fail","Package":"example.com/foo","Test":"TestFoo"}
+{"Action":"fail","Package":"example.com/foo"}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines, nodes := renderTestTree(tree, report)
+	var got []string
+	for _, l := range lines {
+		got = append(got, string(l))
+	}
+	want := []string{
+		"✗ example.com/foo",
+		"  ✗ TestFoo",
+		"    ✓ bar_baz",
+		"        qux",
+		"    BenchmarkFoo",
+		"      small",
+		"    ExampleFoo",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("renderTestTree() = %q, want %q", got, want)
+	}
+	if len(nodes) != len(lines) {
+		t.Fatalf("len(nodes) = %d, want %d", len(nodes), len(lines))
+	}
+	if n := nodes[3]; n.Kind != testNodeSubtest || n.Name != "TestFoo/bar_baz/qux" || n.Pos.Line != 7 {
+		t.Errorf("nodes[3] = %+v, want the TestFoo/bar_baz/qux subtest at line 7", n)
+	}
+
+	if lines, _ := renderTestTree(tree, nil); !strings.HasPrefix(string(lines[1]), "    TestFoo") {
+		t.Errorf("renderTestTree(nil report) = %q, want no status icon", lines[1])
+	}
+}
@@ -0,0 +1,545 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/buildctx"
+	"github.com/zchee/nvim-go/src/internal/gotest"
+	"github.com/zchee/nvim-go/src/logger"
+	"github.com/zchee/nvim-go/src/nvimutil"
+	"go.uber.org/zap"
+)
+
+// testEvent represents a event of the "go test -json" output.
+// Same as the cmd/internal/test2json event.
+type testEvent struct {
+	Time    time.Time
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+// Status of the test result.
+const (
+	testRun  = "run"
+	testPass = "pass"
+	testFail = "fail"
+	testSkip = "skip"
+)
+
+// testResult represents the result of a test or package.
+type testResult struct {
+	Package string
+	Test    string // empty if the package result
+	Status  string
+	Elapsed float64
+	Output  []string
+}
+
+// testReport represents the results of the "go test -json".
+type testReport struct {
+	Tests       []*testResult     // test results in order of run
+	Packages    []*testResult     // package results in order of run
+	BuildOutput []byte            // not JSON output such as the build errors
+	Dirs        map[string]string // map[importPath]dir of the test packages
+
+	results map[[2]string]*testResult // map[[package, test]]*testResult
+}
+
+func newTestReport() *testReport {
+	return &testReport{
+		Dirs:    make(map[string]string),
+		results: make(map[[2]string]*testResult),
+	}
+}
+
+// result returns the result of the pkg package test. returns the package
+// result if test is empty.
+func (r *testReport) result(pkg, test string) *testResult {
+	key := [2]string{pkg, test}
+	res, ok := r.results[key]
+	if !ok {
+		res = &testResult{Package: pkg, Test: test, Status: testRun}
+		r.results[key] = res
+		if test == "" {
+			r.Packages = append(r.Packages, res)
+		} else {
+			r.Tests = append(r.Tests, res)
+		}
+	}
+
+	return res
+}
+
+// add adds the ev event to the report.
+func (r *testReport) add(ev *testEvent) {
+	switch ev.Action {
+	case "build-output":
+		r.BuildOutput = append(r.BuildOutput, ev.Output...)
+		return
+	case "start", "build-fail":
+		return
+	}
+
+	res := r.result(ev.Package, ev.Test)
+	switch ev.Action {
+	case testPass, testFail, testSkip:
+		res.Status = ev.Action
+		res.Elapsed = ev.Elapsed
+	case "output":
+		res.Output = append(res.Output, strings.TrimSuffix(ev.Output, "\n"))
+	}
+}
+
+// Failed returns the failed test results.
+func (r *testReport) Failed() []*testResult {
+	var failed []*testResult
+	for _, res := range r.Tests {
+		if res.Status == testFail {
+			failed = append(failed, res)
+		}
+	}
+
+	return failed
+}
+
+// status returns the status of the pkg package test, or empty if r has no
+// result of the test. returns the package status if test is empty.
+func (r *testReport) status(pkg, test string) string {
+	if r == nil {
+		return ""
+	}
+	res, ok := r.results[[2]string{pkg, test}]
+	if !ok {
+		return ""
+	}
+	return res.Status
+}
+
+// failedPackages returns the import paths of the packages that has the failed tests.
+func (r *testReport) failedPackages() []string {
+	var pkgs []string
+	seen := make(map[string]bool)
+	for _, res := range r.Failed() {
+		if !seen[res.Package] {
+			seen[res.Package] = true
+			pkgs = append(pkgs, res.Package)
+		}
+	}
+
+	return pkgs
+}
+
+// failedRunPattern returns the -run flag pattern that matches the failed
+// top-level tests of the pkg package, or of all packages if pkg is empty.
+func failedRunPattern(report *testReport, pkg string) string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, res := range report.Failed() {
+		if pkg != "" && res.Package != pkg {
+			continue
+		}
+		name := res.Test
+		if i := strings.IndexByte(name, '/'); i >= 0 {
+			name = name[:i]
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, regexp.QuoteMeta(name))
+		}
+	}
+
+	return "^(" + strings.Join(names, "|") + ")$"
+}
+
+// merge returns the new report that the results of the packages in the
+// newer report are replaced by the newer results, so that the results of the
+// removed or fixed tests are not kept. r can be nil.
+func (r *testReport) merge(newer *testReport) *testReport {
+	if r == nil {
+		return newer
+	}
+
+	replaced := make(map[string]bool)
+	for _, rr := range [][]*testResult{newer.Packages, newer.Tests} {
+		for _, res := range rr {
+			replaced[res.Package] = true
+		}
+	}
+	for pkg := range newer.Dirs {
+		replaced[pkg] = true
+	}
+
+	merged := newTestReport()
+	merged.BuildOutput = newer.BuildOutput
+	add := func(res *testResult) {
+		key := [2]string{res.Package, res.Test}
+		if _, ok := merged.results[key]; ok {
+			return
+		}
+		merged.results[key] = res
+		if res.Test == "" {
+			merged.Packages = append(merged.Packages, res)
+		} else {
+			merged.Tests = append(merged.Tests, res)
+		}
+	}
+	for _, res := range r.Packages {
+		if !replaced[res.Package] {
+			add(res)
+		}
+	}
+	for _, res := range r.Tests {
+		if !replaced[res.Package] {
+			add(res)
+		}
+	}
+	for _, res := range newer.Packages {
+		add(res)
+	}
+	for _, res := range newer.Tests {
+		add(res)
+	}
+	for _, rr := range []*testReport{r, newer} {
+		for pkg, dir := range rr.Dirs {
+			merged.Dirs[pkg] = dir
+		}
+	}
+
+	return merged
+}
+
+// count returns the number of tests of each status.
+func (r *testReport) count() (pass, fail, skip int) {
+	for _, res := range r.Tests {
+		switch res.Status {
+		case testPass:
+			pass++
+		case testFail:
+			fail++
+		case testSkip:
+			skip++
+		}
+	}
+
+	return pass, fail, skip
+}
+
+// parseTestEvents parses the "go test -json" output.
+func parseTestEvents(rd io.Reader) (*testReport, error) {
+	report := newTestReport()
+
+	sc := bufio.NewScanner(rd)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Bytes()
+		ev := new(testEvent)
+		if len(line) == 0 || line[0] != '{' || json.Unmarshal(line, ev) != nil {
+			report.BuildOutput = append(report.BuildOutput, line...)
+			report.BuildOutput = append(report.BuildOutput, '\n')
+			continue
+		}
+		report.add(ev)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return report, nil
+}
+
+var (
+	// testLocationRe matches the t.Error and t.Fatal output such as "    foo_test.go:12: message".
+	testLocationRe = regexp.MustCompile(`^(\s+)([^\s:]+\.go):(\d+): ?(.*)$`)
+	// testPanicFrameRe matches the stack frame of panic such as "	/path/to/foo_test.go:12 +0x1d".
+	testPanicFrameRe = regexp.MustCompile(`^\t(\S+\.go):(\d+)(?: \+0x[0-9a-f]+)?$`)
+)
+
+// testErrors returns the error list of the failed tests.
+// pkgDirs is the map of the import path and directory of the test packages,
+// and funcPos is the positions of the test functions of each package.
+// The failed test which has no output location uses the test function position.
+func testErrors(report *testReport, pkgDirs map[string]string, funcPos map[string]map[string]token.Position) []*nvim.QuickfixError {
+	var errlist []*nvim.QuickfixError
+
+	for _, res := range report.Failed() {
+		dir, ok := pkgDirs[res.Package]
+		if !ok {
+			continue
+		}
+
+		var (
+			found    bool
+			last     *nvim.QuickfixError
+			indent   int
+			panicMsg string
+		)
+		for _, out := range res.Output {
+			if m := testLocationRe.FindStringSubmatch(out); m != nil {
+				line, _ := strconv.Atoi(m[3])
+				last = &nvim.QuickfixError{
+					FileName: filepath.Join(dir, m[2]),
+					LNum:     line,
+					Text:     res.Test + ": " + m[4],
+				}
+				indent = len(m[1])
+				errlist = append(errlist, last)
+				found = true
+				continue
+			}
+
+			trimmed := strings.TrimSpace(out)
+			switch {
+			case strings.HasPrefix(out, "panic: "):
+				panicMsg = out
+				last = nil
+			case panicMsg != "" && !found:
+				if m := testPanicFrameRe.FindStringSubmatch(out); m != nil && filepath.Dir(m[1]) == dir && strings.HasSuffix(m[1], "_test.go") {
+					line, _ := strconv.Atoi(m[2])
+					errlist = append(errlist, &nvim.QuickfixError{
+						FileName: m[1],
+						LNum:     line,
+						Text:     res.Test + ": " + panicMsg,
+					})
+					found = true
+				}
+			case last != nil && trimmed != "" && len(out)-len(strings.TrimLeft(out, " \t")) > indent:
+				// continuation line of the multi-line message
+				last.Text += " " + trimmed
+			default:
+				last = nil
+			}
+		}
+		if found || hasFailedSubtest(report, res) {
+			continue
+		}
+
+		name := res.Test
+		if i := strings.IndexByte(name, '/'); i >= 0 {
+			name = name[:i]
+		}
+		pos, ok := funcPos[res.Package][name]
+		if !ok {
+			continue
+		}
+		text := res.Test + ": FAIL"
+		if panicMsg != "" {
+			text = res.Test + ": " + panicMsg
+		}
+		errlist = append(errlist, &nvim.QuickfixError{
+			FileName: pos.Filename,
+			LNum:     pos.Line,
+			Col:      pos.Column,
+			Text:     text,
+		})
+	}
+
+	return errlist
+}
+
+// hasFailedSubtest reports whether the res test has the failed subtest.
+func hasFailedSubtest(report *testReport, res *testResult) bool {
+	for _, sub := range report.Tests {
+		if sub.Package == res.Package && sub.Status == testFail && strings.HasPrefix(sub.Test, res.Test+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// testFuncPositions returns the positions of the Test, Benchmark and Example
+// functions in the dir package test files.
+func testFuncPositions(ctxt *build.Context, dir string) (map[string]token.Position, error) {
+	bp, err := ctxt.ImportDir(dir, build.ImportMode(0))
+	if err != nil {
+		if _, ok := err.(*build.NoGoError); !ok {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	fset := token.NewFileSet()
+	positions := make(map[string]token.Position)
+	for _, files := range [][]string{bp.TestGoFiles, bp.XTestGoFiles} {
+		for _, file := range files {
+			f, err := parser.ParseFile(fset, filepath.Join(dir, file), nil, 0)
+			if err != nil {
+				continue
+			}
+			for _, decl := range f.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv != nil {
+					continue
+				}
+				if gotest.FuncKind(fn.Name.Name) != "" {
+					positions[fn.Name.Name] = fset.Position(fn.Name.Pos())
+				}
+			}
+		}
+	}
+
+	return positions, nil
+}
+
+// runTestJSON runs the cmd test command with -json flag, and sets the
+// failed tests to the error list, the pass and fail signs to the test
+// functions, and echoes the summary.
+func (c *Command) runTestJSON(bctx *buildctx.Context, cmd []string, dir string, pkgDirs map[string]string) error {
+	args := append([]string{cmd[1], "-json"}, cmd[2:]...)
+	testCmd := exec.Command(cmd[0], args...)
+	testCmd.Dir = testRunDir(bctx, dir)
+	testCmd.Env = bctx.Environ()
+
+	var stdout, stderr bytes.Buffer
+	testCmd.Stdout = &stdout
+	testCmd.Stderr = &stderr
+
+	logger.FromContext(c.ctx).Info("runTestJSON", zap.Strings("cmd", testCmd.Args))
+	nvimutil.EchoProgress(c.Nvim, "GoTest", "Running %s", strings.Join(cmd[2:], " "))
+
+	if err := testCmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return nvimutil.ErrorWrap(c.Nvim, errors.WithStack(err))
+		}
+	}
+
+	report, err := parseTestEvents(&stdout)
+	if err != nil {
+		return nvimutil.ErrorWrap(c.Nvim, err)
+	}
+	report.BuildOutput = append(report.BuildOutput, stderr.Bytes()...)
+	for pkg, pkgDir := range pkgDirs {
+		report.Dirs[pkg] = pkgDir
+	}
+
+	funcPos := make(map[string]map[string]token.Position)
+	for pkg, pkgDir := range pkgDirs {
+		pos, err := testFuncPositions(&bctx.BuildContext, pkgDir)
+		if err != nil {
+			continue
+		}
+		funcPos[pkg] = pos
+	}
+
+	errlist := testErrors(report, pkgDirs, funcPos)
+	buildErrs, err := nvimutil.ParseError(report.BuildOutput, testCmd.Dir, &bctx.Build, nil)
+	if err != nil {
+		return nvimutil.ErrorWrap(c.Nvim, err)
+	}
+	errlist = append(errlist, buildErrs...)
+
+	c.testMu.Lock()
+	c.testReport = c.testReport.merge(report)
+	c.testMu.Unlock()
+
+	if err := c.placeTestSigns(report, funcPos); err != nil {
+		return nvimutil.ErrorWrap(c.Nvim, err)
+	}
+	if err := c.renderTestExplorer(); err != nil {
+		return nvimutil.ErrorWrap(c.Nvim, err)
+	}
+
+	c.errs.Delete("Test")
+	if len(errlist) > 0 {
+		c.errs.Store("Test", errlist)
+	}
+	errmap := c.Errors()
+	errmap["Test"] = errlist // clears the diagnostics if no errors
+	if err := nvimutil.ErrorList(c.Nvim, errmap, true); err != nil {
+		return nvimutil.ErrorWrap(c.Nvim, err)
+	}
+
+	pass, fail, skip := report.count()
+	summary := fmt.Sprintf("%d passed, %d failed, %d skipped", pass, fail, skip)
+	if fail > 0 || len(buildErrs) > 0 {
+		return nvimutil.EchohlAfter(c.Nvim, "GoTest", "ErrorMsg", "FAIL | %s", summary)
+	}
+	return nvimutil.EchoSuccess(c.Nvim, "GoTest", summary)
+}
+
+// testSignID is the base id of the test result signs. Avoids conflict with
+// the delve and diagnostic sign ids.
+const testSignID = 5000
+
+var (
+	testPassSign *nvimutil.Sign
+	testFailSign *nvimutil.Sign
+)
+
+// placedSign represents the placed sign id and file.
+type placedSign struct {
+	ID   int
+	File string
+}
+
+// placeTestSigns places the pass and fail signs to the test functions of
+// report, and unplaces the previously placed signs.
+func (c *Command) placeTestSigns(report *testReport, funcPos map[string]map[string]token.Position) error {
+	c.testMu.Lock()
+	defer c.testMu.Unlock()
+
+	if testPassSign == nil {
+		var err error
+		testPassSign, err = nvimutil.NewSign(c.Nvim, "GoTestPass", nvimutil.PassSymbol, nvimutil.SuccessColor, "")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		testFailSign, err = nvimutil.NewSign(c.Nvim, "GoTestFail", nvimutil.FailSymbol, "ErrorMsg", "")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	// the buffer might be already wiped out, so ignore the errors
+	for _, s := range c.testSigns {
+		testPassSign.Unplace(c.Nvim, s.ID, s.File)
+	}
+	c.testSigns = nil
+
+	id := testSignID
+	for _, res := range report.Tests {
+		var sign *nvimutil.Sign
+		switch res.Status {
+		case testPass:
+			sign = testPassSign
+		case testFail:
+			sign = testFailSign
+		default:
+			continue
+		}
+		pos, ok := funcPos[res.Package][res.Test]
+		if !ok {
+			// subtests does not have the function
+			continue
+		}
+		// the signs can only be placed to the loaded buffer
+		if err := sign.Place(c.Nvim, id, pos.Line, pos.Filename, false); err != nil {
+			continue
+		}
+		c.testSigns = append(c.testSigns, placedSign{ID: id, File: pos.Filename})
+		id++
+	}
+
+	return nil
+}
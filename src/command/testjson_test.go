@@ -0,0 +1,202 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/neovim/go-client/nvim"
+)
+
+const testJSONOutput = `{"Action":"run","Package":"example.com/foo","Test":"TestPass"}
+{"Action":"output","Package":"example.com/foo","Test":"TestPass","Output":"=== RUN   TestPass\n"}
+{"Action":"output","Package":"example.com/foo","Test":"TestPass","Output":"--- PASS: TestPass (0.00s)\n"}
+{"Action":"pass","Package":"example.com/foo","Test":"TestPass","Elapsed":0}
+{"Action":"run","Package":"example.com/foo","Test":"TestFail"}
+{"Action":"output","Package":"example.com/foo","Test":"TestFail","Output":"=== RUN   TestFail\n"}
+{"Action":"output","Package":"example.com/foo","Test":"TestFail","Output":"    foo_test.go:12: got 1\n"}
+{"Action":"output","Package":"example.com/foo","Test":"TestFail","Output":"        want 2\n"}
+{"Action":"output","Package":"example.com/foo","Test":"TestFail","Output":"--- FAIL: TestFail (0.00s)\n"}
+{"Action":"fail","Package":"example.com/foo","Test":"TestFail","Elapsed":0.01}
+{"Action":"run","Package":"example.com/foo","Test":"TestSub"}
+{"Action":"run","Package":"example.com/foo","Test":"TestSub/case_1"}
+{"Action":"output","Package":"example.com/foo","Test":"TestSub/case_1","Output":"        foo_test.go:20: case 1 failed\n"}
+{"Action":"fail","Package":"example.com/foo","Test":"TestSub/case_1","Elapsed":0}
+{"Action":"fail","Package":"example.com/foo","Test":"TestSub","Elapsed":0}
+{"Action":"run","Package":"example.com/foo","Test":"TestPanic"}
+{"Action":"output","Package":"example.com/foo","Test":"TestPanic","Output":"--- FAIL: TestPanic (0.00s)\n"}
+{"Action":"output","Package":"example.com/foo","Test":"TestPanic","Output":"panic: runtime error: index out of range\n"}
+{"Action":"output","Package":"example.com/foo","Test":"TestPanic","Output":"\t/go/src/example.com/foo/foo.go:5 +0x1d\n"}
+{"Action":"output","Package":"example.com/foo","Test":"TestPanic","Output":"\t/go/src/example.com/foo/foo_test.go:30 +0x2a\n"}
+{"Action":"fail","Package":"example.com/foo","Test":"TestPanic","Elapsed":0}
+{"Action":"run","Package":"example.com/foo","Test":"TestTimeout"}
+{"Action":"fail","Package":"example.com/foo","Test":"TestTimeout","Elapsed":0}
+{"Action":"skip","Package":"example.com/foo","Test":"TestSkip","Elapsed":0}
+{"Action":"output","Package":"example.com/foo","Output":"FAIL\n"}
+{"Action":"fail","Package":"example.com/foo","Elapsed":0.02}
+# example.com/bar
+bar.go:3:1: syntax error
+`
+
+func TestParseTestEvents(t *testing.T) {
+	report, err := parseTestEvents(strings.NewReader(testJSONOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests []string
+	for _, res := range report.Tests {
+		tests = append(tests, res.Test+":"+res.Status)
+	}
+	wantTests := []string{"TestPass:pass", "TestFail:fail", "TestSub:fail", "TestSub/case_1:fail", "TestPanic:fail", "TestTimeout:fail", "TestSkip:skip"}
+	if !reflect.DeepEqual(tests, wantTests) {
+		t.Errorf("report.Tests = %v, want %v", tests, wantTests)
+	}
+	if len(report.Packages) != 1 || report.Packages[0].Status != testFail {
+		t.Errorf("report.Packages = %v, want the failed example.com/foo", report.Packages)
+	}
+	if want := "# example.com/bar\nbar.go:3:1: syntax error\n"; string(report.BuildOutput) != want {
+		t.Errorf("report.BuildOutput = %q, want %q", report.BuildOutput, want)
+	}
+	if pass, fail, skip := report.count(); pass != 1 || fail != 5 || skip != 1 {
+		t.Errorf("report.count() = (%d, %d, %d), want (1, 5, 1)", pass, fail, skip)
+	}
+}
+
+func TestTestErrors(t *testing.T) {
+	report, err := parseTestEvents(strings.NewReader(testJSONOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := "/go/src/example.com/foo"
+	pkgDirs := map[string]string{"example.com/foo": dir}
+	funcPos := map[string]map[string]token.Position{
+		"example.com/foo": {
+			"TestTimeout": {Filename: dir + "/foo_test.go", Line: 40, Column: 6},
+		},
+	}
+
+	want := []*nvim.QuickfixError{
+		{FileName: dir + "/foo_test.go", LNum: 12, Text: "TestFail: got 1 want 2"},
+		{FileName: dir + "/foo_test.go", LNum: 20, Text: "TestSub/case_1: case 1 failed"},
+		{FileName: dir + "/foo_test.go", LNum: 30, Text: "TestPanic: panic: runtime error: index out of range"},
+		{FileName: dir + "/foo_test.go", LNum: 40, Col: 6, Text: "TestTimeout: FAIL"},
+	}
+	if got := testErrors(report, pkgDirs, funcPos); !reflect.DeepEqual(got, want) {
+		for _, e := range got {
+			t.Logf("%+v", e)
+		}
+		t.Errorf("testErrors() = %v, want %v", got, want)
+	}
+}
+
+func TestFailedRunPattern(t *testing.T) {
+	report, err := parseTestEvents(strings.NewReader(testJSONOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := report.failedPackages(), []string{"example.com/foo"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("report.failedPackages() = %v, want %v", got, want)
+	}
+	if got, want := failedRunPattern(report, "example.com/foo"), "^(TestFail|TestSub|TestPanic|TestTimeout)$"; got != want {
+		t.Errorf("failedRunPattern() = %q, want %q", got, want)
+	}
+
+	// the result of the other package is kept
+	report.add(&testEvent{Action: "pass", Package: "example.com/baz", Test: "TestBaz"})
+
+	// rerun of the failed tests
+	rerun, err := parseTestEvents(strings.NewReader(`{"Action":"pass","Package":"example.com/foo","Test":"TestFail"}
+{"Action":"pass","Package":"example.com/foo","Test":"TestSub/case_1"}
+{"Action":"pass","Package":"example.com/foo","Test":"TestSub"}
+{"Action":"fail","Package":"example.com/foo","Test":"TestTimeout"}
+{"Action":"fail","Package":"example.com/foo"}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rerun.Dirs["example.com/foo"] = "/go/src/example.com/foo"
+
+	merged := report.merge(rerun)
+	if got, want := failedRunPattern(merged, "example.com/foo"), "^(TestTimeout)$"; got != want {
+		t.Errorf("failedRunPattern(merged) = %q, want %q", got, want)
+	}
+	// the results of the replaced package are not kept
+	if got := merged.status("example.com/foo", "TestPass"); got != "" {
+		t.Errorf("merged.status(TestPass) = %q, want the replaced result", got)
+	}
+	if got, want := merged.status("example.com/baz", "TestBaz"), testPass; got != want {
+		t.Errorf("merged.status(TestBaz) = %q, want %q", got, want)
+	}
+	if got := merged.Dirs["example.com/foo"]; got != "/go/src/example.com/foo" {
+		t.Errorf("merged.Dirs = %v, want the example.com/foo directory", merged.Dirs)
+	}
+	if got := (*testReport)(nil).merge(rerun); got != rerun {
+		t.Errorf("nil.merge() = %v, want %v", got, rerun)
+	}
+}
+
+func TestParseTestOutput(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  map[string]string // map[package]failedRunPattern
+	}{
+		{
+			name: "verbose",
+			lines: []string{
+				"=== RUN   TestPass",
+				"--- PASS: TestPass (0.00s)",
+				"=== RUN   TestFail",
+				"--- FAIL: TestFail (0.00s)",
+				"    foo_test.go:12: got 1",
+				"=== RUN   TestSub",
+				"--- FAIL: TestSub (0.00s)",
+				"    --- FAIL: TestSub/case_1 (0.00s)",
+				"FAIL",
+				"FAIL    example.com/foo 0.01s",
+			},
+			want: map[string]string{"example.com/foo": "^(TestFail|TestSub)$"},
+		},
+		{
+			name: "packages",
+			lines: []string{
+				"--- FAIL: TestBar (0.00s)",
+				"FAIL",
+				"FAIL\texample.com/bar\t0.01s",
+				"ok      example.com/baz 0.01s",
+				"--- FAIL: TestQux (0.00s)",
+				"FAIL    example.com/qux 0.01s",
+			},
+			want: map[string]string{
+				"example.com/bar": "^(TestBar)$",
+				"example.com/qux": "^(TestQux)$",
+			},
+		},
+		{
+			name:  "pass",
+			lines: []string{"PASS", "ok      example.com/foo 0.01s"},
+			want:  map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			report := parseTestOutput(tt.lines)
+			got := make(map[string]string)
+			for _, pkg := range report.failedPackages() {
+				got[pkg] = failedRunPattern(report, pkg)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTestOutput(%q) failed tests = %v, want %v", tt.lines, got, tt.want)
+			}
+		})
+	}
+}
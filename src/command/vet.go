@@ -12,6 +12,7 @@ import (
 
 	"github.com/neovim/go-client/nvim"
 	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/buildctx"
 	"github.com/zchee/nvim-go/src/config"
 	"github.com/zchee/nvim-go/src/nvimutil"
 	"github.com/zchee/nvim-go/src/pathutil"
@@ -19,30 +20,35 @@ import (
 
 // CmdVetEval struct type for Eval of GoBuild command.
 type CmdVetEval struct {
-	Cwd  string `msgpack:",array"`
-	File string
+	Cwd   string `msgpack:",array"`
+	File  string
+	BufNr int
 }
 
 func (c *Command) cmdVet(args []string, eval *CmdVetEval) {
+	bctx := c.buildContexts.Context(eval.BufNr, filepath.Dir(eval.File))
 	errch := make(chan interface{}, 1)
 	go func() {
-		delete(c.buildContext.Errlist, "Vet") // cleanup
-		errch <- c.Vet(args, eval)
+		delete(bctx.Errlist, "Vet") // cleanup
+		errch <- c.Vet(bctx, args, eval)
 	}()
 
 	switch err := <-errch; e := err.(type) {
+	case nil:
+		nvimutil.ClearDiagnostics(c.Nvim, "Vet")
 	case error:
 		nvimutil.ErrorWrap(c.Nvim, e)
 	case []*nvim.QuickfixError:
-		c.buildContext.Errlist["Vet"] = e
-		nvimutil.ErrorList(c.Nvim, c.buildContext.Errlist, true)
+		bctx.Errlist["Vet"] = e
+		nvimutil.ErrorList(c.Nvim, bctx.Errlist, true)
 	}
 }
 
 // Vet is a simple checker for static errors in Go source code use go tool vet command.
-func (c *Command) Vet(args []string, eval *CmdVetEval) interface{} {
+func (c *Command) Vet(bctx *buildctx.Context, args []string, eval *CmdVetEval) interface{} {
 	vetCmd := exec.Command("go", "tool", "vet")
 	vetCmd.Dir = eval.Cwd
+	vetCmd.Env = bctx.Environ()
 
 	switch {
 	case len(args) > 0:
@@ -79,7 +85,7 @@ func (c *Command) Vet(args []string, eval *CmdVetEval) interface{} {
 
 	vetErr := vetCmd.Run()
 	if vetErr != nil {
-		errlist, err := nvimutil.ParseError(stderr.Bytes(), eval.Cwd, &c.buildContext.Build, config.GoVetIgnore)
+		errlist, err := nvimutil.ParseError(stderr.Bytes(), eval.Cwd, &bctx.Build, config.GoVetIgnore)
 		if err != nil {
 			return errors.WithStack(err)
 		}
@@ -138,8 +138,8 @@ func TestCommand_Vet(t *testing.T) {
 			t.Parallel()
 
 			tt.fields.buildctxt.Build.Tool = tt.tool
-			c := NewCommand(tt.fields.ctx, tt.fields.Nvim, tt.fields.buildctxt)
-			if got := c.Vet(tt.args.args, tt.args.eval); !reflect.DeepEqual(got, tt.want) {
+			c := NewCommand(tt.fields.ctx, tt.fields.Nvim, buildctx.NewRegistry())
+			if got := c.Vet(tt.fields.buildctxt, tt.args.args, tt.args.eval); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("Command.Vet(%v, %v) = %v, want %v", tt.args.args, tt.args.eval, got, tt.want)
 			}
 		})
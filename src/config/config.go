@@ -103,6 +103,15 @@ func Merge(cfg, cfg2 *Config) *Config {
 		}
 	}
 
+	if cfg2.Check != nil {
+		if itob(cfg.Check.Enable) != itob(cfg2.Check.Enable) {
+			cfg.Check.Enable = cfg2.Check.Enable
+		}
+		if cfg.Check.Delay != cfg2.Check.Delay {
+			cfg.Check.Delay = cfg2.Check.Delay
+		}
+	}
+
 	if cfg2.Cover != nil {
 		if strings.EqualFold(strings.Join(cfg.Cover.Flags, ""), strings.Join(cfg2.Cover.Flags, "")) {
 			cfg.Cover.Flags = cfg2.Cover.Flags
@@ -110,6 +119,24 @@ func Merge(cfg, cfg2 *Config) *Config {
 		if cfg.Cover.Mode != cfg2.Cover.Mode {
 			cfg.Cover.Mode = cfg2.Cover.Mode
 		}
+		if cfg.Cover.Style != cfg2.Cover.Style {
+			cfg.Cover.Style = cfg2.Cover.Style
+		}
+		if itob(cfg.Cover.Counts) != itob(cfg2.Cover.Counts) {
+			cfg.Cover.Counts = cfg2.Cover.Counts
+		}
+	}
+
+	if cfg2.Diagnostic != nil {
+		if itob(cfg.Diagnostic.Echo) != itob(cfg2.Diagnostic.Echo) {
+			cfg.Diagnostic.Echo = cfg2.Diagnostic.Echo
+		}
+		if itob(cfg.Diagnostic.Signs) != itob(cfg2.Diagnostic.Signs) {
+			cfg.Diagnostic.Signs = cfg2.Diagnostic.Signs
+		}
+		if itob(cfg.Diagnostic.VirtualText) != itob(cfg2.Diagnostic.VirtualText) {
+			cfg.Diagnostic.VirtualText = cfg2.Diagnostic.VirtualText
+		}
 	}
 
 	if cfg2.Fmt != nil {
@@ -227,6 +254,27 @@ func Merge(cfg, cfg2 *Config) *Config {
 		if strings.EqualFold(strings.Join(cfg.Test.Flags, ""), strings.Join(cfg2.Test.Flags, "")) {
 			cfg.Test.Flags = cfg2.Test.Flags
 		}
+		if itob(cfg.Test.JSON) != itob(cfg2.Test.JSON) {
+			cfg.Test.JSON = cfg2.Test.JSON
+		}
+	}
+
+	if cfg2.Delve != nil {
+		if itob(cfg.Delve.EvalFloat) != itob(cfg2.Delve.EvalFloat) {
+			cfg.Delve.EvalFloat = cfg2.Delve.EvalFloat
+		}
+		if cfg.Delve.MaxRecurse != cfg2.Delve.MaxRecurse {
+			cfg.Delve.MaxRecurse = cfg2.Delve.MaxRecurse
+		}
+		if cfg.Delve.MaxStringLen != cfg2.Delve.MaxStringLen {
+			cfg.Delve.MaxStringLen = cfg2.Delve.MaxStringLen
+		}
+		if cfg.Delve.MaxArrayValues != cfg2.Delve.MaxArrayValues {
+			cfg.Delve.MaxArrayValues = cfg2.Delve.MaxArrayValues
+		}
+		if cfg.Delve.Backend != cfg2.Delve.Backend {
+			cfg.Delve.Backend = cfg2.Delve.Backend
+		}
 	}
 
 	if cfg2.Debug != nil {
@@ -14,16 +14,20 @@ import (
 type Config struct {
 	Global *Global
 
-	Build    *build
-	Cover    *cover
-	Fmt      *fmt
-	Generate *generate
-	Guru     *guru
-	Iferr    *iferr
-	Lint     *lint
-	Rename   *rename
-	Terminal *terminal
-	Test     *test
+	Build      *build
+	Check      *check
+	Cover      *cover
+	Diagnostic *diagnostic
+	Fmt        *fmt
+	Generate   *generate
+	Guru       *guru
+	Iferr      *iferr
+	Lint       *lint
+	Rename     *rename
+	Terminal   *terminal
+	Test       *test
+
+	Delve *delve
 
 	Debug *debug
 }
@@ -44,9 +48,24 @@ type build struct {
 	IsNotGb   int64    `eval:"get(g:, 'go#build#is_not_gb', 0)"`
 }
 
+// check represents a GoCheck command config variable.
+type check struct {
+	Enable int64 `eval:"get(g:, 'go#check#enable', 0)"`
+	Delay  int64 `eval:"get(g:, 'go#check#delay', 500)"`
+}
+
 type cover struct {
-	Flags []string `eval:"get(g:, 'go#cover#flags', [])"`
-	Mode  string   `eval:"get(g:, 'go#cover#mode', '')"`
+	Flags  []string `eval:"get(g:, 'go#cover#flags', [])"`
+	Mode   string   `eval:"get(g:, 'go#cover#mode', '')"`
+	Style  string   `eval:"get(g:, 'go#cover#style', 'highlight')"`
+	Counts int64    `eval:"get(g:, 'go#cover#counts', 0)"`
+}
+
+// diagnostic represents a diagnostics signs and virtual text config variable.
+type diagnostic struct {
+	Echo        int64 `eval:"get(g:, 'go#diagnostic#echo', 1)"`
+	Signs       int64 `eval:"get(g:, 'go#diagnostic#signs', 1)"`
+	VirtualText int64 `eval:"get(g:, 'go#diagnostic#virtualtext', 1)"`
 }
 
 // fmt represents a GoFmt command config variable.
@@ -110,6 +129,16 @@ type test struct {
 	AllPackage int64    `eval:"get(g:, 'go#test#all_package', 0)"`
 	Autosave   int64    `eval:"get(g:, 'go#test#autosave', 0)"`
 	Flags      []string `eval:"get(g:, 'go#test#flags', [])"`
+	JSON       int64    `eval:"get(g:, 'go#test#json', 0)"`
+}
+
+// delve represents a Dlv commands config variables.
+type delve struct {
+	EvalFloat      int64  `eval:"get(g:, 'go#delve#eval#float', 1)"`
+	MaxRecurse     int64  `eval:"get(g:, 'go#delve#max_variable_recurse', 1)"`
+	MaxStringLen   int64  `eval:"get(g:, 'go#delve#max_string_len', 64)"`
+	MaxArrayValues int64  `eval:"get(g:, 'go#delve#max_array_values', 64)"`
+	Backend        string `eval:"get(g:, 'go#delve#backend', 'rpc2')"`
 }
 
 // Debug represents a debug of nvim-go config variable.
@@ -138,10 +167,26 @@ var (
 	// BuildIsNotGb workaround for not ues gb compiler.
 	BuildIsNotGb bool
 
+	// CheckEnable call the GoCheck command automatically at during the TextChanged and TextChangedI.
+	CheckEnable bool
+	// CheckDelay debounce delay milliseconds of the GoCheck on TextChanged and TextChangedI.
+	CheckDelay int64
+
 	// CoverFlags flags for cover command.
 	CoverFlags []string
 	// CoverMode mode of cover command.
 	CoverMode string
+	// CoverStyle rendering style of the coverage. "highlight" or "sign".
+	CoverStyle string
+	// CoverCounts shows the execution count of each blocks as the virtual text if covermode is count or atomic.
+	CoverCounts bool
+
+	// DiagnosticEcho echoes the error message of the cursor line at during the CursorHold.
+	DiagnosticEcho bool
+	// DiagnosticSigns places the error and warning signs of the error list.
+	DiagnosticSigns bool
+	// DiagnosticVirtualText shows the error list messages as the end of line virtual text.
+	DiagnosticVirtualText bool
 
 	// FmtAutosave call the GoFmt command automatically at during the BufWritePre.
 	FmtAutosave bool
@@ -212,6 +257,20 @@ var (
 	TestAll bool
 	// TestFlags test command default flags.
 	TestFlags []string
+	// TestJSON runs the test command with -json flag, and sets the structured results to the error list and signs instead of terminal.
+	TestJSON bool
+
+	// DelveEvalFloat shows the DlvEval result in the floating window instead of echo.
+	// The result is echoed on Neovim older than 0.4 which has no floating window.
+	DelveEvalFloat bool
+	// DelveMaxVariableRecurse how far to recurse the nested types of the evaluated variables.
+	DelveMaxVariableRecurse int64
+	// DelveMaxStringLen maximum number of bytes read from the evaluated string.
+	DelveMaxStringLen int64
+	// DelveMaxArrayValues maximum number of elements read from the evaluated array, slice or map.
+	DelveMaxArrayValues int64
+	// DelveBackend debugger backend of the Dlv commands, "rpc2" or "dap".
+	DelveBackend string
 
 	// DebugEnable Enable debugging.
 	DebugEnable bool
@@ -233,9 +292,20 @@ func Get(v *nvim.Nvim, cfg *Config) {
 	BuildFlags = cfg.Build.Flags
 	BuildIsNotGb = itob(cfg.Build.IsNotGb)
 
+	// Check
+	CheckEnable = itob(cfg.Check.Enable)
+	CheckDelay = cfg.Check.Delay
+
 	// Cover
 	CoverFlags = cfg.Cover.Flags
 	CoverMode = cfg.Cover.Mode
+	CoverStyle = cfg.Cover.Style
+	CoverCounts = itob(cfg.Cover.Counts)
+
+	// Diagnostic
+	DiagnosticEcho = itob(cfg.Diagnostic.Echo)
+	DiagnosticSigns = itob(cfg.Diagnostic.Signs)
+	DiagnosticVirtualText = itob(cfg.Diagnostic.VirtualText)
 
 	// Fmt
 	FmtAutosave = itob(cfg.Fmt.Autosave)
@@ -283,6 +353,14 @@ func Get(v *nvim.Nvim, cfg *Config) {
 	TestAutosave = itob(cfg.Test.Autosave)
 	TestAll = itob(cfg.Test.AllPackage)
 	TestFlags = cfg.Test.Flags
+	TestJSON = itob(cfg.Test.JSON)
+
+	// Delve
+	DelveEvalFloat = itob(cfg.Delve.EvalFloat)
+	DelveMaxVariableRecurse = cfg.Delve.MaxRecurse
+	DelveMaxStringLen = cfg.Delve.MaxStringLen
+	DelveMaxArrayValues = cfg.Delve.MaxArrayValues
+	DelveBackend = cfg.Delve.Backend
 
 	// Debug
 	DebugEnable = itob(cfg.Debug.Enable)
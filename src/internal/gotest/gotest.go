@@ -0,0 +1,120 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gotest resolves the go test functions and subtests from the Go source.
+package gotest
+
+import (
+	"go/ast"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// FuncArgs returns the go test flags that runs the test function or
+// subtest enclosing the pos.
+// The subtest names are only resolved from the string literal of the
+// t.Run(name, f) argument.
+func FuncArgs(f *ast.File, pos token.Pos) ([]string, error) {
+	path, _ := astutil.PathEnclosingInterval(f, pos, pos)
+
+	var (
+		funcName string
+		subtests []string // inner to outer
+	)
+	for _, n := range path {
+		switch x := n.(type) {
+		case *ast.CallExpr:
+			if name, ok := SubtestName(x); ok {
+				subtests = append(subtests, name)
+			}
+		case *ast.FuncDecl:
+			if x.Recv == nil && x.Name != nil {
+				funcName = x.Name.Name
+			}
+		}
+	}
+
+	if FuncKind(funcName) == "" {
+		return nil, errors.New("not found the Test, Benchmark or Example function at the cursor")
+	}
+
+	name := funcName
+	for i := len(subtests) - 1; i >= 0; i-- {
+		name += "/" + subtests[i]
+	}
+
+	return RunArgs(name), nil
+}
+
+// FuncKind returns the "Test", "Benchmark" or "Example" kind of the test
+// function name, or empty if name is not the test function.
+func FuncKind(name string) string {
+	for _, prefix := range []string{"Test", "Benchmark", "Example"} {
+		if isTestFunc(name, prefix) {
+			return prefix
+		}
+	}
+	return ""
+}
+
+// RunArgs returns the go test flags that runs only the name test.
+// The name is the full test name such as "TestFoo/sub_test".
+func RunArgs(name string) []string {
+	elems := strings.Split(name, "/")
+	kind := FuncKind(elems[0])
+	if kind == "Example" {
+		// Example does not have the subtests
+		elems = elems[:1]
+	}
+
+	pattern := make([]string, len(elems))
+	for i, elem := range elems {
+		pattern[i] = "^" + regexp.QuoteMeta(elem) + "$"
+	}
+
+	if kind == "Benchmark" {
+		return []string{"-run", "^$", "-bench", strings.Join(pattern, "/")}
+	}
+	return []string{"-run", strings.Join(pattern, "/")}
+}
+
+// SubtestName returns the subtest name if the call is t.Run or b.Run with
+// the string literal name.
+// The spaces of name are replaced to the underscore same as the testing package.
+func SubtestName(call *ast.CallExpr) (string, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Run" || len(call.Args) != 2 {
+		return "", false
+	}
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	name, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+
+	return strings.Replace(name, " ", "_", -1), true
+}
+
+// isTestFunc reports whether the name is the test function name with prefix.
+// Same as the go tool's isTest function.
+func isTestFunc(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	if len(name) == len(prefix) { // "Test" is ok
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+	return !unicode.IsLower(r)
+}
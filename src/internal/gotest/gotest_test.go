@@ -0,0 +1,90 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gotest
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testFuncSrc = `package foo
+
+import "testing"
+
+func TestFoo(t *testing.T) {
+	foo() // cursor:TestFoo
+	t.Run("bar baz", func(t *testing.T) {
+		t.Run("qux", func(t *testing.T) {
+			foo() // cursor:qux
+		})
+		foo() // cursor:bar
+	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			foo() // cursor:table
+		})
+	}
+}
+
+func BenchmarkFoo(b *testing.B) {
+	b.Run("small", func(b *testing.B) {
+		foo() // cursor:small
+	})
+}
+
+func ExampleFoo() {
+	foo() // cursor:ExampleFoo
+}
+
+func Testfoo(t *testing.T) {
+	foo() // cursor:Testfoo
+}
+
+func foo() {} // cursor:foo
+`
+
+func TestFuncArgs(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo_test.go", testFuncSrc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		cursor  string
+		want    []string
+		wantErr bool
+	}{
+		{cursor: "TestFoo", want: []string{"-run", "^TestFoo$"}},
+		{cursor: "bar", want: []string{"-run", "^TestFoo$/^bar_baz$"}},
+		{cursor: "qux", want: []string{"-run", "^TestFoo$/^bar_baz$/^qux$"}},
+		{cursor: "table", want: []string{"-run", "^TestFoo$"}},
+		{cursor: "small", want: []string{"-run", "^$", "-bench", "^BenchmarkFoo$/^small$"}},
+		{cursor: "ExampleFoo", want: []string{"-run", "^ExampleFoo$"}},
+		{cursor: "Testfoo", wantErr: true},
+		{cursor: "foo", wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.cursor, func(t *testing.T) {
+			offset := strings.Index(testFuncSrc, "// cursor:"+tt.cursor+"\n")
+			if offset < 0 {
+				t.Fatalf("not found the cursor %q", tt.cursor)
+			}
+			pos := fset.File(f.Pos()).Pos(offset)
+
+			got, err := FuncArgs(f, pos)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FuncArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FuncArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
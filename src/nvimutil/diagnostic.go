@@ -0,0 +1,378 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nvimutil
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/pkg/errors"
+	"github.com/zchee/nvim-go/src/config"
+)
+
+// Severity types of the diagnostics. Same as the nvim.QuickfixError Type.
+const (
+	SeverityError   = "E"
+	SeverityWarning = "W"
+	SeverityInfo    = "I"
+)
+
+// Severity returns the severity type of the quickfix error.
+// The go tools does not set the error type, so treats the unknown type as the error.
+func Severity(e *nvim.QuickfixError) string {
+	switch strings.ToUpper(e.Type) {
+	case SeverityWarning:
+		return SeverityWarning
+	case SeverityInfo:
+		return SeverityInfo
+	default:
+		return SeverityError
+	}
+}
+
+// severityRank returns the higher number if the severity is important.
+func severityRank(severity string) int {
+	switch severity {
+	case SeverityError:
+		return 2
+	case SeverityWarning:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// DiagnosticHighlight returns the highlight group name of the severity.
+func DiagnosticHighlight(severity string) string {
+	return diagnosticHighlights[severity].name
+}
+
+var diagnosticHighlights = map[string]struct {
+	name string
+	link string
+	text string // sign text
+}{
+	SeverityError:   {name: "GoDiagnosticError", link: "ErrorMsg", text: ">>"},
+	SeverityWarning: {name: "GoDiagnosticWarning", link: "WarningMsg", text: ">>"},
+	SeverityInfo:    {name: "GoDiagnosticInfo", link: "Comment", text: "--"},
+}
+
+const (
+	// diagnosticSignID is the base id of the diagnostic signs. Avoids conflict with
+	// the delve breakpoint and program counter sign ids, and the coverage sign ids
+	// which start from 20000.
+	diagnosticSignID = 10000
+	// diagnosticSignRange is the number of the sign ids of each source. The ids
+	// are reused by each refresh, and the signs over the range are not placed.
+	diagnosticSignRange = 500
+	// diagnosticSignSources is the number of the sources that can place the signs.
+	diagnosticSignSources = 20
+)
+
+// diagnosticSource represents the placed signs and virtual text of the one error list source.
+type diagnosticSource struct {
+	namespace int           // namespace id of the virtual text
+	signID    int           // the first sign id of the source, or 0 if no ids are left
+	signs     map[int][]int // map[bufnr][]sign id
+	texts     map[int]bool  // map[bufnr]bool of the virtual text placed buffers
+}
+
+var diagnostics = struct {
+	sync.Mutex
+	defined     bool
+	virtualText bool // whether the Neovim supports the virtual text
+	sources     map[string]*diagnosticSource
+}{
+	sources: make(map[string]*diagnosticSource),
+}
+
+// defineDiagnostics defines the diagnostic highlights and signs.
+func defineDiagnostics(v *nvim.Nvim) error {
+	if diagnostics.defined {
+		return nil
+	}
+
+	for severity, hl := range diagnosticHighlights {
+		if err := v.Command(fmt.Sprintf("highlight default link %s %s", hl.name, hl.link)); err != nil {
+			return errors.WithStack(err)
+		}
+		if _, err := NewSign(v, hl.name, hl.text, DiagnosticHighlight(severity), ""); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	var hasVirtualText int
+	if err := v.Call("has", &hasVirtualText, "nvim-0.3.2"); err != nil {
+		return errors.WithStack(err)
+	}
+	diagnostics.virtualText = hasVirtualText == 1
+	diagnostics.defined = true
+
+	return nil
+}
+
+// diagnosticLine represents the errors of the same line.
+type diagnosticLine struct {
+	FileName string
+	Bufnr    int
+	LNum     int
+	Severity string // the most important severity of errors
+	Errors   []*nvim.QuickfixError
+}
+
+// diagnosticLines groups the errlist by the file and line.
+func diagnosticLines(errlist []*nvim.QuickfixError) []*diagnosticLine {
+	type key struct {
+		fname string
+		bufnr int
+		lnum  int
+	}
+	lines := make(map[key]*diagnosticLine)
+	var keys []key
+	for _, e := range errlist {
+		if e.LNum <= 0 {
+			continue
+		}
+		k := key{fname: e.FileName, bufnr: e.Bufnr, lnum: e.LNum}
+		l, ok := lines[k]
+		if !ok {
+			l = &diagnosticLine{FileName: e.FileName, Bufnr: e.Bufnr, LNum: e.LNum, Severity: Severity(e)}
+			lines[k] = l
+			keys = append(keys, k)
+		}
+		if severityRank(Severity(e)) > severityRank(l.Severity) {
+			l.Severity = Severity(e)
+		}
+		l.Errors = append(l.Errors, e)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].fname != keys[j].fname {
+			return keys[i].fname < keys[j].fname
+		}
+		if keys[i].bufnr != keys[j].bufnr {
+			return keys[i].bufnr < keys[j].bufnr
+		}
+		return keys[i].lnum < keys[j].lnum
+	})
+
+	dlines := make([]*diagnosticLine, len(keys))
+	for i, k := range keys {
+		dlines[i] = lines[k]
+	}
+
+	return dlines
+}
+
+// virtualTextChunks returns the virtual text chunks of the l line.
+func virtualTextChunks(l *diagnosticLine) [][]string {
+	chunks := make([][]string, 0, len(l.Errors)*2)
+	for i, e := range l.Errors {
+		if i > 0 {
+			chunks = append(chunks, []string{" "})
+		}
+		chunks = append(chunks, []string{"■ " + strings.TrimSpace(e.Text), DiagnosticHighlight(Severity(e))})
+	}
+
+	return chunks
+}
+
+// SetDiagnostics places the per-severity signs and end of line virtual text
+// of the errmap which keyed by the error list source such as "Build" and "Vet".
+// Only the sources in the errmap are replaced, and the other sources are kept because
+// the error lists are stored by the separate commands and autocmds.
+// The source that has no errors should be in the errmap with the empty list to clear it.
+func SetDiagnostics(v *nvim.Nvim, errmap map[string][]*nvim.QuickfixError) error {
+	diagnostics.Lock()
+	defer diagnostics.Unlock()
+
+	if !config.DiagnosticSigns && !config.DiagnosticVirtualText {
+		return nil
+	}
+	if err := defineDiagnostics(v); err != nil {
+		return err
+	}
+
+	sources := make([]string, 0, len(errmap))
+	for source := range errmap {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	bufnrs := make(map[string]int) // cache of the buffer number
+	for _, source := range sources {
+		if err := clearDiagnostics(v, source); err != nil {
+			return err
+		}
+		if err := setDiagnostics(v, source, errmap[source], bufnrs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setDiagnostics places the diagnostics of the source.
+func setDiagnostics(v *nvim.Nvim, source string, errlist []*nvim.QuickfixError, bufnrs map[string]int) error {
+	s, ok := diagnostics.sources[source]
+	if !ok {
+		s = &diagnosticSource{signs: make(map[int][]int), texts: make(map[int]bool)}
+		if n := len(diagnostics.sources); n < diagnosticSignSources {
+			s.signID = diagnosticSignID + n*diagnosticSignRange
+		}
+		if diagnostics.virtualText {
+			if err := v.Call("nvim_create_namespace", &s.namespace, "nvim-go-diagnostic-"+source); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		diagnostics.sources[source] = s
+	}
+
+	b := v.NewBatch()
+	id := s.signID // the signs of the source are unplaced before, so reuses the ids
+	for _, l := range diagnosticLines(errlist) {
+		bufnr := l.Bufnr
+		if bufnr == 0 {
+			n, ok := bufnrs[l.FileName]
+			if !ok {
+				var fname string
+				if err := v.Call("fnamemodify", &fname, l.FileName, ":p"); err != nil {
+					return errors.WithStack(err)
+				}
+				if err := v.Call("bufnr", &n, fname); err != nil {
+					return errors.WithStack(err)
+				}
+				bufnrs[l.FileName] = n
+			}
+			bufnr = n
+		}
+		// not loaded buffer
+		if bufnr <= 0 {
+			continue
+		}
+
+		if config.DiagnosticSigns && s.signID != 0 && id < s.signID+diagnosticSignRange {
+			b.Command(fmt.Sprintf("sign place %d name=%s line=%d buffer=%d", id, DiagnosticHighlight(l.Severity), l.LNum, bufnr))
+			s.signs[bufnr] = append(s.signs[bufnr], id)
+			id++
+		}
+		if config.DiagnosticVirtualText && diagnostics.virtualText {
+			b.Call("nvim_buf_set_virtual_text", nil, bufnr, s.namespace, l.LNum-1, virtualTextChunks(l), make(map[string]interface{}))
+			s.texts[bufnr] = true
+		}
+	}
+
+	return errors.WithStack(b.Execute())
+}
+
+// ClearDiagnostics clears the diagnostics of the sources.
+// ClearDiagnostics clears the all sources diagnostics if sources is empty.
+func ClearDiagnostics(v *nvim.Nvim, sources ...string) error {
+	diagnostics.Lock()
+	defer diagnostics.Unlock()
+
+	if len(sources) == 0 {
+		for source := range diagnostics.sources {
+			sources = append(sources, source)
+		}
+	}
+	for _, source := range sources {
+		if err := clearDiagnostics(v, source); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// clearDiagnostics unplaces the signs and clears the virtual text of the source.
+func clearDiagnostics(v *nvim.Nvim, source string) error {
+	s, ok := diagnostics.sources[source]
+	if !ok || len(s.signs) == 0 && len(s.texts) == 0 {
+		return nil
+	}
+
+	// the buffer might be already wiped out, so ignore the errors
+	b := v.NewBatch()
+	for bufnr, ids := range s.signs {
+		for _, id := range ids {
+			b.Command(fmt.Sprintf("silent! sign unplace %d buffer=%d", id, bufnr))
+		}
+	}
+	for bufnr := range s.texts {
+		b.Command(fmt.Sprintf("silent! call nvim_buf_clear_namespace(%d, %d, 0, -1)", bufnr, s.namespace))
+	}
+	s.signs = make(map[int][]int)
+	s.texts = make(map[int]bool)
+
+	return errors.WithStack(b.Execute())
+}
+
+// LineDiagnostic returns the most important error of the line in the file
+// from the errmap, and the source name of the error.
+// The cwd is used to resolve the relative filename of errors.
+func LineDiagnostic(errmap map[string][]*nvim.QuickfixError, cwd, file string, bufnr, line int) (string, *nvim.QuickfixError) {
+	sources := make([]string, 0, len(errmap))
+	for source := range errmap {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	var (
+		source string
+		found  *nvim.QuickfixError
+	)
+	for _, s := range sources {
+		for _, e := range errmap[s] {
+			if e.LNum != line {
+				continue
+			}
+			switch {
+			case e.Bufnr != 0 && e.Bufnr != bufnr:
+				continue
+			case e.Bufnr == 0 && e.FileName != file && filepath.Join(cwd, e.FileName) != file:
+				continue
+			}
+			if found == nil ||
+				severityRank(Severity(e)) > severityRank(Severity(found)) ||
+				severityRank(Severity(e)) == severityRank(Severity(found)) && e.Col < found.Col {
+				source, found = s, e
+			}
+		}
+	}
+
+	return source, found
+}
+
+var echoEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ")
+
+// EchoDiagnostic echoes the error message of the source with the severity highlight.
+// The message is truncated to the width of the command line to avoid the hit-enter prompt.
+func EchoDiagnostic(v *nvim.Nvim, source string, e *nvim.QuickfixError) error {
+	diagnostics.Lock()
+	err := defineDiagnostics(v)
+	diagnostics.Unlock()
+	if err != nil {
+		return err
+	}
+
+	var columns int
+	if err := v.Option("columns", &columns); err != nil {
+		return errors.WithStack(err)
+	}
+
+	msg := []rune(fmt.Sprintf("%s: %s", source, strings.TrimSpace(e.Text)))
+	// 12 is the width of the ruler
+	if max := columns - 12; max > 0 && len(msg) > max {
+		msg = append(msg[:max-3], []rune("...")...)
+	}
+
+	cmd := fmt.Sprintf("echohl %s | echo \"%s\" | echohl None", DiagnosticHighlight(Severity(e)), echoEscaper.Replace(string(msg)))
+	return errors.WithStack(v.Command(cmd))
+}
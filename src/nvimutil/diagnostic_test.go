@@ -0,0 +1,219 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nvimutil
+
+import (
+	"os/exec"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/zchee/nvim-go/src/config"
+)
+
+func TestDiagnosticLines(t *testing.T) {
+	var (
+		aErr  = &nvim.QuickfixError{FileName: "a.go", LNum: 10, Col: 2, Text: "undefined: foo"}
+		aWarn = &nvim.QuickfixError{FileName: "a.go", LNum: 10, Col: 6, Text: "unused variable", Type: "W"}
+		aInfo = &nvim.QuickfixError{FileName: "a.go", LNum: 3, Text: "should have comment", Type: "I"}
+		bWarn = &nvim.QuickfixError{FileName: "b.go", LNum: 1, Text: "unreachable code", Type: "w"}
+		noPos = &nvim.QuickfixError{FileName: "b.go", Text: "package error"}
+	)
+
+	tests := []struct {
+		name    string
+		errlist []*nvim.QuickfixError
+		want    []*diagnosticLine
+	}{
+		{
+			name:    "empty",
+			errlist: nil,
+			want:    []*diagnosticLine{},
+		},
+		{
+			name:    "sorted by file and line",
+			errlist: []*nvim.QuickfixError{bWarn, aInfo},
+			want: []*diagnosticLine{
+				{FileName: "a.go", LNum: 3, Severity: SeverityInfo, Errors: []*nvim.QuickfixError{aInfo}},
+				{FileName: "b.go", LNum: 1, Severity: SeverityWarning, Errors: []*nvim.QuickfixError{bWarn}},
+			},
+		},
+		{
+			name:    "same line uses the most important severity",
+			errlist: []*nvim.QuickfixError{aWarn, aErr, noPos},
+			want: []*diagnosticLine{
+				{FileName: "a.go", LNum: 10, Severity: SeverityError, Errors: []*nvim.QuickfixError{aWarn, aErr}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diagnosticLines(tt.errlist); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diagnosticLines(%v) = %v, want %v", tt.errlist, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVirtualTextChunks(t *testing.T) {
+	l := &diagnosticLine{
+		Errors: []*nvim.QuickfixError{
+			{Text: "undefined: foo "},
+			{Text: "unused variable", Type: "W"},
+		},
+	}
+	want := [][]string{
+		{"■ undefined: foo", "GoDiagnosticError"},
+		{" "},
+		{"■ unused variable", "GoDiagnosticWarning"},
+	}
+	if got := virtualTextChunks(l); !reflect.DeepEqual(got, want) {
+		t.Errorf("virtualTextChunks(%v) = %v, want %v", l, got, want)
+	}
+}
+
+func TestLineDiagnostic(t *testing.T) {
+	var (
+		buildErr = &nvim.QuickfixError{FileName: "a.go", LNum: 10, Col: 8, Text: "undefined: foo"}
+		vetWarn  = &nvim.QuickfixError{FileName: "a.go", LNum: 10, Col: 2, Text: "unreachable code", Type: "W"}
+		lintErr  = &nvim.QuickfixError{FileName: "/src/pkg/a.go", LNum: 20, Col: 4, Text: "exported func should have comment"}
+		lintErr2 = &nvim.QuickfixError{FileName: "/src/pkg/a.go", LNum: 20, Col: 1, Text: "don't use underscores"}
+		bufErr   = &nvim.QuickfixError{Bufnr: 2, LNum: 30, Text: "expected declaration"}
+	)
+	errmap := map[string][]*nvim.QuickfixError{
+		"Build": {buildErr},
+		"Vet":   {vetWarn},
+		"Lint":  {lintErr, lintErr2},
+		"Check": {bufErr},
+	}
+
+	type args struct {
+		file  string
+		bufnr int
+		line  int
+	}
+	tests := []struct {
+		name       string
+		args       args
+		wantSource string
+		want       *nvim.QuickfixError
+	}{
+		{
+			name:       "the error is prior to the warning",
+			args:       args{file: "/src/pkg/a.go", bufnr: 1, line: 10},
+			wantSource: "Build",
+			want:       buildErr,
+		},
+		{
+			name:       "smaller column",
+			args:       args{file: "/src/pkg/a.go", bufnr: 1, line: 20},
+			wantSource: "Lint",
+			want:       lintErr2,
+		},
+		{
+			name:       "buffer number",
+			args:       args{file: "/src/pkg/b.go", bufnr: 2, line: 30},
+			wantSource: "Check",
+			want:       bufErr,
+		},
+		{
+			name: "other buffer",
+			args: args{file: "/src/pkg/a.go", bufnr: 1, line: 30},
+		},
+		{
+			name: "other file",
+			args: args{file: "/src/pkg/b.go", bufnr: 2, line: 10},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			gotSource, got := LineDiagnostic(errmap, "/src/pkg", tt.args.file, tt.args.bufnr, tt.args.line)
+			if gotSource != tt.wantSource || got != tt.want {
+				t.Errorf("LineDiagnostic(%v) = (%v, %v), want (%v, %v)", tt.args, gotSource, got, tt.wantSource, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetDiagnostics(t *testing.T) {
+	if _, err := exec.LookPath("nvim"); err != nil {
+		t.Skip("nvim not found")
+	}
+	v := TestNvim(t)
+
+	defer func(signs, texts bool) {
+		config.DiagnosticSigns, config.DiagnosticVirtualText = signs, texts
+	}(config.DiagnosticSigns, config.DiagnosticVirtualText)
+	config.DiagnosticSigns, config.DiagnosticVirtualText = true, false
+	diagnostics.defined = false
+	diagnostics.sources = make(map[string]*diagnosticSource)
+
+	if err := v.SetBufferLines(0, 0, -1, true, [][]byte{[]byte("a"), []byte("b")}); err != nil {
+		t.Fatal(err)
+	}
+	placed := func() []string {
+		var out string
+		if err := v.Call("execute", &out, "sign place buffer=1"); err != nil {
+			t.Fatal(err)
+		}
+		var lines []string
+		for _, line := range strings.Split(out, "\n") {
+			if strings.Contains(line, "name=GoDiagnostic") {
+				lines = append(lines, strings.TrimSpace(line[:strings.Index(line, "id=")]))
+			}
+		}
+		sort.Strings(lines)
+		return lines
+	}
+
+	steps := []struct {
+		name   string
+		errmap map[string][]*nvim.QuickfixError
+		want   []string
+	}{
+		{
+			name:   "Build",
+			errmap: map[string][]*nvim.QuickfixError{"Build": {{Bufnr: 1, LNum: 1, Text: "undefined: foo"}}},
+			want:   []string{"line=1"},
+		},
+		{
+			name:   "Lint keeps Build",
+			errmap: map[string][]*nvim.QuickfixError{"Lint": {{Bufnr: 1, LNum: 2, Text: "should have comment", Type: "W"}}},
+			want:   []string{"line=1", "line=2"},
+		},
+		{
+			name:   "clean Build clears only Build",
+			errmap: map[string][]*nvim.QuickfixError{"Build": nil},
+			want:   []string{"line=2"},
+		},
+	}
+	for _, step := range steps {
+		if err := SetDiagnostics(v, step.errmap); err != nil {
+			t.Fatalf("%s: SetDiagnostics() error = %v", step.name, err)
+		}
+		if got := placed(); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: placed signs = %q, want %q", step.name, got, step.want)
+		}
+	}
+
+	// the refreshes reuse the sign ids of the source range
+	lintErrs := map[string][]*nvim.QuickfixError{"Lint": {{Bufnr: 1, LNum: 2, Text: "should have comment", Type: "W"}}}
+	for i := 0; i < 3; i++ {
+		if err := SetDiagnostics(v, lintErrs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	build, lint := diagnostics.sources["Build"], diagnostics.sources["Lint"]
+	if build.signID == lint.signID || lint.signID < diagnosticSignID || lint.signID >= diagnosticSignID+diagnosticSignSources*diagnosticSignRange {
+		t.Errorf("sign ids of Build = %d, Lint = %d, want the separate ranges", build.signID, lint.signID)
+	}
+	if got, want := lint.signs[1], []int{lint.signID}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Lint sign ids = %v, want %v", got, want)
+	}
+}
@@ -69,20 +69,25 @@ func getListCmd(v *nvim.Nvim) {
 }
 
 // ErrorList merges the errlist map items and open the locationlist window.
+// ErrorList also sets the diagnostics of errors map for each source.
 func ErrorList(v *nvim.Nvim, errors map[string][]*nvim.QuickfixError, keep bool) error {
 	if listtype == "" {
 		getListCmd(v)
 	}
 
-	if errors == nil || len(errors) == 0 {
-		defer clearlistCmd()
-		return closelistCmd()
+	if err := SetDiagnostics(v, errors); err != nil {
+		return err
 	}
 
 	var errlist []*nvim.QuickfixError
 	for _, err := range errors {
 		errlist = append(errlist, err...)
 	}
+	if len(errlist) == 0 {
+		defer clearlistCmd()
+		return closelistCmd()
+	}
+
 	if err := SetErrorlist(v, errlist); err != nil {
 		return err
 	}
@@ -106,12 +111,16 @@ func SetErrorlist(v *nvim.Nvim, errlist []*nvim.QuickfixError) error {
 	return setlistCmd(errlist)
 }
 
-// ClearErrorlist clear the Neovim error list.
+// ClearErrorlist clear the Neovim error list and the all diagnostics.
 func ClearErrorlist(v *nvim.Nvim, close bool) error {
 	if clearlistCmd == nil {
 		getListCmd(v)
 	}
 
+	if err := ClearDiagnostics(v); err != nil {
+		return err
+	}
+
 	if close {
 		defer closelistCmd()
 	}
@@ -249,6 +258,20 @@ func ParseError(errs []byte, cwd string, buildContext *buildctx.Build, ignoreDir
 			default:
 				filename = pathutil.JoinGoPath(filename)
 			}
+		case "mod":
+			switch {
+			// filename has not directory path
+			case filepath.Dir(filename) == ".":
+				filename = filepath.Join(cwd, filename)
+
+			// filename is like "example.com/foo/bar.go" that joined the "# " package path
+			case strings.HasPrefix(filename, buildContext.ModulePath+"/"):
+				filename = filepath.Join(buildContext.ModuleRoot, strings.TrimPrefix(filename, buildContext.ModulePath+"/"))
+
+			// go command error messages is relative filename path of cwd
+			case !filepath.IsAbs(filename):
+				filename = filepath.Join(cwd, filename)
+			}
 		case "gb":
 			// gb compiler error messages is relative filename path of project root dir
 			if !filepath.IsAbs(filename) {
@@ -258,6 +258,60 @@ FATAL: command "build" failed: exit status 2`),
 			},
 			wantErr: false,
 		},
+		{
+			name: "mod build",
+			args: args{
+				errors: []byte(`# foo.org/mod/bar
+bar/bar.go:8:2: undefined: baz
+# foo.org/mod
+./mod.go:12:9: cannot use 1 (type int) as type string in return argument`),
+				cwd: "/src/mod",
+				buildContext: &buildctx.Build{
+					Tool:        "mod",
+					ProjectRoot: "/src/mod",
+					ModulePath:  "foo.org/mod",
+					ModuleRoot:  "/src/mod",
+				},
+			},
+			want: []*nvim.QuickfixError{
+				{
+					FileName: "bar/bar.go",
+					LNum:     8,
+					Col:      2,
+					Text:     "undefined: baz",
+				},
+				{
+					FileName: "mod.go",
+					LNum:     12,
+					Col:      9,
+					Text:     "cannot use 1 (type int) as type string in return argument",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "mod build (sub directory cwd)",
+			args: args{
+				errors: []byte(`# foo.org/mod
+mod.go:12:9: cannot use 1 (type int) as type string in return argument`),
+				cwd: "/src/mod/bar",
+				buildContext: &buildctx.Build{
+					Tool:        "mod",
+					ProjectRoot: "/src/mod",
+					ModulePath:  "foo.org/mod",
+					ModuleRoot:  "/src/mod",
+				},
+			},
+			want: []*nvim.QuickfixError{
+				{
+					FileName: "../mod.go",
+					LNum:     12,
+					Col:      9,
+					Text:     "cannot use 1 (type int) as type string in return argument",
+				},
+			},
+			wantErr: false,
+		},
 	}
 
 	build.Default.GOPATH = gopath
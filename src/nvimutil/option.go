@@ -91,4 +91,8 @@ const (
 	FiletypeTerminal = "terminal"
 	// FiletypeGoTerminal represents a go-terminal filetype.
 	FiletypeGoTerminal = "go-terminal"
+	// FiletypeGoTestExplorer represents a gotestexplorer filetype.
+	FiletypeGoTestExplorer = "gotestexplorer"
+	// FiletypeGoCoverReport represents a gocoverreport filetype.
+	FiletypeGoCoverReport = "gocoverreport"
 )
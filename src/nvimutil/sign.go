@@ -20,6 +20,10 @@ const (
 	//
 	// ⬤  BLACK LARGE CIRCLE                   (U+2B24)
 	BreakpointSymbolLarge = "\u2b24"
+	// ConditionalBreakpointSymbol symbol of conditional breakpoint.
+	//
+	// ◐  CIRCLE WITH LEFT HALF BLACK          (U+25D0)
+	ConditionalBreakpointSymbol = "\u25d0"
 	// TracepointSymbol symbol of tracepoint.
 	//
 	// ◆  BLACK DIAMOND                        (U+25C6)
@@ -43,6 +47,14 @@ const (
 	// RestartSymbol symbol of restart.
 	// ⟲  ANTICLOCKWISE GAPPED CIRCLE ARROW    (U+27F2)
 	RestartSymbol = "\u27f2"
+	// PassSymbol symbol of passed test.
+	//
+	// ✓  CHECK MARK                           (U+2713)
+	PassSymbol = "\u2713"
+	// FailSymbol symbol of failed test.
+	//
+	// ✗  BALLOT X                             (U+2717)
+	FailSymbol = "\u2717"
 )
 
 // Sign represents a Neovim sign.
@@ -12,19 +12,19 @@ import (
 )
 
 // parsePackage search the parent directory of dir with the recursive loop.
-func parsePackage(dir string) (*build.Package, error) {
+func parsePackage(buildContext build.Context, dir string) (*build.Package, error) {
 	dir = filepath.Clean(dir)
 
 	// for save the before(child) package information
 	savePkg := new(build.Package)
 	for {
 		// Raise the error if dir is reaches root("/") or GOPATH or GOROOT
-		if dir == "/" || dir == build.Default.GOPATH || dir == build.Default.GOROOT {
+		if dir == "/" || dir == buildContext.GOPATH || dir == buildContext.GOROOT {
 			return nil, errors.New("couldn't find the package")
 		}
 
 		// Get the current dir package information
-		pkg, err := build.Default.ImportDir(dir, build.ImportMode(0))
+		pkg, err := buildContext.ImportDir(dir, build.ImportMode(0))
 		if err != nil {
 			// Check the exists .go file in the dir
 			if _, ok := err.(*build.NoGoError); ok {
@@ -52,6 +52,11 @@ func parsePackage(dir string) (*build.Package, error) {
 			return savePkg, nil
 		}
 
+		// Do not go up beyond the module root directory
+		if IsExist(filepath.Join(dir, "go.mod")) {
+			return pkg, nil
+		}
+
 		// Save the current package and re-assign dir to parent dir for the next recursive loop
 		savePkg = pkg
 		dir = filepath.Dir(dir)
@@ -63,7 +68,7 @@ func parsePackage(dir string) (*build.Package, error) {
 // like:
 //  return "/Users/zchee/go/src/github.com/pkg/errors", nil
 func PackagePath(dir string) (string, error) {
-	pkg, err := parsePackage(dir)
+	pkg, err := parsePackage(build.Default, dir)
 	if err != nil {
 		return "", err
 	}
@@ -73,14 +78,24 @@ func PackagePath(dir string) (string, error) {
 
 // PackageID returns the package ID(ImportPath) estimated from the dir
 // directory structure.
+// If dir is inside the Go modules, the ImportPath is based on the module path.
 // like:
 //  return "github.com/pkg/errors", nil
 func PackageID(dir string) (string, error) {
-	pkg, err := parsePackage(dir)
+	return PackageIDContext(build.Default, dir)
+}
+
+// PackageIDContext is like PackageID but uses the buildContext instead of go/build.Default.
+func PackageIDContext(buildContext build.Context, dir string) (string, error) {
+	pkg, err := parsePackage(buildContext, dir)
 	if err != nil {
 		return "", err
 	}
 
+	if root, modPath, ok := IsModule(pkg.Dir); ok {
+		return ModuleImportPath(root, modPath, pkg.Dir)
+	}
+
 	return pkg.ImportPath, nil
 }
 
@@ -0,0 +1,105 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathutil
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// IsModule check the dir whether inside the Go modules directory structure.
+// Return the module root directory, module path and boolean.
+func IsModule(dir string) (string, string, bool) {
+	root, err := FindModuleRoot(dir)
+	if err != nil {
+		return "", "", false
+	}
+
+	gomod, err := ioutil.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "", "", false
+	}
+	modPath := ModulePath(gomod)
+	if modPath == "" {
+		return "", "", false
+	}
+
+	return root, modPath, true
+}
+
+// FindModuleRoot works upwards from dir searching for the go.mod file
+// which identifies the module root.
+func FindModuleRoot(dir string) (string, error) {
+	if dir == "" {
+		return "", errors.New("module root is blank")
+	}
+	dir = filepath.Clean(dir)
+
+	start := dir
+	for {
+		if gomod := filepath.Join(dir, "go.mod"); IsExist(gomod) && !IsDir(gomod) {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return "", fmt.Errorf(`could not find go.mod in "%s" or its parents`, start)
+}
+
+// ModulePath returns the module path from the go.mod file data.
+// Return the empty string if gomod does not have the module directive.
+func ModulePath(gomod []byte) string {
+	s := bufio.NewScanner(bytes.NewReader(gomod))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if !strings.HasPrefix(line, "module") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "module"))
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if strings.HasPrefix(line, `"`) || strings.HasPrefix(line, "`") {
+			p, err := strconv.Unquote(line)
+			if err != nil {
+				return ""
+			}
+			return p
+		}
+		return line
+	}
+
+	return ""
+}
+
+// ModuleImportPath returns the import path of dir that inside the module root
+// directory with modPath.
+// like:
+//  return "github.com/pkg/errors/internal", nil
+func ModuleImportPath(root, modPath, dir string) (string, error) {
+	if !IsSubdir(root, dir) {
+		return "", errors.Errorf("%s is outside of module %s", dir, modPath)
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if rel == "." {
+		return modPath, nil
+	}
+
+	return modPath + "/" + filepath.ToSlash(rel), nil
+}
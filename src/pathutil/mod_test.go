@@ -0,0 +1,147 @@
+// Copyright 2018 The nvim-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathutil_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/zchee/nvim-go/src/pathutil"
+)
+
+func TestIsModule(t *testing.T) {
+	testModRoot, _ := filepath.Abs(filepath.Join("testdata", "mod"))
+
+	type args struct {
+		dir string
+	}
+	tests := []struct {
+		name  string
+		args  args
+		want  string
+		want1 string
+		want2 bool
+	}{
+		{
+			name:  "module root",
+			args:  args{dir: testModRoot},
+			want:  testModRoot,
+			want1: "foo.org/mod",
+			want2: true,
+		},
+		{
+			name:  "sub package",
+			args:  args{dir: filepath.Join(testModRoot, "bar")},
+			want:  testModRoot,
+			want1: "foo.org/mod",
+			want2: true,
+		},
+		{
+			name:  "gopath",
+			args:  args{dir: filepath.Join(testdataPath, "go", "src", "astdump")},
+			want:  "",
+			want1: "",
+			want2: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, got1, got2 := pathutil.IsModule(tt.args.dir)
+			if got != tt.want {
+				t.Errorf("IsModule(%v) got = %v, want %v", tt.args.dir, got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("IsModule(%v) got1 = %v, want %v", tt.args.dir, got1, tt.want1)
+			}
+			if got2 != tt.want2 {
+				t.Errorf("IsModule(%v) got2 = %v, want %v", tt.args.dir, got2, tt.want2)
+			}
+		})
+	}
+}
+
+func TestModulePath(t *testing.T) {
+	tests := []struct {
+		name  string
+		gomod string
+		want  string
+	}{
+		{
+			name:  "simple",
+			gomod: "module github.com/zchee/nvim-go\n",
+			want:  "github.com/zchee/nvim-go",
+		},
+		{
+			name:  "with require",
+			gomod: "module github.com/zchee/nvim-go\n\nrequire (\n\tgithub.com/pkg/errors v0.8.0\n)\n",
+			want:  "github.com/zchee/nvim-go",
+		},
+		{
+			name:  "quoted with comment",
+			gomod: "// nvim-go\nmodule \"github.com/zchee/nvim-go\" // comment\n",
+			want:  "github.com/zchee/nvim-go",
+		},
+		{
+			name:  "no module directive",
+			gomod: "require github.com/pkg/errors v0.8.0\n",
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pathutil.ModulePath([]byte(tt.gomod)); got != tt.want {
+				t.Errorf("ModulePath(%q) = %v, want %v", tt.gomod, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModuleImportPath(t *testing.T) {
+	type args struct {
+		root    string
+		modPath string
+		dir     string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "module root",
+			args:    args{root: "/src/mod", modPath: "foo.org/mod", dir: "/src/mod"},
+			want:    "foo.org/mod",
+			wantErr: false,
+		},
+		{
+			name:    "sub package",
+			args:    args{root: "/src/mod", modPath: "foo.org/mod", dir: "/src/mod/bar/baz"},
+			want:    "foo.org/mod/bar/baz",
+			wantErr: false,
+		},
+		{
+			name:    "outside of module",
+			args:    args{root: "/src/mod", modPath: "foo.org/mod", dir: "/src/other"},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pathutil.ModuleImportPath(tt.args.root, tt.args.modPath, tt.args.dir)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ModuleImportPath(%v, %v, %v) error = %v, wantErr %v", tt.args.root, tt.args.modPath, tt.args.dir, err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ModuleImportPath(%v, %v, %v) = %v, want %v", tt.args.root, tt.args.modPath, tt.args.dir, got, tt.want)
+			}
+		})
+	}
+}
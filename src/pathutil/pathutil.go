@@ -76,6 +76,15 @@ func Rel(cwd, f string) string {
 	return rel
 }
 
+// IsSubdir reports whether the dir is root or the sub directory of root.
+func IsSubdir(root, dir string) bool {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 // ToWildcard returns the path with wildcard(...) suffix.
 func ToWildcard(path string) string {
 	return filepath.Join(path, string(filepath.Separator), "...")
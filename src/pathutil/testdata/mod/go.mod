@@ -0,0 +1 @@
+module foo.org/mod // comment
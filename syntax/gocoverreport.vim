@@ -0,0 +1,26 @@
+" Copyright 2018 The nvim-go Authors. All rights reserved.
+" Use of this source code is governed by a BSD-style
+" license that can be found in the LICENSE file.
+
+" ----------------------------------------------------------------------------
+" initialize
+
+if exists("b:current_syntax")
+  finish
+endif
+
+" ----------------------------------------------------------------------------
+" set syntax highlight
+
+syn match GoCoverReportPackage  /^\S\+/
+syn match GoCoverReportFile     /^\s\+\zs\S\+:\d\+:/
+syn match GoCoverReportPercent  /\<\d\+\.\d%$/
+syn match GoCoverReportZero     /\<0\.0%$/
+
+hi def link GoCoverReportPackage  Directory
+hi def link GoCoverReportFile     Comment
+hi def link GoCoverReportPercent  Number
+hi def link GoCoverReportZero     ErrorMsg
+
+" ----------------------------------------------------------------------------
+let b:current_syntax = "gocoverreport"
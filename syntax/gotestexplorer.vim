@@ -0,0 +1,26 @@
+" Copyright 2018 The nvim-go Authors. All rights reserved.
+" Use of this source code is governed by a BSD-style
+" license that can be found in the LICENSE file.
+
+" ----------------------------------------------------------------------------
+" initialize
+
+if exists("b:current_syntax")
+  finish
+endif
+
+" ----------------------------------------------------------------------------
+" set syntax highlight
+
+syn match GoTestExplorerPackage /^\S.*$/ contains=GoTestExplorerPass,GoTestExplorerFail,GoTestExplorerSkip
+syn match GoTestExplorerPass    /✓/
+syn match GoTestExplorerFail    /✗/
+syn match GoTestExplorerSkip    /^\s*\zs-\ze /
+
+hi def link GoTestExplorerPackage Directory
+hi def link GoTestExplorerPass    Statement
+hi def link GoTestExplorerFail    Identifier
+hi def link GoTestExplorerSkip    Comment
+
+" ----------------------------------------------------------------------------
+let b:current_syntax = "gotestexplorer"